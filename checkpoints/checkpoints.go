@@ -0,0 +1,361 @@
+// package checkpoints provides methods for working with security checkpoints at SFO.
+package checkpoints
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"log/slog"
+
+	"github.com/paulmach/orb/geojson"
+	"github.com/sfomuseum/go-edtf/cmp"
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+)
+
+// The default `architecture.ResolutionPolicy` used by the date-based finders which is to return the first (earliest) checkpoint matching a date.
+var DEFAULT_RESOLUTION_POLICY architecture.ResolutionPolicy = architecture.RESOLVE_FIRST_MATCH
+
+// type Checkpoints is a list of `Checkpoint` records that can be sorted in chronological order. See `architecture.CompareDates` for details.
+// Records whose dates can not be distinguished are sorted by their Who's On First IDs.
+type Checkpoints []*Checkpoint
+
+func (c Checkpoints) Len() int {
+	return len(c)
+}
+
+func (c Checkpoints) Less(i, j int) bool {
+
+	cmp := architecture.CompareDates(c[i].Inception, c[i].Cessation, c[j].Inception, c[j].Cessation)
+
+	if cmp != 0 {
+		return cmp < 0
+	}
+
+	return c[i].WhosOnFirstId < c[j].WhosOnFirstId
+}
+
+func (c Checkpoints) Swap(i, j int) {
+	c[i], c[j] = c[j], c[i]
+}
+
+// type Checkpoint is a struct representing a security checkpoint at SFO.
+type Checkpoint struct {
+	// The Who's On First ID associated with this checkpoint.
+	WhosOnFirstId int64 `json:"wof:id"`
+	// The name of this checkpoint, for example "Checkpoint 3".
+	Name string `json:"wof:name"`
+	// The SFO identifier for this checkpoint.
+	SFOId string `json:"sfo:id,omitempty"`
+	// A Who's On First "existential" (`KnownUnknownFlag`) flag signaling the checkpoint's status
+	IsCurrent int64 `json:"mz:is_current"`
+	// The (EDTF) inception date for the checkpoint
+	Inception string `json:"edtf:inception"`
+	// The (EDTF) cessation date for the checkpoint
+	Cessation string `json:"edtf:cessation"`
+	// The Who's On First ID of the checkpoint's parent (typically a common area).
+	ParentId int64 `json:"wof:parent_id,omitempty"`
+	// The Who's On First ID of the terminal the checkpoint belongs to.
+	TerminalId int64 `json:"terminal_id,omitempty"`
+	// The list of Who's On First IDs that this checkpoint supersedes.
+	Supersedes []int64 `json:"wof:supersedes,omitempty"`
+	// The list of Who's On First IDs that this checkpoint is superseded by.
+	SupersededBy []int64 `json:"wof:superseded_by,omitempty"`
+	// The (EDTF) deprecated date for the checkpoint, if it has been deprecated.
+	Deprecated string `json:"edtf:deprecated,omitempty"`
+	// The Who's On First GeoJSON Feature for the checkpoint, including its geometry and all of its properties. It is only populated by
+	// lookups that load records on demand, for example `ReaderCheckpointsLookup`, and is never encoded.
+	Feature *geojson.Feature `json:"-"`
+}
+
+// String() will return the name of the checkpoint.
+func (cp *Checkpoint) String() string {
+	return fmt.Sprintf("%d %s %s-%s (%d)", cp.WhosOnFirstId, cp.Name, cp.Inception, cp.Cessation, cp.IsCurrent)
+}
+
+// Code returns the primary code for the checkpoint which is its name (label).
+func (cp *Checkpoint) Code() string {
+	return cp.Name
+}
+
+// Dates returns the (EDTF) inception and cessation dates for the checkpoint.
+func (cp *Checkpoint) Dates() (string, string) {
+	return cp.Inception, cp.Cessation
+}
+
+// Id returns the Who's On First ID for the checkpoint.
+func (cp *Checkpoint) Id() int64 {
+	return cp.WhosOnFirstId
+}
+
+// Placetype returns the SFO Museum placetype for the checkpoint which is always `PLACETYPE`.
+func (cp *Checkpoint) Placetype() string {
+	return PLACETYPE
+}
+
+// Status returns whether the checkpoint is current, superseded or deprecated.
+func (cp *Checkpoint) Status() *architecture.Status {
+	return architecture.NewStatus(cp.IsCurrent, cp.SupersededBy, cp.Deprecated)
+}
+
+// Return the Checkpoint matching 'code' that was active for 'date'. Multiple matches throw an error.
+func FindCheckpointForDate(ctx context.Context, code string, date string) (*Checkpoint, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return FindCheckpointForDateWithTypedLookup(ctx, lookup, code, date)
+}
+
+// Return all the Checkpoints matching 'code' that were active for 'date'.
+func FindAllCheckpointsForDate(ctx context.Context, code string, date string) ([]*Checkpoint, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return FindAllCheckpointsForDateWithTypedLookup(ctx, lookup, code, date)
+}
+
+// Return all the Checkpoints matching 'code' that existed at any point between 'start' and 'end'. See `CheckpointsLookup.FindAllForRange` for details.
+func FindAllCheckpointsForRange(ctx context.Context, code string, start string, end string) ([]*Checkpoint, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.FindAllForRange(ctx, code, start, end)
+}
+
+// SnapshotForDate returns a snapshot of all the Checkpoints that were active on 'date' keyed by their codes. See `CheckpointsLookup.SnapshotForDate` for details.
+func SnapshotForDate(ctx context.Context, date string) (*architecture.Snapshot[*Checkpoint], error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.SnapshotForDate(ctx, date)
+}
+
+// Suggest returns a ranked list of checkpoint codes that are similar to 'code'. See `CheckpointsLookup.Suggest` for details.
+func Suggest(ctx context.Context, code string) ([]*architecture.Suggestion, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Suggest(ctx, code)
+}
+
+// Codes returns the sorted list of (primary) codes for all the Checkpoints matching 'filter'. See `CheckpointsLookup.Codes` for details.
+func Codes(ctx context.Context, filter *architecture.ListFilter) ([]string, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Codes(ctx, filter)
+}
+
+// All returns an iterator over all the Checkpoints matching 'filter' in chronological order. See `CheckpointsLookup.All` for details.
+func All(ctx context.Context, filter *architecture.ListFilter) (iter.Seq[*Checkpoint], error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.All(ctx, filter)
+}
+
+// Len returns the total number of Checkpoints.
+func Len(ctx context.Context) (int, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return 0, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Len(ctx)
+}
+
+// Return the current Checkpoint matching 'code'. Multiple matches throw an error.
+func FindCurrentCheckpoint(ctx context.Context, code string) (*Checkpoint, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return FindCurrentCheckpointWithTypedLookup(ctx, lookup, code)
+}
+
+// Return the current Checkpoint matching 'code' with a custom architecture.Lookup instance. Multiple matches throw an error.
+func FindCurrentCheckpointWithLookup(ctx context.Context, lookup architecture.Lookup, code string) (*Checkpoint, error) {
+	return FindCurrentCheckpointWithTypedLookup(ctx, architecture.NewTypedLookup[*Checkpoint](lookup), code)
+}
+
+// FindCurrentCheckpointWithTypedLookup is the same as `FindCurrentCheckpointWithLookup` but with a custom architecture.TypedLookup[*Checkpoint] instance.
+func FindCurrentCheckpointWithTypedLookup(ctx context.Context, lookup architecture.TypedLookup[*Checkpoint], code string) (*Checkpoint, error) {
+
+	current, err := FindCheckpointsCurrentWithTypedLookup(ctx, lookup, code)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(current) {
+	case 0:
+		return nil, NotFound{Code: code, Reason: architecture.REASON_NO_CURRENT}
+	case 1:
+		return current[0], nil
+	default:
+		return nil, MultipleCandidates{Code: code, Reason: architecture.REASON_MULTIPLE_CURRENT, Candidates: current}
+	}
+
+}
+
+// Returns all Checkpoint instances matching 'code' that are marked as current.
+func FindCheckpointsCurrent(ctx context.Context, code string) ([]*Checkpoint, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return FindCheckpointsCurrentWithTypedLookup(ctx, lookup, code)
+}
+
+// Returns all Checkpoint instances matching 'code' that are marked as current with a custom architecture.Lookup instance.
+func FindCheckpointsCurrentWithLookup(ctx context.Context, lookup architecture.Lookup, code string) ([]*Checkpoint, error) {
+	return FindCheckpointsCurrentWithTypedLookup(ctx, architecture.NewTypedLookup[*Checkpoint](lookup), code)
+}
+
+// FindCheckpointsCurrentWithTypedLookup is the same as `FindCheckpointsCurrentWithLookup` but with a custom architecture.TypedLookup[*Checkpoint] instance.
+func FindCheckpointsCurrentWithTypedLookup(ctx context.Context, lookup architecture.TypedLookup[*Checkpoint], code string) ([]*Checkpoint, error) {
+
+	rsp, err := lookup.Find(ctx, code)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find checkpoint '%s', %w", code, err)
+	}
+
+	current := make([]*Checkpoint, 0)
+
+	for _, cp := range rsp {
+
+		// if cp.IsCurrent == 0 {
+		if cp.IsCurrent != 1 {
+			continue
+		}
+
+		current = append(current, cp)
+	}
+
+	return current, nil
+}
+
+// Return the Checkpoint matching 'code' that was active for 'date' using the architecture.Lookup instance 'lookup' and `DEFAULT_RESOLUTION_POLICY`. Multiple matches throw an error.
+func FindCheckpointForDateWithLookup(ctx context.Context, lookup architecture.Lookup, code string, date string) (*Checkpoint, error) {
+	return FindCheckpointForDateWithTypedLookup(ctx, architecture.NewTypedLookup[*Checkpoint](lookup), code, date)
+}
+
+// FindCheckpointForDateWithTypedLookup is the same as `FindCheckpointForDateWithLookup` but with a custom architecture.TypedLookup[*Checkpoint] instance.
+func FindCheckpointForDateWithTypedLookup(ctx context.Context, lookup architecture.TypedLookup[*Checkpoint], code string, date string) (*Checkpoint, error) {
+	return FindCheckpointForDateWithPolicy(ctx, lookup, code, date, DEFAULT_RESOLUTION_POLICY)
+}
+
+// Return the Checkpoint matching 'code' that was active for 'date' using 'lookup' and 'policy'. Multiple matches throw an error.
+func FindCheckpointForDateWithPolicy(ctx context.Context, lookup architecture.TypedLookup[*Checkpoint], code string, date string, policy architecture.ResolutionPolicy) (*Checkpoint, error) {
+
+	checkpoints, err := FindAllCheckpointsForDateWithPolicy(ctx, lookup, code, date, policy)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(checkpoints) {
+	case 0:
+		return nil, NotFound{Code: code, Date: date, Reason: architecture.REASON_NO_DATE_MATCH}
+	case 1:
+		return checkpoints[0], nil
+	default:
+		return nil, MultipleCandidates{Code: code, Date: date, Reason: architecture.REASON_MULTIPLE_DATE_MATCH, Candidates: checkpoints}
+	}
+}
+
+// Return all the Checkpoints matching 'code' that were active for 'date' using the architecture.Lookup instance 'lookup' and `DEFAULT_RESOLUTION_POLICY`.
+func FindAllCheckpointsForDateWithLookup(ctx context.Context, lookup architecture.Lookup, code string, date string) ([]*Checkpoint, error) {
+	return FindAllCheckpointsForDateWithTypedLookup(ctx, architecture.NewTypedLookup[*Checkpoint](lookup), code, date)
+}
+
+// FindAllCheckpointsForDateWithTypedLookup is the same as `FindAllCheckpointsForDateWithLookup` but with a custom architecture.TypedLookup[*Checkpoint] instance.
+func FindAllCheckpointsForDateWithTypedLookup(ctx context.Context, lookup architecture.TypedLookup[*Checkpoint], code string, date string) ([]*Checkpoint, error) {
+	return FindAllCheckpointsForDateWithPolicy(ctx, lookup, code, date, DEFAULT_RESOLUTION_POLICY)
+}
+
+// Return all the Checkpoints matching 'code' that were active for 'date' using 'lookup', choosing between multiple matches using 'policy'.
+// See `architecture.ResolutionPolicy` for details.
+func FindAllCheckpointsForDateWithPolicy(ctx context.Context, lookup architecture.TypedLookup[*Checkpoint], code string, date string, policy architecture.ResolutionPolicy) ([]*Checkpoint, error) {
+
+	rsp, err := lookup.Find(ctx, code)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find checkpoints for code, %w", err)
+	}
+
+	checkpoints := make([]*Checkpoint, 0)
+
+	for _, cp := range rsp {
+
+		inception := cp.Inception
+		cessation := cp.Cessation
+
+		is_between, err := cmp.IsBetween(date, inception, cessation)
+
+		if err != nil {
+			slog.Debug("Failed to determine whether checkpoint matches date conditions", "code", code, "date", date, "checkpoint", cp.Name, "inception", inception, "cessation", cessation, "error", err)
+			continue
+		}
+
+		if !is_between {
+			slog.Debug("Checkpoint does not match date conditions", "id", cp.WhosOnFirstId, "code", code, "date", date, "checkpoint", cp.Name, "inception", inception, "cessation", cessation)
+			continue
+		}
+
+		slog.Debug("Checkpoint DOES match date conditions", "id", cp.WhosOnFirstId, "code", code, "date", date, "checkpoint", cp.Name, "inception", inception, "cessation", cessation)
+		checkpoints = append(checkpoints, cp)
+	}
+
+	checkpoints = architecture.ApplyResolutionPolicy(policy, date, checkpoints, resolutionCandidate)
+
+	slog.Debug("Return checkpoints", "code", code, "date", date, "policy", policy, "count", len(checkpoints))
+	return checkpoints, nil
+}
+
+func resolutionCandidate(cp *Checkpoint) *architecture.ResolutionCandidate {
+
+	c := &architecture.ResolutionCandidate{
+		IsCurrent: cp.IsCurrent,
+		Inception: cp.Inception,
+		Cessation: cp.Cessation,
+	}
+
+	return c
+}
@@ -0,0 +1,147 @@
+package checkpoints
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture/internal/testutil"
+)
+
+func TestCheckpointsLookupEmbedded(t *testing.T) {
+
+	ctx := context.Background()
+
+	lu, err := NewCheckpointsLookup(ctx, "checkpoints://")
+
+	if err != nil {
+		t.Fatalf("Failed to create embedded lookup, %v", err)
+	}
+
+	count, err := lu.Len(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to count checkpoints in embedded data, %v", err)
+	}
+
+	if count == 0 {
+		t.Fatalf("Embedded checkpoints data is empty, it needs to be compiled with 'make compile-checkpoints'")
+	}
+
+	all, err := lu.All(ctx, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to list checkpoints in embedded data, %v", err)
+	}
+
+	for cp := range all {
+
+		rsp, err := lu.Find(ctx, cp.Code())
+
+		if err != nil {
+			t.Fatalf("Failed to find checkpoint %d by its code (%s), %v", cp.WhosOnFirstId, cp.Code(), err)
+		}
+
+		found := false
+
+		for _, r := range rsp {
+
+			if r.WhosOnFirstId == cp.WhosOnFirstId {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			t.Fatalf("Expected to find checkpoint %d by its code (%s)", cp.WhosOnFirstId, cp.Code())
+		}
+	}
+}
+
+func TestFindCheckpointWithLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	checkpoints_list := []*Checkpoint{
+		&Checkpoint{WhosOnFirstId: 1000000001, Name: "Checkpoint 3", SFOId: "SC3", IsCurrent: 0, Inception: "2000", Cessation: "2010"},
+		&Checkpoint{WhosOnFirstId: 1000000002, Name: "Checkpoint 3", SFOId: "SC3", IsCurrent: 1, Inception: "2010", Cessation: ".."},
+		&Checkpoint{WhosOnFirstId: 1000000003, Name: "Checkpoint 4", SFOId: "SC4", IsCurrent: 1, Inception: "2010", Cessation: ".."},
+	}
+
+	lookup, err := NewCheckpointsLookupWithLookupFunc(ctx, NewLookupFuncWithCheckpoints(ctx, checkpoints_list))
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	for _, code := range []string{"Checkpoint 3", "checkpoint 3", "SC3"} {
+
+		cp, err := FindCurrentCheckpointWithTypedLookup(ctx, lookup, code)
+
+		if err != nil {
+			t.Fatalf("Failed to find current checkpoint for %s, %v", code, err)
+		}
+
+		if cp.WhosOnFirstId != 1000000002 {
+			t.Fatalf("Unexpected current checkpoint for %s, %d", code, cp.WhosOnFirstId)
+		}
+	}
+
+	cp, err := FindCheckpointForDateWithTypedLookup(ctx, lookup, "Checkpoint 3", "2005-06-01")
+
+	if err != nil {
+		t.Fatalf("Failed to find checkpoint for date, %v", err)
+	}
+
+	if cp.WhosOnFirstId != 1000000001 {
+		t.Fatalf("Unexpected checkpoint for date, %d", cp.WhosOnFirstId)
+	}
+
+	_, err = FindCheckpointForDateWithTypedLookup(ctx, lookup, "Checkpoint 4", "2005-06-01")
+
+	if !IsNotFound(err) {
+		t.Fatalf("Expected no checkpoint 4 for date, got %v", err)
+	}
+}
+
+func TestCompileCheckpointsData(t *testing.T) {
+
+	ctx := context.Background()
+
+	features := map[string]string{
+		"1000000001.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000001, "wof:name": "Checkpoint 3", "sfo:id": "SC3", "sfomuseum:placetype": "checkpoint", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+		"1000000002.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000002, "wof:name": "Checkpoint 4", "sfomuseum:placetype": "checkpoint", "mz:is_current": 0, "edtf:inception": "2000", "edtf:cessation": "2010"}),
+	}
+
+	root := testutil.NewDirectoryData(t, features)
+
+	q := url.Values{}
+	q.Set("uri", "directory://")
+	q.Set("source", root)
+
+	lu, err := NewCheckpointsLookup(ctx, "checkpoints://iterator?"+q.Encode())
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup from iterator, %v", err)
+	}
+
+	rsp, err := lu.Find(ctx, "SC3")
+
+	if err != nil {
+		t.Fatalf("Failed to find SC3, %v", err)
+	}
+
+	if len(rsp) != 1 || rsp[0].WhosOnFirstId != 1000000001 || rsp[0].Name != "Checkpoint 3" {
+		t.Fatalf("Unexpected results for SC3, %v", rsp)
+	}
+
+	count, err := lu.Len(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to count checkpoints, %v", err)
+	}
+
+	if count != 2 {
+		t.Fatalf("Unexpected number of checkpoints, %d", count)
+	}
+}
@@ -0,0 +1,137 @@
+package checkpoints
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+	"github.com/tidwall/gjson"
+	"github.com/whosonfirst/go-whosonfirst-feature/properties"
+	"github.com/whosonfirst/go-whosonfirst-iterate/v2/iterator"
+	"github.com/whosonfirst/go-whosonfirst-uri"
+)
+
+// CompileCheckpointsData will generate a list of `Checkpoint` struct to be used as the source data for an `SFOMuseumLookup` instance.
+// The list of checkpoint are compiled by iterating over one or more source. `iterator_uri` is a valid `whosonfirst/go-whosonfirst-iterate` URI
+// and `iterator_sources` are one more (iterator) URIs to process.
+func CompileCheckpointsData(ctx context.Context, iterator_uri string, iterator_sources ...string) ([]*Checkpoint, error) {
+
+	lookup := make([]*Checkpoint, 0)
+	mu := new(sync.RWMutex)
+
+	iter_cb := func(ctx context.Context, path string, fh io.ReadSeeker, args ...interface{}) error {
+
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+			// pass
+		}
+
+		if strings.HasSuffix(path, "~") {
+			return nil
+		}
+
+		_, uri_args, err := uri.ParseURI(path)
+
+		if err != nil {
+			return fmt.Errorf("Failed to parse %s, %w", path, err)
+		}
+
+		if uri_args.IsAlternate {
+			return nil
+		}
+
+		body, err := io.ReadAll(fh)
+
+		if err != nil {
+			return fmt.Errorf("Failed to read %s, %w", path, err)
+		}
+
+		cp, err := newCheckpointFromFeature(body)
+
+		if err != nil {
+			return fmt.Errorf("Failed to derive checkpoint from %s, %w", path, err)
+		}
+
+		mu.Lock()
+		lookup = append(lookup, cp)
+		mu.Unlock()
+
+		return nil
+	}
+
+	iter, err := iterator.NewIterator(ctx, iterator_uri, iter_cb)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create iterator, %w", err)
+	}
+
+	err = iter.IterateURIs(ctx, iterator_sources...)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to iterate sources, %w", err)
+	}
+
+	return lookup, nil
+}
+
+// newCheckpointFromFeature returns a new `Checkpoint` instance derived from the Who's On First GeoJSON Feature 'body'.
+func newCheckpointFromFeature(body []byte) (*Checkpoint, error) {
+
+	wof_id, err := properties.Id(body)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive ID, %w", err)
+	}
+
+	wof_name, err := properties.Name(body)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive name, %w", err)
+	}
+
+	fl, err := properties.IsCurrent(body)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to determine is current, %w", err)
+	}
+
+	sfoid_rsp := gjson.GetBytes(body, "properties.sfo:id")
+
+	inception := properties.Inception(body)
+	cessation := properties.Cessation(body)
+
+	parents := architecture.DeriveParents(body)
+
+	cp := &Checkpoint{
+		WhosOnFirstId: wof_id,
+		Name:          wof_name,
+		SFOId:         sfoid_rsp.String(),
+		IsCurrent:     fl.Flag(),
+		Inception:     inception,
+		Cessation:     cessation,
+		ParentId:      parents.ParentId,
+		TerminalId:    parents.TerminalId,
+		Supersedes:    properties.Supersedes(body),
+		SupersededBy:  properties.SupersededBy(body),
+		Deprecated:    properties.Deprecated(body),
+	}
+
+	return cp, nil
+}
+
+// newCheckpointRecord derives a `Checkpoint` record from 'body' for use as an `architecture.FeatureRecordFunc`.
+func newCheckpointRecord(ctx context.Context, body []byte) (interface{}, error) {
+
+	r, err := newCheckpointFromFeature(body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
@@ -0,0 +1,57 @@
+package checkpoints
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+)
+
+func lineageNode(cp *Checkpoint) *architecture.LineageNode {
+
+	n := &architecture.LineageNode{
+		Id:           cp.WhosOnFirstId,
+		Supersedes:   cp.Supersedes,
+		SupersededBy: cp.SupersededBy,
+		Inception:    cp.Inception,
+		Cessation:    cp.Cessation,
+	}
+
+	return n
+}
+
+// Lineage returns the supersession chain, in date order, for the checkpoint with Who's On First ID 'id'. See `architecture.TableLookup.Lineage` for details.
+func Lineage(ctx context.Context, id int64) (*architecture.Lineage[*Checkpoint], error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Lineage(ctx, id)
+}
+
+// Predecessors returns all the checkpoints, in date order, that the checkpoint with Who's On First ID 'id' supersedes directly or indirectly.
+func Predecessors(ctx context.Context, id int64) ([]*Checkpoint, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Predecessors(ctx, id)
+}
+
+// Successors returns all the checkpoints, in date order, that supersede the checkpoint with Who's On First ID 'id' directly or indirectly.
+func Successors(ctx context.Context, id int64) ([]*Checkpoint, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Successors(ctx, id)
+}
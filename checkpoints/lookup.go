@@ -0,0 +1,420 @@
+package checkpoints
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/paulmach/orb/geojson"
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+	"github.com/sfomuseum/go-sfomuseum-architecture/data"
+)
+
+// The SFO Museum placetype for checkpoints.
+const PLACETYPE string = "checkpoint"
+
+// The name of the compiled data file, in the `architecture.DataEnvelope` format.
+const DATA_JSON string = "checkpoints.json"
+
+// The name of the gzip-compressed copy of `DATA_JSON`. This is the only copy that is embedded, and published alongside the `SHA256SUMS`
+// checksum manifest, and the one read by default.
+const DATA_JSON_GZIP string = DATA_JSON + ".gz"
+
+// The maximum edit distance for codes returned by `Suggest`.
+const SUGGEST_MAX_DISTANCE int = 2
+
+var default_lookup *CheckpointsLookup
+var default_lookup_mu = new(sync.Mutex)
+
+// record_kind describes checkpoints to the generic lookups in the architecture package.
+var record_kind = &architecture.RecordKind[*Checkpoint]{
+	Placetype:          PLACETYPE,
+	Label:              "checkpoint",
+	Codes:              possibleCodes,
+	Candidate:          resolutionCandidate,
+	NotFound:           notFound,
+	FromFeature:        newCheckpointFromFeature,
+	WithFeature:        withFeature,
+	LineageNode:        lineageNode,
+	SuggestMaxDistance: SUGGEST_MAX_DISTANCE,
+	SQLiteIndices:      sqlite_indices,
+	SQLiteQueries:      sqlite_queries,
+	SQLiteCodeExpr:     sqlite_code_expr,
+}
+
+// CheckpointsLookupFunc is a function that, when invoked, returns the list of `Checkpoint` records to be used by a `CheckpointsLookup` instance.
+type CheckpointsLookupFunc func(context.Context) ([]*Checkpoint, error)
+
+// CheckpointsLookup implements the `architecture.TypedLookup[*Checkpoint]` interface for checkpoints. Each instance has its own lookup table. See
+// `architecture.TableLookup` for details of the methods used to query, list, change and export the records in the lookup table.
+type CheckpointsLookup struct {
+	*architecture.TableLookup[*Checkpoint]
+}
+
+func init() {
+	ctx := context.Background()
+	architecture.RegisterLookup(ctx, "checkpoints", NewLookup)
+	architecture.RegisterFeatureRecordFunc(ctx, PLACETYPE, "checkpoints", newCheckpointRecord)
+}
+
+// NewLookup will return an `architecture.Lookup` instance. By default the lookup table is derived from precompiled (embedded) gzip-compressed data in `data/checkpoints.json.gz`
+// by passing in `sfomuseum://` as the URI. It is also possible to create a new lookup table with the following URI options:
+//
+//	`sfomuseum://github`
+//
+// This will cause the lookup table to be derived from the data stored at https://raw.githubusercontent.com/sfomuseum/go-sfomuseum-architecture/main/data/checkpoints.json.gz. This might be desirable if there have been updates to the underlying data that are not reflected in the locally installed package's pre-compiled data.
+//
+//	`sfomuseum://remote?url={URL}`
+//
+// This will cause the lookup table to be derived from the data stored at `{URL}/checkpoints.json.gz`. Both the `github` and `remote` options verify the data against the `SHA256SUMS` checksum manifest published alongside it and accept optional `cache`, `verify`, `fallback` and `timeout` parameters. See `architecture.NewRemoteSourceWithURL` for details.
+//
+//	`sfomuseum://iterator?uri={URI}&source={SOURCE}`
+//
+// This will cause the lookup table to be derived, at runtime, from data emitted by a `whosonfirst/go-whosonfirst-iterate` instance. `{URI}` should be a valid `whosonfirst/go-whosonfirst-iterate/iterator` URI and `{SOURCE}` is one or more URIs for the iterator to process.
+//
+//	`sfomuseum://file?path={PATH}`
+//
+// This will cause the lookup table to be derived from the data stored in the local file `{PATH}`. It is assumed that the data in `{PATH}` will be formatted in the same way as the precompiled (embedded) data. This might be desirable if you want to pin a specific release of the data.
+//
+//	`sfomuseum://sqlite?dsn={DSN}`
+//
+// This will cause checkpoints to be queried, as needed, from the Who's On First SQLite database `{DSN}` rather than being loaded in to memory. See `NewSQLiteCheckpointsLookup` for details.
+//
+//	`sfomuseum://reader?reader={READER_URI}`
+//
+// This will cause checkpoints to be read, as needed, from the `whosonfirst/go-reader` URI `{READER_URI}` and cached, keeping only an index of their codes in memory. See `NewReaderCheckpointsLookup` for details.
+func NewLookup(ctx context.Context, uri string) (architecture.Lookup, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	switch u.Host {
+	case "sqlite":
+
+		l, err := NewSQLiteCheckpointsLookup(ctx, uri)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return architecture.NewUntypedLookup[*Checkpoint](l), nil
+
+	case "reader":
+
+		l, err := NewReaderCheckpointsLookup(ctx, uri)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return architecture.NewUntypedLookup[*Checkpoint](l), nil
+	}
+
+	l, err := NewCheckpointsLookup(ctx, uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return architecture.NewUntypedLookup[*Checkpoint](l), nil
+}
+
+// NewCheckpointsLookup will return a `CheckpointsLookup` instance derived from 'uri'. See `NewLookup` for details on the URI options.
+func NewCheckpointsLookup(ctx context.Context, uri string) (*CheckpointsLookup, error) {
+
+	lookup_func, err := NewLookupFuncWithURI(ctx, uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCheckpointsLookupWithLookupFunc(ctx, lookup_func)
+}
+
+// NewCheckpointsLookupWithFS will return a `CheckpointsLookup` instance derived from the data stored in 'filename' in 'fsys'. See `NewLookupFuncWithFS` for details.
+func NewCheckpointsLookupWithFS(ctx context.Context, fsys fs.FS, filename string) (*CheckpointsLookup, error) {
+
+	lookup_func, err := NewLookupFuncWithFS(ctx, fsys, filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCheckpointsLookupWithLookupFunc(ctx, lookup_func)
+}
+
+// NewLookupFuncWithURI will return a `CheckpointsLookupFunc` function instance derived from 'uri'. See `NewLookup` for details on the URI options.
+func NewLookupFuncWithURI(ctx context.Context, uri string) (CheckpointsLookupFunc, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	// Reminder: u.Scheme is used by the architecture.Lookup constructor
+
+	switch u.Host {
+	case "iterator":
+
+		q := u.Query()
+
+		iterator_uri := q.Get("uri")
+		iterator_sources := q["source"]
+
+		checkpoints_list, err := CompileCheckpointsData(ctx, iterator_uri, iterator_sources...)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to compile checkpoints data, %w", err)
+		}
+
+		return NewLookupFuncWithCheckpoints(ctx, checkpoints_list), nil
+
+	case "github", "remote":
+
+		src, err := architecture.NewRemoteSourceWithURL(u, data.FS)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create remote source, %w", err)
+		}
+
+		fh, err := src.Open(ctx, DATA_JSON_GZIP)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load remote data, %w", err)
+		}
+
+		return NewLookupFuncWithReader(ctx, fh), nil
+
+	case "file":
+
+		path := u.Query().Get("path")
+
+		if path == "" {
+			return nil, fmt.Errorf("Missing ?path= parameter")
+		}
+
+		return NewLookupFuncWithFS(ctx, os.DirFS(filepath.Dir(path)), filepath.Base(path))
+
+	case "sqlite":
+
+		return nil, fmt.Errorf("The sqlite host can not be used to derive a lookup table, use NewSQLiteCheckpointsLookup instead")
+
+	case "reader":
+
+		return nil, fmt.Errorf("The reader host can not be used to derive a lookup table, use NewReaderCheckpointsLookup instead")
+
+	default:
+
+		lookup_func, err := NewLookupFuncWithFS(ctx, data.FS, DATA_JSON_GZIP)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load local precompiled data, %w", err)
+		}
+
+		return lookup_func, nil
+	}
+}
+
+// NewLookupFuncWithFS will return a `CheckpointsLookupFunc` function instance that, when invoked, will populate an `architecture.Lookup` instance with data stored in
+// 'filename' in 'fsys'. It is assumed that the data in 'filename' will be formatted in the same way as the precompiled (embedded) data stored in `data/checkpoints.json.gz`, with or without gzip compression.
+func NewLookupFuncWithFS(ctx context.Context, fsys fs.FS, filename string) (CheckpointsLookupFunc, error) {
+
+	fh, err := fsys.Open(filename)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open %s, %w", filename, err)
+	}
+
+	return NewLookupFuncWithReader(ctx, fh), nil
+}
+
+// NewLookupWithReader will return an `CheckpointsLookupFunc` function instance that, when invoked, will populate an `architecture.Lookup` instance with data stored in `r`.
+// `r` will be closed when the `CheckpointsLookupFunc` function instance is invoked.
+// It is assumed that the data in `r` will be formatted in the same way as the procompiled (embedded) data stored in `data/checkpoints.json.gz`, with or without gzip compression. Both the versioned
+// `architecture.DataEnvelope` format and the legacy bare JSON array format are supported. See `architecture.DecodeData` for details.
+func NewLookupFuncWithReader(ctx context.Context, r io.ReadCloser) CheckpointsLookupFunc {
+
+	defer r.Close()
+
+	checkpoints_list, _, err := architecture.DecodeData[*Checkpoint](r, PLACETYPE)
+
+	if err != nil {
+
+		lookup_func := func(ctx context.Context) ([]*Checkpoint, error) {
+			return nil, fmt.Errorf("Failed to decode data, %w", err)
+		}
+
+		return lookup_func
+	}
+
+	return NewLookupFuncWithCheckpoints(ctx, checkpoints_list)
+}
+
+// NewLookupFuncWithCheckpoints will return an `CheckpointsLookupFunc` function instance that, when invoked, will populate an `architecture.Lookup` instance with data stored in `checkpoints_list`.
+func NewLookupFuncWithCheckpoints(ctx context.Context, checkpoints_list []*Checkpoint) CheckpointsLookupFunc {
+
+	lookup_func := func(ctx context.Context) ([]*Checkpoint, error) {
+		return checkpoints_list, nil
+	}
+
+	return lookup_func
+}
+
+// NewLookupWithLookupFunc will return an `architecture.Lookup` instance derived by data compiled using `lookup_func`.
+// Each call to `lookup_func` produces a new lookup table so the instances returned by this method do not share any data.
+func NewLookupWithLookupFunc(ctx context.Context, lookup_func CheckpointsLookupFunc) (architecture.Lookup, error) {
+
+	l, err := NewCheckpointsLookupWithLookupFunc(ctx, lookup_func)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return architecture.NewUntypedLookup[*Checkpoint](l), nil
+}
+
+// NewCheckpointsLookupWithLookupFunc will return a `CheckpointsLookup` instance derived by data compiled using `lookup_func`.
+func NewCheckpointsLookupWithLookupFunc(ctx context.Context, lookup_func CheckpointsLookupFunc) (*CheckpointsLookup, error) {
+
+	checkpoints_list, err := lookup_func(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := architecture.NewTableLookup(ctx, record_kind, checkpoints_list)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := &CheckpointsLookup{
+		TableLookup: table,
+	}
+
+	return l, nil
+}
+
+// defaultLookup returns a package-level `CheckpointsLookup` instance derived from the precompiled (embedded) data. It is created lazily the first
+// time it is needed with a background context, rather than the context of the first caller which may be cancelled while it is still
+// being used, and a failure to create it is not retained so that it is attempted again by the next caller.
+func defaultLookup() (*CheckpointsLookup, error) {
+
+	default_lookup_mu.Lock()
+	defer default_lookup_mu.Unlock()
+
+	if default_lookup != nil {
+		return default_lookup, nil
+	}
+
+	lookup, err := NewCheckpointsLookup(context.Background(), "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	default_lookup = lookup
+	return default_lookup, nil
+}
+
+// Reload replaces the data used by the package-level `Find*` methods with data derived from 'uri'. See `NewLookup` for details on the URI options.
+func Reload(ctx context.Context, uri string) error {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return err
+	}
+
+	return lookup.Reload(ctx, uri)
+}
+
+// NewLookupFromIterator will return an `architecture.Lookup` instance derived from data compiled by `CompileCheckpointsData`.
+func NewLookupFromIterator(ctx context.Context, iterator_uri string, iterator_sources ...string) (architecture.Lookup, error) {
+
+	l, err := NewCheckpointsLookupFromIterator(ctx, iterator_uri, iterator_sources...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return architecture.NewUntypedLookup[*Checkpoint](l), nil
+}
+
+// NewCheckpointsLookupFromIterator will return a `CheckpointsLookup` instance derived from data compiled by `CompileCheckpointsData`.
+func NewCheckpointsLookupFromIterator(ctx context.Context, iterator_uri string, iterator_sources ...string) (*CheckpointsLookup, error) {
+
+	checkpoints_list, err := CompileCheckpointsData(ctx, iterator_uri, iterator_sources...)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to compile checkpoints data, %w", err)
+	}
+
+	lookup_func := NewLookupFuncWithCheckpoints(ctx, checkpoints_list)
+	return NewCheckpointsLookupWithLookupFunc(ctx, lookup_func)
+}
+
+// Reload replaces the lookup table with a new table derived from 'uri'. See `NewLookup` for details on the URI options.
+// The new table is built in full before it replaces the current table so calls to `Find` will see either the old data or the
+// new data but never a mix of both. If the new table can not be built the current table is left in place and an error is returned.
+// Any changes made to the table (for example with `Append`) while the new table is being built are lost.
+func (l *CheckpointsLookup) Reload(ctx context.Context, uri string) error {
+
+	lookup_func, err := NewLookupFuncWithURI(ctx, uri)
+
+	if err != nil {
+		return fmt.Errorf("Failed to derive lookup function, %w", err)
+	}
+
+	return l.ReloadWithLookupFunc(ctx, lookup_func)
+}
+
+// ReloadWithLookupFunc replaces the lookup table with a new table derived from data compiled using `lookup_func`.
+func (l *CheckpointsLookup) ReloadWithLookupFunc(ctx context.Context, lookup_func CheckpointsLookupFunc) error {
+
+	checkpoints_list, err := lookup_func(ctx)
+
+	if err != nil {
+		return fmt.Errorf("Failed to reload lookup table, %w", err)
+	}
+
+	err = l.Replace(ctx, checkpoints_list)
+
+	if err != nil {
+		return fmt.Errorf("Failed to reload lookup table, %w", err)
+	}
+
+	return nil
+}
+
+// possibleCodes returns the list of codes that 'data' can be found by.
+func possibleCodes(data *Checkpoint) []string {
+
+	str_wofid := strconv.FormatInt(data.WhosOnFirstId, 10)
+
+	possible_codes := []string{
+		data.Name,
+		data.SFOId,
+		str_wofid,
+	}
+
+	return possible_codes
+}
+
+func notFound(code string, reason string) error {
+	return NotFound{Code: code, Reason: reason}
+}
+
+func withFeature(cp *Checkpoint, f *geojson.Feature) {
+	cp.Feature = f
+}
@@ -0,0 +1,104 @@
+package checkpoints
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture/internal/testutil"
+)
+
+func TestCheckpointsLookupSFOId(t *testing.T) {
+
+	ctx := context.Background()
+
+	lookup_func := NewLookupFuncWithCheckpoints(ctx, []*Checkpoint{
+		&Checkpoint{WhosOnFirstId: 1000000001, Name: "Checkpoint 3", SFOId: "SC3", Inception: "2000", Cessation: "2010"},
+		&Checkpoint{WhosOnFirstId: 1000000002, Name: "Checkpoint 4", SFOId: "SC4", Inception: "2005", Cessation: ".."},
+	})
+
+	lu, err := NewCheckpointsLookupWithLookupFunc(ctx, lookup_func)
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	rsp, err := lu.Find(ctx, "SC3")
+
+	if err != nil || len(rsp) != 1 || rsp[0].WhosOnFirstId != 1000000001 {
+		t.Fatalf("Failed to find checkpoint by its SFO ID, %v", err)
+	}
+
+	// Updating a checkpoint's SFO ID should remove its old SFO ID from the lookup table
+
+	err = lu.Update(ctx, &Checkpoint{WhosOnFirstId: 1000000001, Name: "Checkpoint 3", SFOId: "SC9", Inception: "2000", Cessation: "2010"})
+
+	if err != nil {
+		t.Fatalf("Failed to update checkpoint, %v", err)
+	}
+
+	_, err = lu.Find(ctx, "SC3")
+
+	if !IsNotFound(err) {
+		t.Fatalf("Expected old SFO ID to be removed by update, got %v", err)
+	}
+
+	rsp, err = lu.Find(ctx, "SC9")
+
+	if err != nil || len(rsp) != 1 || rsp[0].WhosOnFirstId != 1000000001 {
+		t.Fatalf("Failed to find updated checkpoint, %v", err)
+	}
+}
+
+func TestSQLiteCheckpointsLookupSFOId(t *testing.T) {
+
+	ctx := context.Background()
+
+	features := map[string]string{
+		"1000000001.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000001, "wof:name": "Checkpoint 3", "sfo:id": "SC3", "sfomuseum:placetype": "checkpoint", "mz:is_current": 0, "edtf:inception": "2000", "edtf:cessation": "2010"}),
+		"1000000002.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000002, "wof:name": "Checkpoint 3", "sfo:id": "SC3", "sfomuseum:placetype": "checkpoint", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+		"1000000003.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000003, "wof:name": "Checkpoint 4", "sfo:id": "SC3", "sfomuseum:placetype": "gate", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+	}
+
+	dsn := testutil.NewSQLiteDatabase(t, features)
+
+	lu, err := NewSQLiteCheckpointsLookup(ctx, "checkpoints://sqlite?dsn="+url.QueryEscape(dsn))
+
+	if err != nil {
+		t.Fatalf("Failed to create SQLite lookup, %v", err)
+	}
+
+	defer lu.Close()
+
+	rsp, err := lu.Find(ctx, "SC3")
+
+	if err != nil {
+		t.Fatalf("Failed to find SC3, %v", err)
+	}
+
+	if len(rsp) != 2 || rsp[0].WhosOnFirstId != 1000000001 || rsp[1].WhosOnFirstId != 1000000002 {
+		t.Fatalf("Unexpected results for SC3, %v", rsp)
+	}
+
+	current, err := FindCurrentCheckpointWithTypedLookup(ctx, lu, "SC3")
+
+	if err != nil {
+		t.Fatalf("Failed to find current checkpoint, %v", err)
+	}
+
+	if current.WhosOnFirstId != 1000000002 {
+		t.Fatalf("Unexpected current checkpoint %d", current.WhosOnFirstId)
+	}
+
+	// Codes are checkpoint names, rather than SFO IDs
+
+	codes, err := lu.Codes(ctx, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to list codes, %v", err)
+	}
+
+	if len(codes) != 1 || codes[0] != "Checkpoint 3" {
+		t.Fatalf("Unexpected codes, %v", codes)
+	}
+}
@@ -0,0 +1,20 @@
+package checkpoints
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+	"github.com/sfomuseum/go-sfomuseum-architecture/terminals"
+)
+
+// Terminal returns the terminal that the checkpoint belonged to, for the period the checkpoint existed, using 'lookup'.
+// See `terminals.FindTerminalForParentWithLookup` for details.
+func (cp *Checkpoint) Terminal(ctx context.Context, lookup architecture.TypedLookup[*terminals.Terminal]) (*terminals.Terminal, error) {
+
+	if cp.TerminalId <= 0 {
+		return nil, fmt.Errorf("Checkpoint %d does not have a terminal ID", cp.WhosOnFirstId)
+	}
+
+	return terminals.FindTerminalForParentWithLookup(ctx, lookup, cp.TerminalId, cp.Inception, cp.Cessation)
+}
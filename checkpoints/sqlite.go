@@ -0,0 +1,71 @@
+package checkpoints
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+)
+
+// The indices, on the `geojson` table, used by `SQLiteCheckpointsLookup` in addition to the placetype index and those created by `campus.NewDatabaseWithIterator`.
+var sqlite_indices = map[string]string{
+	"geojson_by_sfo_id": sqlite_sfo_id_expr,
+}
+
+// The queries used by `SQLiteCheckpointsLookup` to find checkpoints by their Who's On First ID, name or SFO ID.
+var sqlite_queries = []*architecture.SQLiteCodeQuery{
+	architecture.SQLITE_QUERY_BY_ID,
+	architecture.SQLITE_QUERY_BY_NAME,
+	architecture.NewSQLiteCodeQuery("SFO ID", sqlite_sfo_id_expr, false),
+}
+
+const sqlite_sfo_id_expr string = `JSON_EXTRACT(body, '$.properties."sfo:id"')`
+
+// The SQL expression that derives the same value as `Checkpoint.Code`, the checkpoint's name.
+const sqlite_code_expr string = `JSON_EXTRACT(body, '$.properties."wof:name"')`
+
+// SQLiteCheckpointsLookup implements the `architecture.TypedLookup[*Checkpoint]` interface for checkpoints stored in a Who's On First SQLite database,
+// as produced by `campus.NewDatabaseWithIterator`. Records are queried from the database, by their Who's On First ID, name or SFO ID, each time
+// `Find` is called rather than being loaded in to memory ahead of time. The current and date-based finders (for example `FindCurrentCheckpointWithLookup`)
+// filter the records returned by `Find` for a code so they only ever evaluate the handful of records sharing that code. See `architecture.SQLiteLookup`
+// for details.
+type SQLiteCheckpointsLookup struct {
+	*architecture.SQLiteLookup[*Checkpoint]
+}
+
+// NewSQLiteCheckpointsLookup will return a `SQLiteCheckpointsLookup` instance derived from 'uri' which is expected to take the form:
+//
+//	`checkpoints://sqlite?dsn={DSN}`
+//
+// Where `{DSN}` is the data source name of a Who's On First SQLite database. See `architecture.NewSQLiteDatabaseWithURL` for details
+// on the other parameters.
+func NewSQLiteCheckpointsLookup(ctx context.Context, uri string) (*SQLiteCheckpointsLookup, error) {
+
+	sqlite_lookup, err := architecture.NewSQLiteLookup(ctx, record_kind, uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := &SQLiteCheckpointsLookup{
+		SQLiteLookup: sqlite_lookup,
+	}
+
+	return l, nil
+}
+
+// NewSQLiteCheckpointsLookupWithDatabase will return a `SQLiteCheckpointsLookup` instance for 'db'. No indices are created.
+func NewSQLiteCheckpointsLookupWithDatabase(ctx context.Context, db *sql.DB) (*SQLiteCheckpointsLookup, error) {
+
+	sqlite_lookup, err := architecture.NewSQLiteLookupWithDatabase(ctx, record_kind, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := &SQLiteCheckpointsLookup{
+		SQLiteLookup: sqlite_lookup,
+	}
+
+	return l, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"flag"
@@ -8,19 +9,23 @@ import (
 	"io"
 	"log"
 	"os"
+	"strings"
 
+	"github.com/sfomuseum/go-sfomuseum-architecture"
 	"github.com/sfomuseum/go-sfomuseum-architecture/terminals"
 )
 
 func main() {
 
-	default_target := fmt.Sprintf("data/%s", terminals.DATA_JSON)
+	default_target := fmt.Sprintf("data/%s", terminals.DATA_JSON_GZIP)
 
 	iterator_uri := flag.String("iterator-uri", "repo://?include=properties.sfomuseum:placetype=terminal&exclude=properties.edtf:deprecated=.*", "A valid whosonfirst/go-whosonfirst-iterate URI")
 	iterator_source := flag.String("iterator-source", "/usr/local/data/sfomuseum-data-architecture", "The URI containing documents to iterate.")
 
-	target := flag.String("target", default_target, "The path to write SFO Museum terminals data.")
+	target := flag.String("target", default_target, "The path to write SFO Museum terminals data. If the path ends in \".gz\" the data is gzip-compressed.")
 	stdout := flag.Bool("stdout", false, "Emit SFO Museum terminals data to SDOUT.")
+	source_repo := flag.String("source-repo", architecture.DEFAULT_DATA_SOURCE, "The repository the data is compiled from, recorded in the data's metadata.")
+	source_commit := flag.String("source-commit", "", "The commit, in the source repository, the data is compiled from, recorded in the data's metadata.")
 
 	flag.Parse()
 
@@ -28,13 +33,27 @@ func main() {
 
 	writers := make([]io.Writer, 0)
 
-	fh, err := os.OpenFile(*target, os.O_RDWR|os.O_CREATE, 0644)
+	fh, err := os.OpenFile(*target, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
 
 	if err != nil {
 		log.Fatalf("Failed to open '%s', %v", *target, err)
 	}
 
-	writers = append(writers, fh)
+	var gz_wr *gzip.Writer
+
+	if strings.HasSuffix(*target, ".gz") {
+
+		gz_wr, err = gzip.NewWriterLevel(fh, gzip.BestCompression)
+
+		if err != nil {
+			log.Fatalf("Failed to create gzip writer, %v", err)
+		}
+
+		writers = append(writers, gz_wr)
+
+	} else {
+		writers = append(writers, fh)
+	}
 
 	if *stdout {
 		writers = append(writers, os.Stdout)
@@ -48,10 +67,23 @@ func main() {
 		log.Fatalf("Failed to compile terminals data, %v", err)
 	}
 
+	env := architecture.NewDataEnvelope(terminals.PLACETYPE, lookup)
+	env.Source = *source_repo
+	env.Commit = *source_commit
+
 	enc := json.NewEncoder(wr)
-	err = enc.Encode(lookup)
+	err = enc.Encode(env)
 
 	if err != nil {
 		log.Fatalf("Failed to marshal results, %v", err)
 	}
+
+	if gz_wr != nil {
+
+		err = gz_wr.Close()
+
+		if err != nil {
+			log.Fatalf("Failed to close gzip writer, %v", err)
+		}
+	}
 }
@@ -1,8 +1,10 @@
 package main
 
 import (
+	_ "github.com/sfomuseum/go-sfomuseum-architecture/checkpoints"
 	_ "github.com/sfomuseum/go-sfomuseum-architecture/galleries"
 	_ "github.com/sfomuseum/go-sfomuseum-architecture/gates"
+	_ "github.com/sfomuseum/go-sfomuseum-architecture/publicart"
 	_ "github.com/sfomuseum/go-sfomuseum-architecture/terminals"
 )
 
@@ -17,7 +19,7 @@ import (
 
 func main() {
 
-	lookup_uri := flag.String("lookup-uri", "", "Valid options are: gates://, galleries://, terminals://")
+	lookup_uri := flag.String("lookup-uri", "", "Valid options are: checkpoints://, gates://, galleries://, publicart://, terminals://")
 
 	flag.Parse()
 
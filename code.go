@@ -0,0 +1,175 @@
+package architecture
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// The list of (upper-cased) prefixes that are removed from codes by `NormalizeCode`.
+var code_prefixes = []string{
+	"GATE",
+	"GALLERY",
+	"TERMINAL",
+	"CHECKPOINT",
+}
+
+// NormalizeCode returns a normalized version of 'code' suitable for comparing codes typed by hand, from historical documents,
+// with the codes stored in lookup tables. Normalization:
+//
+// * Upper-cases the code.
+// * Removes whitespace and separator characters (`-`, `_`, `.`, `/`).
+// * Removes leading "Gate", "Gallery", "Terminal" or "Checkpoint" prefixes, if they are followed by other characters.
+// * Removes leading zeros from numbers ("A09" becomes "A9").
+//
+// For example "a9", "Gate A9", "A09" and "A-9" are all normalized to "A9".
+func NormalizeCode(code string) string {
+
+	tokens := strings.FieldsFunc(strings.ToUpper(code), func(r rune) bool {
+
+		if unicode.IsSpace(r) {
+			return true
+		}
+
+		switch r {
+		case '-', '_', '.', '/':
+			return true
+		default:
+			return false
+		}
+	})
+
+	if len(tokens) == 0 {
+		return ""
+	}
+
+	for _, prefix := range code_prefixes {
+
+		if tokens[0] == prefix && len(tokens) > 1 {
+			tokens = tokens[1:]
+			break
+		}
+
+		if strings.HasPrefix(tokens[0], prefix) && len(tokens[0]) > len(prefix) && unicode.IsDigit(rune(tokens[0][len(prefix)])) {
+			tokens[0] = strings.TrimPrefix(tokens[0], prefix)
+			break
+		}
+	}
+
+	joined := []rune(strings.Join(tokens, ""))
+	normalized := make([]rune, 0, len(joined))
+
+	for i, r := range joined {
+
+		// Skip zeros at the start of a number unless they are the last digit in the number
+
+		if r == '0' && isLeadingZero(joined, i) && i+1 < len(joined) && unicode.IsDigit(joined[i+1]) {
+			continue
+		}
+
+		normalized = append(normalized, r)
+	}
+
+	return string(normalized)
+}
+
+// isLeadingZero reports whether every digit preceding position 'i' in the current number is a zero.
+func isLeadingZero(runes []rune, i int) bool {
+
+	for j := i - 1; j >= 0; j-- {
+
+		if !unicode.IsDigit(runes[j]) {
+			return true
+		}
+
+		if runes[j] != '0' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// type Suggestion is a candidate code returned by `SuggestCodes`.
+type Suggestion struct {
+	// The candidate code.
+	Code string `json:"code"`
+	// The edit distance between the normalized versions of the candidate code and the code being queried.
+	Distance int `json:"distance"`
+}
+
+// SuggestCodes returns the list of codes in 'candidates' whose normalized values are within 'max_distance' edits of the
+// normalized value of 'code', ranked by edit distance and then alphabetically.
+func SuggestCodes(code string, candidates []string, max_distance int) []*Suggestion {
+
+	normalized := NormalizeCode(code)
+
+	seen := make(map[string]bool)
+	suggestions := make([]*Suggestion, 0)
+
+	for _, c := range candidates {
+
+		if seen[c] {
+			continue
+		}
+
+		seen[c] = true
+
+		d := levenshtein(normalized, NormalizeCode(c))
+
+		if d > max_distance {
+			continue
+		}
+
+		s := &Suggestion{
+			Code:     c,
+			Distance: d,
+		}
+
+		suggestions = append(suggestions, s)
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+
+		if suggestions[i].Distance != suggestions[j].Distance {
+			return suggestions[i].Distance < suggestions[j].Distance
+		}
+
+		return suggestions[i].Code < suggestions[j].Code
+	})
+
+	return suggestions
+}
+
+func levenshtein(a string, b string) int {
+
+	ra := []rune(a)
+	rb := []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+
+		curr[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+
+			cost := 1
+
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
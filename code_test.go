@@ -0,0 +1,63 @@
+package architecture
+
+import (
+	"testing"
+)
+
+func TestNormalizeCode(t *testing.T) {
+
+	tests := map[string]string{
+		"A9":            "A9",
+		"a9":            "A9",
+		"Gate A9":       "A9",
+		"A09":           "A9",
+		"A-9":           "A9",
+		" gate  a-09 ":  "A9",
+		"Terminal 2":    "2",
+		"Terminal2":     "2",
+		"Checkpoint 3":  "3",
+		"T1":            "T1",
+		"K04B":          "K4B",
+		"100":           "100",
+		"A00":           "A0",
+		"0":             "0",
+		"Gate":          "GATE",
+		"Gateway 7":     "GATEWAY7",
+		"":              "",
+		"International": "INTERNATIONAL",
+	}
+
+	for code, expected := range tests {
+
+		normalized := NormalizeCode(code)
+
+		if normalized != expected {
+			t.Fatalf("Unexpected normalization for '%s', expected '%s' but got '%s'", code, expected, normalized)
+		}
+	}
+}
+
+func TestSuggestCodes(t *testing.T) {
+
+	candidates := []string{
+		"A9",
+		"A19",
+		"B9",
+		"F5",
+		"1763588135",
+	}
+
+	suggestions := SuggestCodes("a-9x", candidates, 2)
+
+	if len(suggestions) != 3 {
+		t.Fatalf("Unexpected number of suggestions, %d", len(suggestions))
+	}
+
+	if suggestions[0].Code != "A9" || suggestions[0].Distance != 1 {
+		t.Fatalf("Unexpected first suggestion, %s (%d)", suggestions[0].Code, suggestions[0].Distance)
+	}
+
+	if suggestions[1].Code != "A19" || suggestions[2].Code != "B9" {
+		t.Fatalf("Unexpected ranking, %s, %s", suggestions[1].Code, suggestions[2].Code)
+	}
+}
@@ -0,0 +1,112 @@
+package architecture
+
+import (
+	"slices"
+	"sync"
+)
+
+// type CodeIndex is a concurrency-safe index of Who's On First IDs keyed by code. It is intended for lookups that only keep
+// track of which records match a code and load the records themselves on demand.
+type CodeIndex struct {
+	mu         *sync.RWMutex
+	codes      map[string][]int64
+	normalized map[string][]int64
+}
+
+// NewCodeIndex returns a new, empty, `CodeIndex` instance.
+func NewCodeIndex() *CodeIndex {
+
+	idx := &CodeIndex{
+		mu:         new(sync.RWMutex),
+		codes:      make(map[string][]int64),
+		normalized: make(map[string][]int64),
+	}
+
+	return idx
+}
+
+// Add indexes 'id' by each of 'codes' and their normalized values. Empty codes are ignored. See `NormalizeCode` for details.
+func (idx *CodeIndex) Add(id int64, codes ...string) {
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, code := range codes {
+
+		if code == "" {
+			continue
+		}
+
+		idx.codes[code] = appendId(idx.codes[code], id)
+
+		normalized_code := NormalizeCode(code)
+
+		if normalized_code != "" {
+			idx.normalized[normalized_code] = appendId(idx.normalized[normalized_code], id)
+		}
+	}
+}
+
+// Find returns the IDs indexed by 'code'. If there are no IDs indexed by 'code' exactly then the IDs indexed by its normalized value
+// are returned. The second return value is false if there are no matches.
+func (idx *CodeIndex) Find(code string) ([]int64, bool) {
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	ids, ok := idx.codes[code]
+
+	if !ok {
+		ids, ok = idx.normalized[NormalizeCode(code)]
+	}
+
+	if !ok {
+		return nil, false
+	}
+
+	return slices.Clone(ids), true
+}
+
+// Len returns the number of distinct (exact) codes in the index.
+func (idx *CodeIndex) Len() int {
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return len(idx.codes)
+}
+
+// Ids returns the sorted list of distinct IDs in the index.
+func (idx *CodeIndex) Ids() []int64 {
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	seen := make(map[int64]bool)
+	ids := make([]int64, 0)
+
+	for _, code_ids := range idx.codes {
+
+		for _, id := range code_ids {
+
+			if seen[id] {
+				continue
+			}
+
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	slices.Sort(ids)
+	return ids
+}
+
+func appendId(ids []int64, id int64) []int64 {
+
+	if slices.Contains(ids, id) {
+		return ids
+	}
+
+	return append(ids, id)
+}
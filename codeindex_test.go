@@ -0,0 +1,50 @@
+package architecture
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCodeIndex(t *testing.T) {
+
+	idx := NewCodeIndex()
+
+	idx.Add(1, "A9", "1")
+	idx.Add(2, "A9", "", "2")
+	idx.Add(2, "A9")
+	idx.Add(3, "A09")
+
+	tests := map[string][]int64{
+		"A9":      []int64{1, 2},
+		"A09":     []int64{3},
+		"gate a9": []int64{1, 2, 3},
+		"2":       []int64{2},
+	}
+
+	for code, expected := range tests {
+
+		ids, ok := idx.Find(code)
+
+		if !ok {
+			t.Fatalf("Failed to find %s", code)
+		}
+
+		if !slices.Equal(ids, expected) {
+			t.Fatalf("Unexpected IDs for %s, expected %v but got %v", code, expected, ids)
+		}
+	}
+
+	_, ok := idx.Find("B1")
+
+	if ok {
+		t.Fatalf("Did not expect to find B1")
+	}
+
+	if idx.Len() != 4 {
+		t.Fatalf("Unexpected number of codes, %d", idx.Len())
+	}
+
+	if !slices.Equal(idx.Ids(), []int64{1, 2, 3}) {
+		t.Fatalf("Unexpected IDs, %v", idx.Ids())
+	}
+}
@@ -0,0 +1,276 @@
+package architecture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sfomuseum/go-edtf/cmp"
+)
+
+// DatedRecord is implemented by records that have (EDTF) inception and cessation dates.
+type DatedRecord interface {
+	// Dates returns the (EDTF) inception and cessation dates for the record.
+	Dates() (string, string)
+}
+
+// type Match is a record returned by a `CompositeLookup` instance tagged with the kind of place it is.
+type Match struct {
+	// The kind of place the record is. This is the scheme, without "://", of the lookup the record was found by; for example "gates".
+	Kind string
+	// The record itself; for example a `*gates.Gate` instance.
+	Record interface{}
+}
+
+// type CompositeLookup queries every lookup registered with `RegisterLookup` at the same time, for codes whose kind of place is not known.
+// Lookups registered after a `CompositeLookup` instance has been created are added the next time it is queried.
+type CompositeLookup struct {
+	mu      *sync.RWMutex
+	uris    map[string]string
+	lookups map[string]Lookup
+}
+
+// NewCompositeLookup returns a new `CompositeLookup` instance where each lookup is created using its default (scheme-only) URI.
+func NewCompositeLookup(ctx context.Context) (*CompositeLookup, error) {
+	return NewCompositeLookupWithURIs(ctx, nil)
+}
+
+// NewCompositeLookupWithURIs returns a new `CompositeLookup` instance where lookups are created using the URIs in 'uris', keyed by
+// kind (for example "gates"). Lookups for kinds not present in 'uris' are created using their default (scheme-only) URIs.
+func NewCompositeLookupWithURIs(ctx context.Context, uris map[string]string) (*CompositeLookup, error) {
+
+	l := &CompositeLookup{
+		mu:      new(sync.RWMutex),
+		uris:    make(map[string]string),
+		lookups: make(map[string]Lookup),
+	}
+
+	for k, uri := range uris {
+		l.uris[k] = uri
+	}
+
+	err := l.ensureLookups(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Kinds returns the sorted list of kinds that are queried.
+func (l *CompositeLookup) Kinds(ctx context.Context) ([]string, error) {
+
+	err := l.ensureLookups(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	kinds := make([]string, 0, len(l.lookups))
+
+	for k := range l.lookups {
+		kinds = append(kinds, k)
+	}
+
+	sort.Strings(kinds)
+	return kinds, nil
+}
+
+// Find returns the records matching 'code' for every kind, ordered by kind. Kinds that fail to be queried are logged and skipped as long
+// as there are matches for the other kinds. An error matching `ErrNotFound` is returned if every kind was queried and there are no matches.
+func (l *CompositeLookup) Find(ctx context.Context, code string) ([]*Match, error) {
+	return l.find(ctx, code, "")
+}
+
+// FindForDate returns the records matching 'code' for every kind that were active for 'date', ordered by kind. Records that do not
+// implement the `DatedRecord` interface are excluded. Unlike the date-based finders in the gates, galleries and terminals packages
+// no `ResolutionPolicy` is applied so all the records active for 'date' are returned. Failures are handled in the same way as `Find`.
+func (l *CompositeLookup) FindForDate(ctx context.Context, code string, date string) ([]*Match, error) {
+	return l.find(ctx, code, date)
+}
+
+func (l *CompositeLookup) find(ctx context.Context, code string, date string) ([]*Match, error) {
+
+	kinds, err := l.Kinds(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.RLock()
+	lookups := make([]Lookup, len(kinds))
+
+	for idx, k := range kinds {
+		lookups[idx] = l.lookups[k]
+	}
+
+	l.mu.RUnlock()
+
+	results := make([][]*Match, len(kinds))
+	errs := make([]error, len(kinds))
+
+	wg := new(sync.WaitGroup)
+
+	for idx, k := range kinds {
+
+		wg.Add(1)
+
+		go func(idx int, k string, lu Lookup) {
+
+			defer wg.Done()
+
+			matches, err := findMatches(ctx, lu, k, code, date)
+
+			if err != nil {
+				errs[idx] = fmt.Errorf("Failed to find '%s' in %s, %w", code, k, err)
+				return
+			}
+
+			results[idx] = matches
+
+		}(idx, k, lookups[idx])
+	}
+
+	wg.Wait()
+
+	matches := make([]*Match, 0)
+
+	for _, m := range results {
+		matches = append(matches, m...)
+	}
+
+	err = errors.Join(errs...)
+
+	// A failure for one kind does not hide the matches for the others
+
+	if len(matches) > 0 {
+
+		if err != nil {
+			slog.Warn("Failed to query one or more kinds", "code", code, "error", err)
+		}
+
+		return matches, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if date != "" {
+		return nil, fmt.Errorf("No records found for '%s' for date '%s', %w", code, date, ErrNotFound)
+	}
+
+	return nil, fmt.Errorf("No records found for '%s', %w", code, ErrNotFound)
+}
+
+func findMatches(ctx context.Context, lu Lookup, kind string, code string, date string) ([]*Match, error) {
+
+	rsp, err := lu.Find(ctx, code)
+
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]*Match, 0)
+
+	for _, r := range rsp {
+
+		if date != "" {
+
+			dr, ok := r.(DatedRecord)
+
+			if !ok {
+				continue
+			}
+
+			inception, cessation := dr.Dates()
+
+			is_between, err := cmp.IsBetween(date, inception, cessation)
+
+			if err != nil {
+				slog.Debug("Failed to determine whether record matches date conditions", "kind", kind, "code", code, "date", date, "inception", inception, "cessation", cessation, "error", err)
+				continue
+			}
+
+			if !is_between {
+				continue
+			}
+		}
+
+		m := &Match{
+			Kind:   kind,
+			Record: r,
+		}
+
+		matches = append(matches, m)
+	}
+
+	return matches, nil
+}
+
+// ensureLookups creates lookups for any schemes that have been registered since it was last called.
+func (l *CompositeLookup) ensureLookups(ctx context.Context) error {
+
+	schemes := Schemes()
+
+	l.mu.RLock()
+	missing := false
+
+	for _, scheme := range schemes {
+
+		_, ok := l.lookups[strings.TrimSuffix(scheme, "://")]
+
+		if !ok {
+			missing = true
+			break
+		}
+	}
+
+	l.mu.RUnlock()
+
+	if !missing {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, scheme := range schemes {
+
+		k := strings.TrimSuffix(scheme, "://")
+
+		_, ok := l.lookups[k]
+
+		if ok {
+			continue
+		}
+
+		uri, ok := l.uris[k]
+
+		if !ok {
+			uri = scheme
+		}
+
+		lu, err := NewLookup(ctx, uri)
+
+		if err != nil {
+			return fmt.Errorf("Failed to create lookup for %s, %w", k, err)
+		}
+
+		l.lookups[k] = lu
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package architecture_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/sfomuseum/go-edtf/cmp"
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+	"github.com/sfomuseum/go-sfomuseum-architecture/galleries"
+	"github.com/sfomuseum/go-sfomuseum-architecture/gates"
+	"github.com/sfomuseum/go-sfomuseum-architecture/terminals"
+)
+
+type compositeTestRecord struct {
+	code string
+}
+
+type compositeTestLookup struct{}
+
+func (l *compositeTestLookup) Find(ctx context.Context, code string) ([]interface{}, error) {
+
+	switch code {
+	case "A9":
+		return []interface{}{&compositeTestRecord{code: code}}, nil
+	case "T1", "BROKEN":
+		return nil, fmt.Errorf("Failed to query compositetest")
+	default:
+		return nil, architecture.ErrNotFound
+	}
+}
+
+func (l *compositeTestLookup) Append(ctx context.Context, data interface{}) error {
+	return errors.ErrUnsupported
+}
+
+func TestCompositeLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	lu, err := architecture.NewCompositeLookup(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to create composite lookup, %v", err)
+	}
+
+	kinds, err := lu.Kinds(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to derive kinds, %v", err)
+	}
+
+	for _, k := range []string{"galleries", "gates", "terminals"} {
+
+		if !slices.Contains(kinds, k) {
+			t.Fatalf("Missing kind %s", k)
+		}
+	}
+
+	tests := map[string]string{
+		"A9": "gates",
+		"2D": "galleries",
+		"T1": "terminals",
+	}
+
+	for code, kind := range tests {
+
+		matches, err := lu.Find(ctx, code)
+
+		if err != nil {
+			t.Fatalf("Failed to find %s, %v", code, err)
+		}
+
+		for _, m := range matches {
+
+			if m.Kind != kind {
+				t.Fatalf("Unexpected kind for %s, expected %s but got %s", code, kind, m.Kind)
+			}
+
+			var ok bool
+
+			switch kind {
+			case "gates":
+				_, ok = m.Record.(*gates.Gate)
+			case "galleries":
+				_, ok = m.Record.(*galleries.Gallery)
+			case "terminals":
+				_, ok = m.Record.(*terminals.Terminal)
+			}
+
+			if !ok {
+				t.Fatalf("Unexpected record type for %s, %T", code, m.Record)
+			}
+		}
+	}
+
+	all, err := lu.Find(ctx, "A9")
+
+	if err != nil {
+		t.Fatalf("Failed to find A9, %v", err)
+	}
+
+	dated, err := lu.FindForDate(ctx, "A9", "2022-01-01")
+
+	if err != nil {
+		t.Fatalf("Failed to find A9 for date, %v", err)
+	}
+
+	if len(dated) == 0 || len(dated) >= len(all) {
+		t.Fatalf("Expected date to filter results for A9, got %d of %d", len(dated), len(all))
+	}
+
+	for _, m := range dated {
+
+		inception, cessation := m.Record.(architecture.DatedRecord).Dates()
+
+		is_between, err := cmp.IsBetween("2022-01-01", inception, cessation)
+
+		if err != nil || !is_between {
+			t.Fatalf("Unexpected record for date, %s-%s", inception, cessation)
+		}
+	}
+
+	_, err = lu.Find(ctx, "NOT-A-CODE")
+
+	if !errors.Is(err, architecture.ErrNotFound) {
+		t.Fatalf("Expected unknown code to not be found, got %v", err)
+	}
+
+	// Drivers registered after the lookup was created should be picked up automatically
+
+	init_func := func(ctx context.Context, uri string) (architecture.Lookup, error) {
+		return &compositeTestLookup{}, nil
+	}
+
+	err = architecture.RegisterLookup(ctx, "compositetest", init_func)
+
+	if err != nil {
+		t.Fatalf("Failed to register lookup, %v", err)
+	}
+
+	matches, err := lu.Find(ctx, "A9")
+
+	if err != nil {
+		t.Fatalf("Failed to find A9, %v", err)
+	}
+
+	if matches[0].Kind != "compositetest" {
+		t.Fatalf("Expected matches to include (and be ordered by) the new kind, got %s", matches[0].Kind)
+	}
+
+	if len(matches) != len(all)+1 {
+		t.Fatalf("Unexpected number of matches, expected %d but got %d", len(all)+1, len(matches))
+	}
+
+	// Undated records are excluded from date queries
+
+	dated_again, err := lu.FindForDate(ctx, "A9", "2022-01-01")
+
+	if err != nil {
+		t.Fatalf("Failed to find A9 for date, %v", err)
+	}
+
+	if len(dated_again) != len(dated) {
+		t.Fatalf("Expected undated records to be excluded, got %d matches", len(dated_again))
+	}
+
+	// A failure for one kind should not hide the matches for the other kinds
+
+	matches, err = lu.Find(ctx, "T1")
+
+	if err != nil {
+		t.Fatalf("Failed to find T1, %v", err)
+	}
+
+	for _, m := range matches {
+
+		if m.Kind != "terminals" {
+			t.Fatalf("Unexpected kind for T1, %s", m.Kind)
+		}
+	}
+
+	// But it should be reported if there are no matches at all
+
+	_, err = lu.Find(ctx, "BROKEN")
+
+	if err == nil || errors.Is(err, architecture.ErrNotFound) {
+		t.Fatalf("Expected failure for BROKEN to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package architecture
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// NewDataReader returns an `io.Reader` instance for the precompiled data in 'r' which transparently decompresses gzip-compressed data.
+// Uncompressed data is returned as-is.
+func NewDataReader(r io.Reader) (io.Reader, error) {
+
+	br := bufio.NewReader(r)
+
+	magic, err := br.Peek(2)
+
+	if err != nil || magic[0] != 0x1f || magic[1] != 0x8b {
+		return br, nil
+	}
+
+	gz, err := gzip.NewReader(br)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create gzip reader, %w", err)
+	}
+
+	return gz, nil
+}
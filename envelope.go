@@ -0,0 +1,279 @@
+package architecture
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+	"unicode"
+)
+
+// The current version of the compiled data schema. Data files with a greater schema version can not be read.
+const DATA_SCHEMA_VERSION int = 1
+
+// The default source repository for compiled data.
+const DEFAULT_DATA_SOURCE string = "https://github.com/sfomuseum-data/sfomuseum-data-architecture"
+
+// type DataMetadata is a struct describing a compiled data file.
+type DataMetadata struct {
+	// The version of the schema used to encode the data file.
+	SchemaVersion int `json:"schema_version"`
+	// The SFO Museum placetype of the records in the data file.
+	Placetype string `json:"placetype"`
+	// The (RFC 3339) time the data file was generated.
+	GeneratedAt string `json:"generated_at,omitempty"`
+	// The repository the records in the data file were compiled from.
+	Source string `json:"source,omitempty"`
+	// The commit, in the source repository, the records in the data file were compiled from.
+	Commit string `json:"commit,omitempty"`
+	// The number of records in the data file.
+	Count int `json:"count"`
+	// Boolean flag signaling that the data file uses the legacy (bare JSON array) format. It is never encoded.
+	Legacy bool `json:"-"`
+}
+
+// type DataEnvelope is a struct wrapping the records in a compiled data file with `DataMetadata` describing them.
+type DataEnvelope[T any] struct {
+	DataMetadata
+	// The records in the data file.
+	Records []T `json:"records"`
+}
+
+// NewDataEnvelope returns a new `DataEnvelope` instance for 'records', using the current schema version and time.
+func NewDataEnvelope[T any](placetype string, records []T) *DataEnvelope[T] {
+
+	e := &DataEnvelope[T]{
+		DataMetadata: DataMetadata{
+			SchemaVersion: DATA_SCHEMA_VERSION,
+			Placetype:     placetype,
+			GeneratedAt:   time.Now().UTC().Format(time.RFC3339),
+			Count:         len(records),
+		},
+		Records: records,
+	}
+
+	return e
+}
+
+// EncodeData writes 'records' to 'wr' in the versioned `DataEnvelope` format read by `DecodeData`. Unlike `NewDataEnvelope` no generation
+// time is recorded so the same records, in the same order, always produce the same output.
+func EncodeData[T any](wr io.Writer, placetype string, records []T) error {
+
+	enc, err := NewDataEncoder[T](wr, placetype, len(records))
+
+	if err != nil {
+		return err
+	}
+
+	for _, r := range records {
+
+		err := enc.Encode(r)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return enc.Close()
+}
+
+// type DataEncoder writes records to an `io.Writer`, one at a time, in the versioned `DataEnvelope` format read by `DecodeData` so that
+// records can be encoded without all of them being held in memory at once. The output is the same as `EncodeData` for the same records.
+type DataEncoder[T any] struct {
+	wr      io.Writer
+	count   int
+	written int
+}
+
+// NewDataEncoder returns a new `DataEncoder` instance that writes exactly 'count' records of SFO Museum placetype 'placetype' to 'wr'.
+// The envelope's metadata is written immediately. The number of records needs to be known ahead of time since it is encoded before them.
+func NewDataEncoder[T any](wr io.Writer, placetype string, count int) (*DataEncoder[T], error) {
+
+	md := DataMetadata{
+		SchemaVersion: DATA_SCHEMA_VERSION,
+		Placetype:     placetype,
+		Count:         count,
+	}
+
+	enc_md, err := json.Marshal(md)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode metadata, %w", err)
+	}
+
+	// The records are appended to the (encoded) metadata object in the same way that they would be by encoding a `DataEnvelope`
+
+	_, err = wr.Write(enc_md[:len(enc_md)-1])
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = io.WriteString(wr, `,"records":[`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	e := &DataEncoder[T]{
+		wr:    wr,
+		count: count,
+	}
+
+	return e, nil
+}
+
+// Encode writes 'r' to the encoder's `io.Writer`. An error is returned if more records are written than the encoder was created with.
+func (e *DataEncoder[T]) Encode(r T) error {
+
+	if e.written >= e.count {
+		return fmt.Errorf("Unexpected record, expected %d records", e.count)
+	}
+
+	enc_r, err := json.Marshal(r)
+
+	if err != nil {
+		return fmt.Errorf("Failed to encode record, %w", err)
+	}
+
+	if e.written > 0 {
+
+		_, err := io.WriteString(e.wr, ",")
+
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = e.wr.Write(enc_r)
+
+	if err != nil {
+		return err
+	}
+
+	e.written += 1
+	return nil
+}
+
+// Close completes the envelope. An error is returned if fewer records were written than the encoder was created with since the output
+// would not be read by `DecodeData`.
+func (e *DataEncoder[T]) Close() error {
+
+	if e.written != e.count {
+		return fmt.Errorf("Unexpected record count, expected %d but wrote %d", e.count, e.written)
+	}
+
+	_, err := io.WriteString(e.wr, "]}\n")
+	return err
+}
+
+// DecodeData decodes the compiled data in 'r', which may be gzip-compressed, returning its records and metadata. Both the versioned
+// `DataEnvelope` format and the legacy bare JSON array format are supported. An error is returned if the data was encoded with a
+// newer schema version than `DATA_SCHEMA_VERSION`, if its placetype is not 'placetype' or if its record count does not match.
+func DecodeData[T any](r io.Reader, placetype string) ([]T, *DataMetadata, error) {
+
+	data_r, err := NewDataReader(r)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	br := bufio.NewReader(data_r)
+
+	legacy, err := isLegacyData(br)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dec := json.NewDecoder(br)
+
+	if legacy {
+
+		var records []T
+
+		err := dec.Decode(&records)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		md := &DataMetadata{
+			Placetype: placetype,
+			Count:     len(records),
+			Legacy:    true,
+		}
+
+		return records, md, nil
+	}
+
+	// Decode the metadata first so that newer schemas, whose records may be encoded differently, are rejected with a useful error
+
+	var raw struct {
+		DataMetadata
+		Records json.RawMessage `json:"records"`
+	}
+
+	err = dec.Decode(&raw)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	md := &raw.DataMetadata
+
+	if md.SchemaVersion < 1 || md.SchemaVersion > DATA_SCHEMA_VERSION {
+		return nil, nil, fmt.Errorf("Unsupported schema version %d, this package supports versions 1 to %d", md.SchemaVersion, DATA_SCHEMA_VERSION)
+	}
+
+	if md.Placetype != placetype {
+		return nil, nil, fmt.Errorf("Unexpected placetype '%s', expected '%s'", md.Placetype, placetype)
+	}
+
+	var records []T
+
+	err = json.Unmarshal(raw.Records, &records)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to decode records, %w", err)
+	}
+
+	if len(records) != md.Count {
+		return nil, nil, fmt.Errorf("Unexpected record count, expected %d but got %d", md.Count, len(records))
+	}
+
+	return records, md, nil
+}
+
+// isLegacyData reports whether the first non-whitespace character in 'br' opens a JSON array.
+func isLegacyData(br *bufio.Reader) (bool, error) {
+
+	for {
+
+		r, _, err := br.ReadRune()
+
+		if err != nil {
+			return false, fmt.Errorf("Failed to read data, %w", err)
+		}
+
+		if unicode.IsSpace(r) {
+			continue
+		}
+
+		err = br.UnreadRune()
+
+		if err != nil {
+			return false, err
+		}
+
+		switch r {
+		case '[':
+			return true, nil
+		case '{':
+			return false, nil
+		default:
+			return false, fmt.Errorf("Unrecognized data format, unexpected character '%c'", r)
+		}
+	}
+}
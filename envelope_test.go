@@ -0,0 +1,248 @@
+package architecture
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture/data"
+)
+
+type envelopeTestRecord struct {
+	Id int64 `json:"wof:id"`
+}
+
+func TestDecodeData(t *testing.T) {
+
+	records := []*envelopeTestRecord{
+		&envelopeTestRecord{Id: 1000000001},
+		&envelopeTestRecord{Id: 1000000002},
+	}
+
+	env := NewDataEnvelope("gate", records)
+	env.Commit = "abc123"
+
+	enc_env, err := json.Marshal(env)
+
+	if err != nil {
+		t.Fatalf("Failed to marshal envelope, %v", err)
+	}
+
+	rsp, md, err := DecodeData[*envelopeTestRecord](bytes.NewReader(enc_env), "gate")
+
+	if err != nil {
+		t.Fatalf("Failed to decode envelope, %v", err)
+	}
+
+	if len(rsp) != 2 || rsp[1].Id != 1000000002 {
+		t.Fatalf("Unexpected records, %v", rsp)
+	}
+
+	if md.Legacy || md.SchemaVersion != DATA_SCHEMA_VERSION || md.Commit != "abc123" || md.Count != 2 || md.GeneratedAt == "" {
+		t.Fatalf("Unexpected metadata, %v", md)
+	}
+
+	// Gzip-compressed envelope
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	gz.Write(enc_env)
+	gz.Close()
+
+	rsp, _, err = DecodeData[*envelopeTestRecord](&buf, "gate")
+
+	if err != nil || len(rsp) != 2 {
+		t.Fatalf("Failed to decode gzip-compressed envelope, %v", err)
+	}
+
+	// Legacy
+
+	rsp, md, err = DecodeData[*envelopeTestRecord](strings.NewReader(` [{"wof:id": 1000000001}]`), "gate")
+
+	if err != nil {
+		t.Fatalf("Failed to decode legacy data, %v", err)
+	}
+
+	if len(rsp) != 1 || !md.Legacy || md.Count != 1 {
+		t.Fatalf("Unexpected legacy data, %v", md)
+	}
+
+	// Invalid
+
+	invalid := []string{
+		`{"schema_version": 99, "placetype": "gate", "count": 0, "records": []}`,
+		`{"placetype": "gate", "count": 0, "records": []}`,
+		`{"schema_version": 1, "placetype": "gallery", "count": 0, "records": []}`,
+		`{"schema_version": 1, "placetype": "gate", "count": 2, "records": [{"wof:id": 1}]}`,
+		`"bunk"`,
+		``,
+	}
+
+	for _, str := range invalid {
+
+		_, _, err := DecodeData[*envelopeTestRecord](strings.NewReader(str), "gate")
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", str)
+		}
+	}
+}
+
+func TestEncodeData(t *testing.T) {
+
+	records := []*envelopeTestRecord{
+		&envelopeTestRecord{Id: 1000000001},
+		&envelopeTestRecord{Id: 1000000002},
+	}
+
+	var buf_a bytes.Buffer
+	var buf_b bytes.Buffer
+
+	for _, buf := range []*bytes.Buffer{&buf_a, &buf_b} {
+
+		err := EncodeData(buf, "gate", records)
+
+		if err != nil {
+			t.Fatalf("Failed to encode data, %v", err)
+		}
+	}
+
+	if !bytes.Equal(buf_a.Bytes(), buf_b.Bytes()) {
+		t.Fatalf("Expected encoded data to be deterministic")
+	}
+
+	rsp, md, err := DecodeData[*envelopeTestRecord](&buf_a, "gate")
+
+	if err != nil {
+		t.Fatalf("Failed to decode encoded data, %v", err)
+	}
+
+	if len(rsp) != 2 || rsp[1].Id != 1000000002 || md.GeneratedAt != "" {
+		t.Fatalf("Unexpected decoded data, %v", md)
+	}
+
+	var buf_empty bytes.Buffer
+
+	err = EncodeData[*envelopeTestRecord](&buf_empty, "gate", nil)
+
+	if err != nil {
+		t.Fatalf("Failed to encode empty data, %v", err)
+	}
+
+	rsp, _, err = DecodeData[*envelopeTestRecord](&buf_empty, "gate")
+
+	if err != nil || len(rsp) != 0 {
+		t.Fatalf("Failed to decode empty data, %v", err)
+	}
+}
+
+func TestDataEncoder(t *testing.T) {
+
+	records := []*envelopeTestRecord{
+		&envelopeTestRecord{Id: 1000000001},
+		&envelopeTestRecord{Id: 1000000002},
+	}
+
+	// The output should be the same as encoding the envelope as a whole
+
+	var expected bytes.Buffer
+
+	e := &DataEnvelope[*envelopeTestRecord]{
+		DataMetadata: DataMetadata{
+			SchemaVersion: DATA_SCHEMA_VERSION,
+			Placetype:     "gate",
+			Count:         len(records),
+		},
+		Records: records,
+	}
+
+	err := json.NewEncoder(&expected).Encode(e)
+
+	if err != nil {
+		t.Fatalf("Failed to encode envelope, %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	enc, err := NewDataEncoder[*envelopeTestRecord](&buf, "gate", len(records))
+
+	if err != nil {
+		t.Fatalf("Failed to create encoder, %v", err)
+	}
+
+	for _, r := range records {
+
+		err := enc.Encode(r)
+
+		if err != nil {
+			t.Fatalf("Failed to encode record, %v", err)
+		}
+	}
+
+	err = enc.Encode(records[0])
+
+	if err == nil {
+		t.Fatalf("Expected encoding more records than the encoder was created with to fail")
+	}
+
+	err = enc.Close()
+
+	if err != nil {
+		t.Fatalf("Failed to close encoder, %v", err)
+	}
+
+	if buf.String() != expected.String() {
+		t.Fatalf("Unexpected output, %s", buf.String())
+	}
+
+	enc, err = NewDataEncoder[*envelopeTestRecord](&buf, "gate", len(records))
+
+	if err != nil {
+		t.Fatalf("Failed to create encoder, %v", err)
+	}
+
+	err = enc.Close()
+
+	if err == nil {
+		t.Fatalf("Expected closing an encoder with missing records to fail")
+	}
+}
+
+func TestDecodeEmbeddedData(t *testing.T) {
+
+	tests := map[string]string{
+		"checkpoints.json.gz": "checkpoint",
+		"galleries.json.gz":   "gallery",
+		"gates.json.gz":       "gate",
+		"publicart.json.gz":   "publicart",
+		"terminals.json.gz":   "terminal",
+	}
+
+	for filename, placetype := range tests {
+
+		fh, err := data.FS.Open(filename)
+
+		if err != nil {
+			t.Fatalf("Failed to open %s, %v", filename, err)
+		}
+
+		records, md, err := DecodeData[map[string]any](fh, placetype)
+
+		fh.Close()
+
+		if err != nil {
+			t.Fatalf("Failed to decode %s, %v", filename, err)
+		}
+
+		if md.Legacy {
+			t.Fatalf("Expected %s to be encoded as a data envelope", filename)
+		}
+
+		if md.Count != len(records) {
+			t.Fatalf("Unexpected count for %s, expected %d but got %d", filename, md.Count, len(records))
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package architecture
+
+import (
+	"errors"
+)
+
+// ErrNotFound is the error matched (using `errors.Is`) by all the `NotFound` errors returned by the gates, galleries and terminals packages.
+var ErrNotFound = errors.New("Not found")
+
+// ErrMultipleCandidates is the error matched (using `errors.Is`) by all the `MultipleCandidates` errors returned by the gates, galleries
+// and terminals packages.
+var ErrMultipleCandidates = errors.New("Multiple candidates")
+
+// The reason reported when a code is not present in a lookup.
+const REASON_UNKNOWN_CODE string = "unknown code"
+
+// The reason reported when none of the records for a code are marked as current.
+const REASON_NO_CURRENT string = "no current records"
+
+// The reason reported when more than one of the records for a code are marked as current.
+const REASON_MULTIPLE_CURRENT string = "multiple current records"
+
+// The reason reported when none of the records for a code were active for the date being queried.
+const REASON_NO_DATE_MATCH string = "no records active for date"
+
+// The reason reported when more than one of the records for a code were active for the date being queried.
+const REASON_MULTIPLE_DATE_MATCH string = "multiple records active for date"
+
+// The reason reported when a Who's On First ID is not present in a lookup.
+const REASON_UNKNOWN_ID string = "unknown ID"
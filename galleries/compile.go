@@ -7,6 +7,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/sfomuseum/go-sfomuseum-architecture"
 	"github.com/tidwall/gjson"
 	"github.com/whosonfirst/go-whosonfirst-feature/properties"
 	"github.com/whosonfirst/go-whosonfirst-iterate/v2/iterator"
@@ -50,42 +51,10 @@ func CompileGalleriesData(ctx context.Context, iterator_uri string, iterator_sou
 			return fmt.Errorf("Failed load feature from %s, %w", path, err)
 		}
 
-		wof_id, err := properties.Id(body)
+		g, err := newGalleryFromFeature(body)
 
 		if err != nil {
-			return fmt.Errorf("Failed to derive ID for %s, %w", path, err)
-		}
-
-		wof_name, err := properties.Name(body)
-
-		if err != nil {
-			return fmt.Errorf("Failed to derive name for %s, %w", path, err)
-		}
-
-		fl, err := properties.IsCurrent(body)
-
-		if err != nil {
-			return fmt.Errorf("Failed to determine is current for %s, %v", path, err)
-		}
-
-		sfomid_rsp := gjson.GetBytes(body, "properties.sfomuseum:gallery_id")
-
-		if !sfomid_rsp.Exists() {
-			return fmt.Errorf("Missing sfomuseum:gallery_id property (%s)", path)
-		}
-
-		mapid_rsp := gjson.GetBytes(body, "properties.sfomuseum:map_id")
-		inception_rsp := gjson.GetBytes(body, "properties.edtf:inception")
-		cessation_rsp := gjson.GetBytes(body, "properties.edtf:cessation")
-
-		g := &Gallery{
-			WhosOnFirstId: wof_id,
-			SFOMuseumId:   sfomid_rsp.Int(),
-			MapId:         mapid_rsp.String(),
-			Name:          wof_name,
-			Inception:     inception_rsp.String(),
-			Cessation:     cessation_rsp.String(),
-			IsCurrent:     fl.Flag(),
+			return fmt.Errorf("Failed to derive gallery from %s, %w", path, err)
 		}
 
 		mu.Lock()
@@ -109,3 +78,67 @@ func CompileGalleriesData(ctx context.Context, iterator_uri string, iterator_sou
 
 	return lookup, nil
 }
+
+// newGalleryFromFeature returns a new `Gallery` instance derived from the Who's On First GeoJSON Feature 'body'.
+func newGalleryFromFeature(body []byte) (*Gallery, error) {
+
+	wof_id, err := properties.Id(body)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive ID, %w", err)
+	}
+
+	wof_name, err := properties.Name(body)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive name, %w", err)
+	}
+
+	fl, err := properties.IsCurrent(body)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to determine is current, %w", err)
+	}
+
+	sfomid_rsp := gjson.GetBytes(body, "properties.sfomuseum:gallery_id")
+
+	if !sfomid_rsp.Exists() {
+		return nil, fmt.Errorf("Missing sfomuseum:gallery_id property")
+	}
+
+	mapid_rsp := gjson.GetBytes(body, "properties.sfomuseum:map_id")
+	inception_rsp := gjson.GetBytes(body, "properties.edtf:inception")
+	cessation_rsp := gjson.GetBytes(body, "properties.edtf:cessation")
+
+	parents := architecture.DeriveParents(body)
+
+	g := &Gallery{
+		WhosOnFirstId:  wof_id,
+		SFOMuseumId:    sfomid_rsp.Int(),
+		MapId:          mapid_rsp.String(),
+		Name:           wof_name,
+		Inception:      inception_rsp.String(),
+		Cessation:      cessation_rsp.String(),
+		IsCurrent:      fl.Flag(),
+		ParentId:       parents.ParentId,
+		BoardingAreaId: parents.BoardingAreaId,
+		TerminalId:     parents.TerminalId,
+		Supersedes:     properties.Supersedes(body),
+		SupersededBy:   properties.SupersededBy(body),
+		Deprecated:     properties.Deprecated(body),
+	}
+
+	return g, nil
+}
+
+// newGalleryRecord derives a `Gallery` record from 'body' for use as an `architecture.FeatureRecordFunc`.
+func newGalleryRecord(ctx context.Context, body []byte) (interface{}, error) {
+
+	r, err := newGalleryFromFeature(body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
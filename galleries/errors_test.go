@@ -1,13 +1,16 @@
 package galleries
 
 import (
-	_ "fmt"
+	"errors"
+	"fmt"
 	"testing"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
 )
 
 func TestGalleriesNotFound(t *testing.T) {
 
-	e := NotFound{"D16"}
+	e := NotFound{Code: "D16"}
 
 	if !IsNotFound(e) {
 		t.Fatalf("Expected NotFound error")
@@ -20,7 +23,7 @@ func TestGalleriesNotFound(t *testing.T) {
 
 func TestGalleriesMultipleCandidates(t *testing.T) {
 
-	e := MultipleCandidates{"D16"}
+	e := MultipleCandidates{Code: "D16"}
 
 	if !IsMultipleCandidates(e) {
 		t.Fatalf("Expected MultipleCandidates error")
@@ -30,3 +33,42 @@ func TestGalleriesMultipleCandidates(t *testing.T) {
 		t.Fatalf("Invalid stringification")
 	}
 }
+
+func TestWrappedErrors(t *testing.T) {
+
+	candidates := []*Gallery{
+		&Gallery{WhosOnFirstId: 1000000001},
+		&Gallery{WhosOnFirstId: 1000000002},
+	}
+
+	var err error = MultipleCandidates{Code: "D16", Date: "2020", Reason: architecture.REASON_MULTIPLE_DATE_MATCH, Candidates: candidates}
+	err = fmt.Errorf("Failed to find D16, %w", err)
+
+	if !IsMultipleCandidates(err) {
+		t.Fatalf("Expected wrapped MultipleCandidates error")
+	}
+
+	if !errors.Is(err, architecture.ErrMultipleCandidates) {
+		t.Fatalf("Expected wrapped error to match architecture.ErrMultipleCandidates")
+	}
+
+	var mc MultipleCandidates
+
+	if !errors.As(err, &mc) {
+		t.Fatalf("Expected wrapped error to be a MultipleCandidates error")
+	}
+
+	if len(mc.Candidates) != 2 || mc.Date != "2020" {
+		t.Fatalf("Unexpected candidates or date, %v", mc)
+	}
+
+	err = fmt.Errorf("Failed to find D16, %w", &NotFound{Code: "D16"})
+
+	if !IsNotFound(err) || !errors.Is(err, architecture.ErrNotFound) {
+		t.Fatalf("Expected wrapped NotFound error")
+	}
+
+	if errors.Is(err, architecture.ErrMultipleCandidates) {
+		t.Fatalf("NotFound error should not match architecture.ErrMultipleCandidates")
+	}
+}
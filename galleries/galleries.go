@@ -4,12 +4,55 @@ package galleries
 import (
 	"context"
 	"fmt"
+	"iter"
 	"log/slog"
+	"strconv"
 
+	"github.com/paulmach/orb/geojson"
 	"github.com/sfomuseum/go-edtf/cmp"
 	"github.com/sfomuseum/go-sfomuseum-architecture"
 )
 
+// The default `architecture.ResolutionPolicy` used by the date-based finders which is to prefer galleries that are marked as current or, failing that, galleries whose inception date matches the date being queried.
+//
+// Given the following scenario:
+//
+//	2024/07/26 15:50:27 DEBUG Gallery DOES match date conditions code=42 date=2024-06-17 "gallery id"=1914589529 gallery="AML 06 AML Photography" inception=2021-11-09 cessation=2024-06-17
+//	2024/07/26 15:50:27 DEBUG Gallery DOES match date conditions code=42 date=2024-06-17 "gallery id"=1914601189 gallery="AML 06 AML Photography" inception=2024-06-17 cessation=..
+//
+// Where, by virtue of 2024-06-17 being "between" the end date of one gallery and the start date of another, then
+// filter out matches that are not considered to be "current".
+//
+// But wait, there's more. What if the same situation exists (matching inception/cessation dates) but none of the candidate
+// galleries are "current" ? In that situation give precedence to records whose inception date matches the date being queried against.
+//
+//	2024/07/26 16:07:56 DEBUG Gallery DOES match date conditions code=3 date=2021-11-09 "gallery id"=1745882483 gallery="3E Gate 76" inception=2021-05-25 cessation=2021-11-09
+//	2024/07/26 16:07:56 DEBUG Gallery DOES match date conditions code=3 date=2021-11-09 "gallery id"=1763588523 gallery="F-03 Gate 76" inception=2021-11-09 cessation=2024-06-17
+var DEFAULT_RESOLUTION_POLICY architecture.ResolutionPolicy = architecture.RESOLVE_PREFER_CURRENT | architecture.RESOLVE_PREFER_STARTING_ON_DATE
+
+// type Galleries is a list of `Gallery` records that can be sorted in chronological order. See `architecture.CompareDates` for details.
+// Records whose dates can not be distinguished are sorted by their Who's On First IDs.
+type Galleries []*Gallery
+
+func (c Galleries) Len() int {
+	return len(c)
+}
+
+func (c Galleries) Less(i, j int) bool {
+
+	cmp := architecture.CompareDates(c[i].Inception, c[i].Cessation, c[j].Inception, c[j].Cessation)
+
+	if cmp != 0 {
+		return cmp < 0
+	}
+
+	return c[i].WhosOnFirstId < c[j].WhosOnFirstId
+}
+
+func (c Galleries) Swap(i, j int) {
+	c[i], c[j] = c[j], c[i]
+}
+
 // type Gallery is a struct representing a passenger gallery at SFO.
 type Gallery struct {
 	// The Who's On First ID associated with this gallery.
@@ -26,6 +69,21 @@ type Gallery struct {
 	Cessation string `json:"edtf:cessation"`
 	// A Who's On First "existential" (`KnownUnknownFlag`) flag signaling the gallery's status
 	IsCurrent int64 `json:"mz:is_current"`
+	// The Who's On First ID of the gallery's parent (typically a boarding area).
+	ParentId int64 `json:"wof:parent_id,omitempty"`
+	// The Who's On First ID of the boarding area the gallery belongs to.
+	BoardingAreaId int64 `json:"boardingarea_id,omitempty"`
+	// The Who's On First ID of the terminal the gallery belongs to.
+	TerminalId int64 `json:"terminal_id,omitempty"`
+	// The list of Who's On First IDs that this gallery supersedes.
+	Supersedes []int64 `json:"wof:supersedes,omitempty"`
+	// The list of Who's On First IDs that this gallery is superseded by.
+	SupersededBy []int64 `json:"wof:superseded_by,omitempty"`
+	// The (EDTF) deprecated date for the gallery, if it has been deprecated.
+	Deprecated string `json:"edtf:deprecated,omitempty"`
+	// The Who's On First GeoJSON Feature for the gallery, including its geometry and all of its properties. It is only populated by
+	// lookups that load records on demand, for example `ReaderGalleriesLookup`, and is never encoded.
+	Feature *geojson.Feature `json:"-"`
 }
 
 // String() will return the name of the gallery.
@@ -33,46 +91,153 @@ func (g *Gallery) String() string {
 	return fmt.Sprintf("%d#%d %s %s-%s (%d)", g.WhosOnFirstId, g.SFOMuseumId, g.Name, g.Inception, g.Cessation, g.IsCurrent)
 }
 
+// Code returns the primary code for the gallery which is its map ID or, if empty, its SFO Museum ID.
+func (g *Gallery) Code() string {
+
+	if g.MapId != "" {
+		return g.MapId
+	}
+
+	return strconv.FormatInt(g.SFOMuseumId, 10)
+}
+
+// Dates returns the (EDTF) inception and cessation dates for the gallery.
+func (g *Gallery) Dates() (string, string) {
+	return g.Inception, g.Cessation
+}
+
+// Id returns the Who's On First ID for the gallery.
+func (g *Gallery) Id() int64 {
+	return g.WhosOnFirstId
+}
+
+// Placetype returns the SFO Museum placetype for the gallery which is always `PLACETYPE`.
+func (g *Gallery) Placetype() string {
+	return PLACETYPE
+}
+
+// Status returns whether the gallery is current, superseded or deprecated.
+func (g *Gallery) Status() *architecture.Status {
+	return architecture.NewStatus(g.IsCurrent, g.SupersededBy, g.Deprecated)
+}
+
 // Return the Gallery matching 'code' that was active for 'date'. Multiple matches throw an error.
 func FindGalleryForDate(ctx context.Context, code string, date string) (*Gallery, error) {
 
-	lookup, err := NewLookup(ctx, "")
+	lookup, err := defaultLookup()
 
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
 	}
 
-	return FindGalleryForDateWithLookup(ctx, lookup, code, date)
+	return FindGalleryForDateWithTypedLookup(ctx, lookup, code, date)
 }
 
 // Return all the Galleries matching 'code' that were active for 'date'.
 func FindAllGalleriesForDate(ctx context.Context, code string, date string) ([]*Gallery, error) {
 
-	lookup, err := NewLookup(ctx, "")
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return FindAllGalleriesForDateWithTypedLookup(ctx, lookup, code, date)
+}
+
+// Return all the Galleries matching 'code' that existed at any point between 'start' and 'end'. See `GalleriesLookup.FindAllForRange` for details.
+func FindAllGalleriesForRange(ctx context.Context, code string, start string, end string) ([]*Gallery, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.FindAllForRange(ctx, code, start, end)
+}
+
+// SnapshotForDate returns a snapshot of all the Galleries that were active on 'date' keyed by their codes. See `GalleriesLookup.SnapshotForDate` for details.
+func SnapshotForDate(ctx context.Context, date string) (*architecture.Snapshot[*Gallery], error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.SnapshotForDate(ctx, date)
+}
+
+// Suggest returns a ranked list of gallery codes that are similar to 'code'. See `GalleriesLookup.Suggest` for details.
+func Suggest(ctx context.Context, code string) ([]*architecture.Suggestion, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Suggest(ctx, code)
+}
+
+// Codes returns the sorted list of (primary) codes for all the Galleries matching 'filter'. See `GalleriesLookup.Codes` for details.
+func Codes(ctx context.Context, filter *architecture.ListFilter) ([]string, error) {
+
+	lookup, err := defaultLookup()
 
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
 	}
 
-	return FindAllGalleriesForDateWithLookup(ctx, lookup, code, date)
+	return lookup.Codes(ctx, filter)
+}
+
+// All returns an iterator over all the Galleries matching 'filter' in chronological order. See `GalleriesLookup.All` for details.
+func All(ctx context.Context, filter *architecture.ListFilter) (iter.Seq[*Gallery], error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.All(ctx, filter)
+}
+
+// Len returns the total number of Galleries.
+func Len(ctx context.Context) (int, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return 0, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Len(ctx)
 }
 
 // Return the current Gallery matching 'code'. Multiple matches throw an error.
 func FindCurrentGallery(ctx context.Context, code string) (*Gallery, error) {
 
-	lookup, err := NewLookup(ctx, "")
+	lookup, err := defaultLookup()
 
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
 	}
 
-	return FindCurrentGalleryWithLookup(ctx, lookup, code)
+	return FindCurrentGalleryWithTypedLookup(ctx, lookup, code)
 }
 
 // Return the current Gallery matching 'code' with a custom architecture.Lookup instance. Multiple matches throw an error.
 func FindCurrentGalleryWithLookup(ctx context.Context, lookup architecture.Lookup, code string) (*Gallery, error) {
+	return FindCurrentGalleryWithTypedLookup(ctx, architecture.NewTypedLookup[*Gallery](lookup), code)
+}
+
+// FindCurrentGalleryWithTypedLookup is the same as `FindCurrentGalleryWithLookup` but with a custom architecture.TypedLookup[*Gallery] instance.
+func FindCurrentGalleryWithTypedLookup(ctx context.Context, lookup architecture.TypedLookup[*Gallery], code string) (*Gallery, error) {
 
-	current, err := FindGalleriesCurrentWithLookup(ctx, lookup, code)
+	current, err := FindGalleriesCurrentWithTypedLookup(ctx, lookup, code)
 
 	if err != nil {
 		return nil, err
@@ -80,11 +245,11 @@ func FindCurrentGalleryWithLookup(ctx context.Context, lookup architecture.Looku
 
 	switch len(current) {
 	case 0:
-		return nil, NotFound{code}
+		return nil, NotFound{Code: code, Reason: architecture.REASON_NO_CURRENT}
 	case 1:
 		return current[0], nil
 	default:
-		return nil, MultipleCandidates{code}
+		return nil, MultipleCandidates{Code: code, Reason: architecture.REASON_MULTIPLE_CURRENT, Candidates: current}
 	}
 
 }
@@ -92,17 +257,22 @@ func FindCurrentGalleryWithLookup(ctx context.Context, lookup architecture.Looku
 // Returns all Gallery instances matching 'code' that are marked as current.
 func FindGalleriesCurrent(ctx context.Context, code string) ([]*Gallery, error) {
 
-	lookup, err := NewLookup(ctx, "")
+	lookup, err := defaultLookup()
 
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
 	}
 
-	return FindGalleriesCurrentWithLookup(ctx, lookup, code)
+	return FindGalleriesCurrentWithTypedLookup(ctx, lookup, code)
 }
 
 // Returns all Gallery instances matching 'code' that are marked as current with a custom architecture.Lookup instance.
 func FindGalleriesCurrentWithLookup(ctx context.Context, lookup architecture.Lookup, code string) ([]*Gallery, error) {
+	return FindGalleriesCurrentWithTypedLookup(ctx, architecture.NewTypedLookup[*Gallery](lookup), code)
+}
+
+// FindGalleriesCurrentWithTypedLookup is the same as `FindGalleriesCurrentWithLookup` but with a custom architecture.TypedLookup[*Gallery] instance.
+func FindGalleriesCurrentWithTypedLookup(ctx context.Context, lookup architecture.TypedLookup[*Gallery], code string) ([]*Gallery, error) {
 
 	rsp, err := lookup.Find(ctx, code)
 
@@ -112,9 +282,7 @@ func FindGalleriesCurrentWithLookup(ctx context.Context, lookup architecture.Loo
 
 	current := make([]*Gallery, 0)
 
-	for _, r := range rsp {
-
-		g := r.(*Gallery)
+	for _, g := range rsp {
 
 		// if g.IsCurrent == 0 {
 		if g.IsCurrent != 1 {
@@ -127,10 +295,20 @@ func FindGalleriesCurrentWithLookup(ctx context.Context, lookup architecture.Loo
 	return current, nil
 }
 
-// Return the Gallery matching 'code' that was active for 'date' using 'lookup'. Multiple matches throw an error.
+// Return the Gallery matching 'code' that was active for 'date' using the architecture.Lookup instance 'lookup' and `DEFAULT_RESOLUTION_POLICY`. Multiple matches throw an error.
 func FindGalleryForDateWithLookup(ctx context.Context, lookup architecture.Lookup, code string, date string) (*Gallery, error) {
+	return FindGalleryForDateWithTypedLookup(ctx, architecture.NewTypedLookup[*Gallery](lookup), code, date)
+}
+
+// FindGalleryForDateWithTypedLookup is the same as `FindGalleryForDateWithLookup` but with a custom architecture.TypedLookup[*Gallery] instance.
+func FindGalleryForDateWithTypedLookup(ctx context.Context, lookup architecture.TypedLookup[*Gallery], code string, date string) (*Gallery, error) {
+	return FindGalleryForDateWithPolicy(ctx, lookup, code, date, DEFAULT_RESOLUTION_POLICY)
+}
 
-	galleries, err := FindAllGalleriesForDateWithLookup(ctx, lookup, code, date)
+// Return the Gallery matching 'code' that was active for 'date' using 'lookup' and 'policy'. Multiple matches throw an error.
+func FindGalleryForDateWithPolicy(ctx context.Context, lookup architecture.TypedLookup[*Gallery], code string, date string, policy architecture.ResolutionPolicy) (*Gallery, error) {
+
+	galleries, err := FindAllGalleriesForDateWithPolicy(ctx, lookup, code, date, policy)
 
 	if err != nil {
 		return nil, err
@@ -138,29 +316,37 @@ func FindGalleryForDateWithLookup(ctx context.Context, lookup architecture.Looku
 
 	switch len(galleries) {
 	case 0:
-		return nil, NotFound{code}
+		return nil, NotFound{Code: code, Date: date, Reason: architecture.REASON_NO_DATE_MATCH}
 	case 1:
 		return galleries[0], nil
 	default:
-		return nil, MultipleCandidates{code}
+		return nil, MultipleCandidates{Code: code, Date: date, Reason: architecture.REASON_MULTIPLE_DATE_MATCH, Candidates: galleries}
 	}
-
 }
 
-// Return all the Gallerys matching 'code' that were active for 'date' using 'lookup'.
+// Return all the Galleries matching 'code' that were active for 'date' using the architecture.Lookup instance 'lookup' and `DEFAULT_RESOLUTION_POLICY`.
 func FindAllGalleriesForDateWithLookup(ctx context.Context, lookup architecture.Lookup, code string, date string) ([]*Gallery, error) {
+	return FindAllGalleriesForDateWithTypedLookup(ctx, architecture.NewTypedLookup[*Gallery](lookup), code, date)
+}
+
+// FindAllGalleriesForDateWithTypedLookup is the same as `FindAllGalleriesForDateWithLookup` but with a custom architecture.TypedLookup[*Gallery] instance.
+func FindAllGalleriesForDateWithTypedLookup(ctx context.Context, lookup architecture.TypedLookup[*Gallery], code string, date string) ([]*Gallery, error) {
+	return FindAllGalleriesForDateWithPolicy(ctx, lookup, code, date, DEFAULT_RESOLUTION_POLICY)
+}
+
+// Return all the Galleries matching 'code' that were active for 'date' using 'lookup', choosing between multiple matches using 'policy'.
+// See `architecture.ResolutionPolicy` for details.
+func FindAllGalleriesForDateWithPolicy(ctx context.Context, lookup architecture.TypedLookup[*Gallery], code string, date string, policy architecture.ResolutionPolicy) ([]*Gallery, error) {
 
 	rsp, err := lookup.Find(ctx, code)
 
 	if err != nil {
-		return nil, fmt.Errorf("Failed to find gallerys for code, %w", err)
+		return nil, fmt.Errorf("Failed to find galleries for code, %w", err)
 	}
 
 	galleries := make([]*Gallery, 0)
 
-	for _, r := range rsp {
-
-		g := r.(*Gallery)
+	for _, g := range rsp {
 
 		inception := g.Inception
 		cessation := g.Cessation
@@ -173,67 +359,27 @@ func FindAllGalleriesForDateWithLookup(ctx context.Context, lookup architecture.
 		}
 
 		if !is_between {
-			slog.Debug("Gallery does not match date conditions", "code", code, "date", date, "gallery", g.Name, "inception", inception, "cessation", cessation)
+			slog.Debug("Gallery does not match date conditions", "id", g.WhosOnFirstId, "code", code, "date", date, "gallery", g.Name, "inception", inception, "cessation", cessation)
 			continue
 		}
 
-		slog.Debug("Gallery DOES match date conditions", "code", code, "date", date, "gallery id", g.WhosOnFirstId, "gallery", g.Name, "inception", inception, "cessation", cessation)
+		slog.Debug("Gallery DOES match date conditions", "id", g.WhosOnFirstId, "code", code, "date", date, "gallery", g.Name, "inception", inception, "cessation", cessation)
 		galleries = append(galleries, g)
 	}
 
-	if len(galleries) > 1 {
-
-		/*
-
-			Given the following scenario:
-
-			2024/07/26 15:50:27 DEBUG Gallery DOES match date conditions code=42 date=2024-06-17 "gallery id"=1914589529 gallery="AML 06 AML Photography" inception=2021-11-09 cessation=2024-06-17
-			2024/07/26 15:50:27 DEBUG Gallery DOES match date conditions code=42 date=2024-06-17 "gallery id"=1914601189 gallery="AML 06 AML Photography" inception=2024-06-17 cessation=..
-
-			Where, by virtue of 2024-06-17 being "between" the end date of one gallery and the start date of another, then
-			filter out matches that are not considered to be "current".
-
-		*/
-
-		current_galleries := make([]*Gallery, 0)
+	galleries = architecture.ApplyResolutionPolicy(policy, date, galleries, resolutionCandidate)
 
-		for _, g := range galleries {
-
-			if g.IsCurrent == 1 {
-				current_galleries = append(current_galleries, g)
-			}
-		}
-
-		if len(current_galleries) > 0 {
-			galleries = current_galleries
-		} else {
-
-			/*
-
-				But wait, there's more. What if the same situation exists (matching inception/cessation dates) but
-				none of the candidate galleries are "current" ? In that situation give precedence to records whose
-				inception date matches the date being queried against.
-
-				2024/07/26 16:07:56 DEBUG Gallery DOES match date conditions code=3 date=2021-11-09 "gallery id"=1745882483 gallery="3E Gate 76" inception=2021-05-25 cessation=2021-11-09
-				2024/07/26 16:07:56 DEBUG Gallery DOES match date conditions code=3 date=2021-11-09 "gallery id"=1763588523 gallery="F-03 Gate 76" inception=2021-11-09 cessation=2024-06-17
-
-			*/
-
-			starting_galleries := make([]*Gallery, 0)
-
-			for _, g := range galleries {
+	slog.Debug("Return galleries", "code", code, "date", date, "policy", policy, "count", len(galleries))
+	return galleries, nil
+}
 
-				if g.Inception == date {
-					starting_galleries = append(starting_galleries, g)
-				}
-			}
+func resolutionCandidate(g *Gallery) *architecture.ResolutionCandidate {
 
-			if len(starting_galleries) > 0 {
-				galleries = starting_galleries
-			}
-		}
+	c := &architecture.ResolutionCandidate{
+		IsCurrent: g.IsCurrent,
+		Inception: g.Inception,
+		Cessation: g.Cessation,
 	}
 
-	slog.Debug("Return galleries", "code", code, "date", date, "count", len(galleries))
-	return galleries, nil
+	return c
 }
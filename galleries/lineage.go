@@ -0,0 +1,57 @@
+package galleries
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+)
+
+func lineageNode(g *Gallery) *architecture.LineageNode {
+
+	n := &architecture.LineageNode{
+		Id:           g.WhosOnFirstId,
+		Supersedes:   g.Supersedes,
+		SupersededBy: g.SupersededBy,
+		Inception:    g.Inception,
+		Cessation:    g.Cessation,
+	}
+
+	return n
+}
+
+// Lineage returns the supersession chain, in date order, for the gallery with Who's On First ID 'id'. See `architecture.TableLookup.Lineage` for details.
+func Lineage(ctx context.Context, id int64) (*architecture.Lineage[*Gallery], error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Lineage(ctx, id)
+}
+
+// Predecessors returns all the galleries, in date order, that the gallery with Who's On First ID 'id' supersedes directly or indirectly.
+func Predecessors(ctx context.Context, id int64) ([]*Gallery, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Predecessors(ctx, id)
+}
+
+// Successors returns all the galleries, in date order, that supersede the gallery with Who's On First ID 'id' directly or indirectly.
+func Successors(ctx context.Context, id int64) ([]*Gallery, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Successors(ctx, id)
+}
@@ -2,52 +2,93 @@ package galleries
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
+	"io/fs"
 	"net/url"
-	"sort"
+	"os"
+	"path/filepath"
 	"strconv"
-	"strings"
 	"sync"
-	"sync/atomic"
 
+	"github.com/paulmach/orb/geojson"
 	"github.com/sfomuseum/go-sfomuseum-architecture"
 	"github.com/sfomuseum/go-sfomuseum-architecture/data"
 )
 
-const DATA_JSON string = "galleries.json"
+// The SFO Museum placetype for galleries.
+const PLACETYPE string = "gallery"
 
-var lookup_table *sync.Map
-var lookup_idx int64
+// The name of the compiled data file, in the `architecture.DataEnvelope` format.
+const DATA_JSON string = "galleries.json"
 
-var lookup_init sync.Once
-var lookup_init_err error
+// The name of the gzip-compressed copy of `DATA_JSON`. This is the only copy that is embedded, and published alongside the `SHA256SUMS`
+// checksum manifest, and the one read by default.
+const DATA_JSON_GZIP string = DATA_JSON + ".gz"
+
+// The maximum edit distance for codes returned by `Suggest`.
+const SUGGEST_MAX_DISTANCE int = 2
+
+var default_lookup *GalleriesLookup
+var default_lookup_mu = new(sync.Mutex)
+
+// record_kind describes galleries to the generic lookups in the architecture package.
+var record_kind = &architecture.RecordKind[*Gallery]{
+	Placetype:          PLACETYPE,
+	Label:              "gallery",
+	Codes:              possibleCodes,
+	Candidate:          resolutionCandidate,
+	NotFound:           notFound,
+	FromFeature:        newGalleryFromFeature,
+	WithFeature:        withFeature,
+	LineageNode:        lineageNode,
+	SuggestMaxDistance: SUGGEST_MAX_DISTANCE,
+	SQLiteIndices:      sqlite_indices,
+	SQLiteQueries:      sqlite_queries,
+	SQLiteCodeExpr:     sqlite_code_expr,
+}
 
-type GalleriesLookupFunc func(context.Context)
+// GalleriesLookupFunc is a function that, when invoked, returns the list of `Gallery` records to be used by a `GalleriesLookup` instance.
+type GalleriesLookupFunc func(context.Context) ([]*Gallery, error)
 
+// GalleriesLookup implements the `architecture.TypedLookup[*Gallery]` interface for galleries. Each instance has its own lookup table. See
+// `architecture.TableLookup` for details of the methods used to query, list, change and export the records in the lookup table.
 type GalleriesLookup struct {
-	architecture.Lookup
+	*architecture.TableLookup[*Gallery]
 }
 
 func init() {
 	ctx := context.Background()
 	architecture.RegisterLookup(ctx, "galleries", NewLookup)
-
-	lookup_idx = int64(0)
+	architecture.RegisterFeatureRecordFunc(ctx, PLACETYPE, "galleries", newGalleryRecord)
 }
 
-// NewLookup will return an `architecture.Lookup` instance. By default the lookup table is derived from precompiled (embedded) data in `data/galleries.json`
+// NewLookup will return an `architecture.Lookup` instance. By default the lookup table is derived from precompiled (embedded) gzip-compressed data in `data/galleries.json.gz`
 // by passing in `sfomuseum://` as the URI. It is also possible to create a new lookup table with the following URI options:
 //
 //	`sfomuseum://github`
 //
-// This will cause the lookup table to be derived from the data stored at https://raw.githubusercontent.com/sfomuseum/go-sfomuseum-architecture/main/data/galleries.json. This might be desirable if there have been updates to the underlying data that are not reflected in the locally installed package's pre-compiled data.
+// This will cause the lookup table to be derived from the data stored at https://raw.githubusercontent.com/sfomuseum/go-sfomuseum-architecture/main/data/galleries.json.gz. This might be desirable if there have been updates to the underlying data that are not reflected in the locally installed package's pre-compiled data.
+//
+//	`sfomuseum://remote?url={URL}`
+//
+// This will cause the lookup table to be derived from the data stored at `{URL}/galleries.json.gz`. Both the `github` and `remote` options verify the data against the `SHA256SUMS` checksum manifest published alongside it and accept optional `cache`, `verify`, `fallback` and `timeout` parameters. See `architecture.NewRemoteSourceWithURL` for details.
 //
 //	`sfomuseum://iterator?uri={URI}&source={SOURCE}`
 //
 // This will cause the lookup table to be derived, at runtime, from data emitted by a `whosonfirst/go-whosonfirst-iterate` instance. `{URI}` should be a valid `whosonfirst/go-whosonfirst-iterate/iterator` URI and `{SOURCE}` is one or more URIs for the iterator to process.
+//
+//	`sfomuseum://file?path={PATH}`
+//
+// This will cause the lookup table to be derived from the data stored in the local file `{PATH}`. It is assumed that the data in `{PATH}` will be formatted in the same way as the precompiled (embedded) data. This might be desirable if you want to pin a specific release of the data.
+//
+//	`sfomuseum://sqlite?dsn={DSN}`
+//
+// This will cause galleries to be queried, as needed, from the Who's On First SQLite database `{DSN}` rather than being loaded in to memory. See `NewSQLiteGalleriesLookup` for details.
+//
+//	`sfomuseum://reader?reader={READER_URI}`
+//
+// This will cause galleries to be read, as needed, from the `whosonfirst/go-reader` URI `{READER_URI}` and cached, keeping only an index of their codes in memory. See `NewReaderGalleriesLookup` for details.
 func NewLookup(ctx context.Context, uri string) (architecture.Lookup, error) {
 
 	u, err := url.Parse(uri)
@@ -56,6 +97,70 @@ func NewLookup(ctx context.Context, uri string) (architecture.Lookup, error) {
 		return nil, fmt.Errorf("Failed to parse URI, %w", err)
 	}
 
+	switch u.Host {
+	case "sqlite":
+
+		l, err := NewSQLiteGalleriesLookup(ctx, uri)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return architecture.NewUntypedLookup[*Gallery](l), nil
+
+	case "reader":
+
+		l, err := NewReaderGalleriesLookup(ctx, uri)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return architecture.NewUntypedLookup[*Gallery](l), nil
+	}
+
+	l, err := NewGalleriesLookup(ctx, uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return architecture.NewUntypedLookup[*Gallery](l), nil
+}
+
+// NewGalleriesLookup will return a `GalleriesLookup` instance derived from 'uri'. See `NewLookup` for details on the URI options.
+func NewGalleriesLookup(ctx context.Context, uri string) (*GalleriesLookup, error) {
+
+	lookup_func, err := NewLookupFuncWithURI(ctx, uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewGalleriesLookupWithLookupFunc(ctx, lookup_func)
+}
+
+// NewGalleriesLookupWithFS will return a `GalleriesLookup` instance derived from the data stored in 'filename' in 'fsys'. See `NewLookupFuncWithFS` for details.
+func NewGalleriesLookupWithFS(ctx context.Context, fsys fs.FS, filename string) (*GalleriesLookup, error) {
+
+	lookup_func, err := NewLookupFuncWithFS(ctx, fsys, filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewGalleriesLookupWithLookupFunc(ctx, lookup_func)
+}
+
+// NewLookupFuncWithURI will return a `GalleriesLookupFunc` function instance derived from 'uri'. See `NewLookup` for details on the URI options.
+func NewLookupFuncWithURI(ctx context.Context, uri string) (GalleriesLookupFunc, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
 	// Reminder: u.Scheme is used by the architecture.Lookup constructor
 
 	switch u.Host {
@@ -66,50 +171,87 @@ func NewLookup(ctx context.Context, uri string) (architecture.Lookup, error) {
 		iterator_uri := q.Get("uri")
 		iterator_sources := q["source"]
 
-		return NewLookupFromIterator(ctx, iterator_uri, iterator_sources...)
+		galleries_list, err := CompileGalleriesData(ctx, iterator_uri, iterator_sources...)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to compile galleries data, %w", err)
+		}
+
+		return NewLookupFuncWithGalleries(ctx, galleries_list), nil
 
-	case "github":
+	case "github", "remote":
 
-		data_url := fmt.Sprintf("https://raw.githubusercontent.com/sfomuseum/go-sfomuseum-architecture/main/data/%s", DATA_JSON)
-		rsp, err := http.Get(data_url)
+		src, err := architecture.NewRemoteSourceWithURL(u, data.FS)
 
 		if err != nil {
-			return nil, fmt.Errorf("Failed to load remote data from Github, %w", err)
+			return nil, fmt.Errorf("Failed to create remote source, %w", err)
+		}
+
+		fh, err := src.Open(ctx, DATA_JSON_GZIP)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load remote data, %w", err)
+		}
+
+		return NewLookupFuncWithReader(ctx, fh), nil
+
+	case "file":
+
+		path := u.Query().Get("path")
+
+		if path == "" {
+			return nil, fmt.Errorf("Missing ?path= parameter")
 		}
 
-		lookup_func := NewLookupFuncWithReader(ctx, rsp.Body)
-		return NewLookupWithLookupFunc(ctx, lookup_func)
+		return NewLookupFuncWithFS(ctx, os.DirFS(filepath.Dir(path)), filepath.Base(path))
+
+	case "sqlite":
+
+		return nil, fmt.Errorf("The sqlite host can not be used to derive a lookup table, use NewSQLiteGalleriesLookup instead")
+
+	case "reader":
+
+		return nil, fmt.Errorf("The reader host can not be used to derive a lookup table, use NewReaderGalleriesLookup instead")
 
 	default:
 
-		fs := data.FS
-		fh, err := fs.Open(DATA_JSON)
+		lookup_func, err := NewLookupFuncWithFS(ctx, data.FS, DATA_JSON_GZIP)
 
 		if err != nil {
 			return nil, fmt.Errorf("Failed to load local precompiled data, %w", err)
 		}
 
-		lookup_func := NewLookupFuncWithReader(ctx, fh)
-		return NewLookupWithLookupFunc(ctx, lookup_func)
+		return lookup_func, nil
+	}
+}
+
+// NewLookupFuncWithFS will return a `GalleriesLookupFunc` function instance that, when invoked, will populate an `architecture.Lookup` instance with data stored in
+// 'filename' in 'fsys'. It is assumed that the data in 'filename' will be formatted in the same way as the precompiled (embedded) data stored in `data/galleries.json.gz`, with or without gzip compression.
+func NewLookupFuncWithFS(ctx context.Context, fsys fs.FS, filename string) (GalleriesLookupFunc, error) {
+
+	fh, err := fsys.Open(filename)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open %s, %w", filename, err)
 	}
+
+	return NewLookupFuncWithReader(ctx, fh), nil
 }
 
 // NewLookup will return an `GalleriesLookupFunc` function instance that, when invoked, will populate an `architecture.Lookup` instance with data stored in `r`.
 // `r` will be closed when the `GalleriesLookupFunc` function instance is invoked.
-// It is assumed that the data in `r` will be formatted in the same way as the procompiled (embedded) data stored in `data/sfomuseum.json`.
+// It is assumed that the data in `r` will be formatted in the same way as the procompiled (embedded) data stored in `data/galleries.json.gz`, with or without gzip compression. Both the versioned
+// `architecture.DataEnvelope` format and the legacy bare JSON array format are supported. See `architecture.DecodeData` for details.
 func NewLookupFuncWithReader(ctx context.Context, r io.ReadCloser) GalleriesLookupFunc {
 
 	defer r.Close()
 
-	var galleries_list []*Gallery
-
-	dec := json.NewDecoder(r)
-	err := dec.Decode(&galleries_list)
+	galleries_list, _, err := architecture.DecodeData[*Gallery](r, PLACETYPE)
 
 	if err != nil {
 
-		lookup_func := func(ctx context.Context) {
-			lookup_init_err = err
+		lookup_func := func(ctx context.Context) ([]*Gallery, error) {
+			return nil, fmt.Errorf("Failed to decode data, %w", err)
 		}
 
 		return lookup_func
@@ -121,115 +263,142 @@ func NewLookupFuncWithReader(ctx context.Context, r io.ReadCloser) GalleriesLook
 // NewLookup will return an `GalleriesLookupFunc` function instance that, when invoked, will populate an `architecture.Lookup` instance with data stored in `galleries_list`.
 func NewLookupFuncWithGalleries(ctx context.Context, galleries_list []*Gallery) GalleriesLookupFunc {
 
-	lookup_func := func(ctx context.Context) {
-
-		table := new(sync.Map)
-
-		for _, data := range galleries_list {
-
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				// pass
-			}
-
-			appendData(ctx, table, data)
-		}
-
-		lookup_table = table
+	lookup_func := func(ctx context.Context) ([]*Gallery, error) {
+		return galleries_list, nil
 	}
 
 	return lookup_func
 }
 
 // NewLookupWithLookupFunc will return an `architecture.Lookup` instance derived by data compiled using `lookup_func`.
+// Each call to `lookup_func` produces a new lookup table so the instances returned by this method do not share any data.
 func NewLookupWithLookupFunc(ctx context.Context, lookup_func GalleriesLookupFunc) (architecture.Lookup, error) {
 
-	fn := func() {
-		lookup_func(ctx)
-	}
-
-	lookup_init.Do(fn)
+	l, err := NewGalleriesLookupWithLookupFunc(ctx, lookup_func)
 
-	if lookup_init_err != nil {
-		return nil, lookup_init_err
+	if err != nil {
+		return nil, err
 	}
 
-	l := GalleriesLookup{}
-	return &l, nil
+	return architecture.NewUntypedLookup[*Gallery](l), nil
 }
 
-func NewLookupFromIterator(ctx context.Context, iterator_uri string, iterator_sources ...string) (architecture.Lookup, error) {
+// NewGalleriesLookupWithLookupFunc will return a `GalleriesLookup` instance derived by data compiled using `lookup_func`.
+func NewGalleriesLookupWithLookupFunc(ctx context.Context, lookup_func GalleriesLookupFunc) (*GalleriesLookup, error) {
 
-	galleries_list, err := CompileGalleriesData(ctx, iterator_uri, iterator_sources...)
+	galleries_list, err := lookup_func(ctx)
 
 	if err != nil {
-		return nil, fmt.Errorf("Failed to compile galleries data, %w", err)
+		return nil, err
 	}
 
-	lookup_func := NewLookupFuncWithGalleries(ctx, galleries_list)
-	return NewLookupWithLookupFunc(ctx, lookup_func)
+	table, err := architecture.NewTableLookup(ctx, record_kind, galleries_list)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := &GalleriesLookup{
+		TableLookup: table,
+	}
+
+	return l, nil
 }
 
-func (l *GalleriesLookup) Find(ctx context.Context, code string) ([]interface{}, error) {
+// defaultLookup returns a package-level `GalleriesLookup` instance derived from the precompiled (embedded) data. It is created lazily the first
+// time it is needed with a background context, rather than the context of the first caller which may be cancelled while it is still
+// being used, and a failure to create it is not retained so that it is attempted again by the next caller.
+func defaultLookup() (*GalleriesLookup, error) {
 
-	pointers, ok := lookup_table.Load(code)
+	default_lookup_mu.Lock()
+	defer default_lookup_mu.Unlock()
 
-	if !ok {
-		return nil, fmt.Errorf("Code '%s' not found", code)
+	if default_lookup != nil {
+		return default_lookup, nil
 	}
 
-	galleries := make([]*Gallery, 0)
+	lookup, err := NewGalleriesLookup(context.Background(), "")
 
-	for _, p := range pointers.([]string) {
+	if err != nil {
+		return nil, err
+	}
 
-		if !strings.HasPrefix(p, "pointer:") {
-			return nil, fmt.Errorf("Invalid pointer '%s'", p)
-		}
+	default_lookup = lookup
+	return default_lookup, nil
+}
 
-		row, ok := lookup_table.Load(p)
+// Reload replaces the data used by the package-level `Find*` methods with data derived from 'uri'. See `NewLookup` for details on the URI options.
+func Reload(ctx context.Context, uri string) error {
 
-		if !ok {
-			return nil, fmt.Errorf("Invalid pointer '%s'", p)
-		}
+	lookup, err := defaultLookup()
 
-		galleries = append(galleries, row.(*Gallery))
+	if err != nil {
+		return err
 	}
 
-	sort.Slice(galleries, func(i, j int) bool {
+	return lookup.Reload(ctx, uri)
+}
 
-		inception_i := galleries[i].Inception
-		cessation_i := galleries[i].Cessation
+// NewLookupFromIterator will return an `architecture.Lookup` instance derived from data compiled by `CompileGalleriesData`.
+func NewLookupFromIterator(ctx context.Context, iterator_uri string, iterator_sources ...string) (architecture.Lookup, error) {
+
+	l, err := NewGalleriesLookupFromIterator(ctx, iterator_uri, iterator_sources...)
 
-		inception_j := galleries[j].Inception
-		cessation_j := galleries[j].Cessation
+	if err != nil {
+		return nil, err
+	}
 
-		date_i := fmt.Sprintf("%s - %s", inception_i, cessation_i)
-		date_j := fmt.Sprintf("%s - %s", inception_j, cessation_j)
+	return architecture.NewUntypedLookup[*Gallery](l), nil
+}
 
-		return date_i < date_j
-	})
+// NewGalleriesLookupFromIterator will return a `GalleriesLookup` instance derived from data compiled by `CompileGalleriesData`.
+func NewGalleriesLookupFromIterator(ctx context.Context, iterator_uri string, iterator_sources ...string) (*GalleriesLookup, error) {
 
-	galleries_list := make([]interface{}, len(galleries))
+	galleries_list, err := CompileGalleriesData(ctx, iterator_uri, iterator_sources...)
 
-	for idx, g := range galleries {
-		galleries_list[idx] = g
+	if err != nil {
+		return nil, fmt.Errorf("Failed to compile galleries data, %w", err)
 	}
 
-	return galleries_list, nil
+	lookup_func := NewLookupFuncWithGalleries(ctx, galleries_list)
+	return NewGalleriesLookupWithLookupFunc(ctx, lookup_func)
 }
 
-func (l *GalleriesLookup) Append(ctx context.Context, data interface{}) error {
-	return appendData(ctx, lookup_table, data.(*Gallery))
+// Reload replaces the lookup table with a new table derived from 'uri'. See `NewLookup` for details on the URI options.
+// The new table is built in full before it replaces the current table so calls to `Find` will see either the old data or the
+// new data but never a mix of both. If the new table can not be built the current table is left in place and an error is returned.
+// Any changes made to the table (for example with `Append`) while the new table is being built are lost.
+func (l *GalleriesLookup) Reload(ctx context.Context, uri string) error {
+
+	lookup_func, err := NewLookupFuncWithURI(ctx, uri)
+
+	if err != nil {
+		return fmt.Errorf("Failed to derive lookup function, %w", err)
+	}
+
+	return l.ReloadWithLookupFunc(ctx, lookup_func)
 }
 
-func appendData(ctx context.Context, table *sync.Map, data *Gallery) error {
+// ReloadWithLookupFunc replaces the lookup table with a new table derived from data compiled using `lookup_func`.
+func (l *GalleriesLookup) ReloadWithLookupFunc(ctx context.Context, lookup_func GalleriesLookupFunc) error {
+
+	galleries_list, err := lookup_func(ctx)
+
+	if err != nil {
+		return fmt.Errorf("Failed to reload lookup table, %w", err)
+	}
+
+	err = l.Replace(ctx, galleries_list)
+
+	if err != nil {
+		return fmt.Errorf("Failed to reload lookup table, %w", err)
+	}
 
-	idx := atomic.AddInt64(&lookup_idx, 1)
+	return nil
+}
 
-	pointer := fmt.Sprintf("pointer:%d", idx)
-	table.Store(pointer, data)
+// possibleCodes returns the list of codes that 'data' can be found by.
+func possibleCodes(data *Gallery) []string {
 
 	str_wofid := strconv.FormatInt(data.WhosOnFirstId, 10)
 	str_sfomid := strconv.FormatInt(data.SFOMuseumId, 10)
@@ -243,37 +412,13 @@ func appendData(ctx context.Context, table *sync.Map, data *Gallery) error {
 		possible_codes = append(possible_codes, data.MapId)
 	}
 
-	for _, code := range possible_codes {
-
-		if code == "" {
-			continue
-		}
-
-		pointers := make([]string, 0)
-		has_pointer := false
-
-		others, ok := table.Load(code)
-
-		if ok {
-
-			pointers = others.([]string)
-		}
-
-		for _, dupe := range pointers {
-
-			if dupe == pointer {
-				has_pointer = true
-				break
-			}
-		}
-
-		if has_pointer {
-			continue
-		}
+	return possible_codes
+}
 
-		pointers = append(pointers, pointer)
-		table.Store(code, pointers)
-	}
+func notFound(code string, reason string) error {
+	return NotFound{Code: code, Reason: reason}
+}
 
-	return nil
+func withFeature(r *Gallery, f *geojson.Feature) {
+	r.Feature = f
 }
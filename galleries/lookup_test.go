@@ -1,10 +1,24 @@
 package galleries
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
 
 	"github.com/sfomuseum/go-sfomuseum-architecture"
+	"github.com/sfomuseum/go-sfomuseum-architecture/data"
+	"github.com/sfomuseum/go-sfomuseum-architecture/internal/testutil"
 )
 
 func TestGalleriesLookup(t *testing.T) {
@@ -49,3 +63,807 @@ func TestGalleriesLookup(t *testing.T) {
 		}
 	}
 }
+
+func TestGalleriesLookupInstances(t *testing.T) {
+
+	ctx := context.Background()
+
+	g := &Gallery{
+		WhosOnFirstId: 1000000001,
+		Name:          "Z1",
+		IsCurrent:     1,
+		Inception:     "2024",
+		Cessation:     "..",
+	}
+
+	lookup_func := NewLookupFuncWithGalleries(ctx, []*Gallery{g})
+
+	lu_a, err := NewLookupWithLookupFunc(ctx, lookup_func)
+
+	if err != nil {
+		t.Fatalf("Failed to create first lookup, %v", err)
+	}
+
+	lu_b, err := NewLookupWithLookupFunc(ctx, lookup_func)
+
+	if err != nil {
+		t.Fatalf("Failed to create second lookup, %v", err)
+	}
+
+	g2 := &Gallery{
+		WhosOnFirstId: 1000000002,
+		Name:          "Z1",
+		IsCurrent:     0,
+		Inception:     "2020",
+		Cessation:     "2024",
+	}
+
+	err = lu_a.Append(ctx, g2)
+
+	if err != nil {
+		t.Fatalf("Failed to append gallery, %v", err)
+	}
+
+	results_a, err := lu_a.Find(ctx, "1000000002")
+
+	if err != nil {
+		t.Fatalf("Expected to find appended gallery in first lookup, %v", err)
+	}
+
+	if len(results_a) != 1 {
+		t.Fatalf("Unexpected results for first lookup, %d", len(results_a))
+	}
+
+	_, err = lu_b.Find(ctx, "1000000002")
+
+	if err == nil {
+		t.Fatalf("Did not expect to find appended gallery in second lookup")
+	}
+
+	_, err = lu_b.Find(ctx, "1000000001")
+
+	if err != nil {
+		t.Fatalf("Failed to find gallery in second lookup, %v", err)
+	}
+
+	embedded_lu, err := NewLookup(ctx, "galleries://")
+
+	if err != nil {
+		t.Fatalf("Failed to create embedded lookup, %v", err)
+	}
+
+	_, err = embedded_lu.Find(ctx, "1000000001")
+
+	if err == nil {
+		t.Fatalf("Did not expect to find custom gallery in embedded lookup")
+	}
+}
+
+func TestGalleriesLookupReload(t *testing.T) {
+
+	ctx := context.Background()
+
+	g := &Gallery{
+		WhosOnFirstId: 1000000001,
+		Name:          "Z1",
+		IsCurrent:     1,
+		Inception:     "2024",
+		Cessation:     "..",
+	}
+
+	lookup_func := NewLookupFuncWithGalleries(ctx, []*Gallery{g})
+
+	lu, err := NewGalleriesLookupWithLookupFunc(ctx, lookup_func)
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	_, err = lu.Find(ctx, "2D")
+
+	if err == nil {
+		t.Fatalf("Did not expect to find 2D before reloading")
+	}
+
+	err = lu.Reload(ctx, "galleries://")
+
+	if err != nil {
+		t.Fatalf("Failed to reload lookup, %v", err)
+	}
+
+	_, err = lu.Find(ctx, "2D")
+
+	if err != nil {
+		t.Fatalf("Failed to find 2D after reloading, %v", err)
+	}
+
+	_, err = lu.Find(ctx, "1000000001")
+
+	if err == nil {
+		t.Fatalf("Did not expect to find custom gallery after reloading")
+	}
+
+	r := io.NopCloser(strings.NewReader("{"))
+
+	err = lu.ReloadWithLookupFunc(ctx, NewLookupFuncWithReader(ctx, r))
+
+	if err == nil {
+		t.Fatalf("Expected reload with invalid data to fail")
+	}
+
+	_, err = lu.Find(ctx, "2D")
+
+	if err != nil {
+		t.Fatalf("Failed to find 2D after failed reload, %v", err)
+	}
+}
+
+func TestGalleriesLookupMutate(t *testing.T) {
+
+	ctx := context.Background()
+
+	lookup_func := NewLookupFuncWithGalleries(ctx, []*Gallery{
+		&Gallery{WhosOnFirstId: 1000000001, MapId: "Z1"},
+		&Gallery{WhosOnFirstId: 1000000002, MapId: "Z2"},
+	})
+
+	lu, err := NewGalleriesLookupWithLookupFunc(ctx, lookup_func)
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	err = lu.Append(ctx, &Gallery{WhosOnFirstId: 1000000001, MapId: "Z1"})
+
+	if err != nil {
+		t.Fatalf("Failed to append gallery, %v", err)
+	}
+
+	rsp, err := lu.Find(ctx, "Z1")
+
+	if err != nil {
+		t.Fatalf("Failed to find Z1, %v", err)
+	}
+
+	if len(rsp) != 1 {
+		t.Fatalf("Expected re-appended gallery to replace the original, got %d results", len(rsp))
+	}
+
+	err = lu.Update(ctx, &Gallery{WhosOnFirstId: 1000000001, MapId: "Z9"})
+
+	if err != nil {
+		t.Fatalf("Failed to update gallery, %v", err)
+	}
+
+	_, err = lu.Find(ctx, "Z1")
+
+	if !IsNotFound(err) {
+		t.Fatalf("Expected old code to be removed by update, got %v", err)
+	}
+
+	for _, code := range []string{"Z9", "1000000001"} {
+
+		rsp, err := lu.Find(ctx, code)
+
+		if err != nil {
+			t.Fatalf("Failed to find updated gallery by %s, %v", code, err)
+		}
+
+		if len(rsp) != 1 || rsp[0].MapId != "Z9" {
+			t.Fatalf("Unexpected results for %s, %v", code, rsp)
+		}
+	}
+
+	err = lu.Update(ctx, &Gallery{WhosOnFirstId: 1000000003, MapId: "Z3"})
+
+	if !errors.Is(err, architecture.ErrNotFound) {
+		t.Fatalf("Expected update of unknown gallery to fail, got %v", err)
+	}
+
+	err = lu.Remove(ctx, 1000000002)
+
+	if err != nil {
+		t.Fatalf("Failed to remove gallery, %v", err)
+	}
+
+	for _, code := range []string{"Z2", "1000000002"} {
+
+		_, err := lu.Find(ctx, code)
+
+		if !IsNotFound(err) {
+			t.Fatalf("Expected removed gallery to not be found by %s, got %v", code, err)
+		}
+	}
+
+	err = lu.Remove(ctx, 1000000002)
+
+	if !errors.Is(err, architecture.ErrNotFound) {
+		t.Fatalf("Expected removal of unknown gallery to fail, got %v", err)
+	}
+
+	err = lu.AppendBatch(ctx, []*Gallery{&Gallery{WhosOnFirstId: 1000000004, MapId: "Z4"}, nil})
+
+	if err == nil {
+		t.Fatalf("Expected batch with invalid gallery to fail")
+	}
+
+	_, err = lu.Find(ctx, "Z4")
+
+	if !IsNotFound(err) {
+		t.Fatalf("Expected failed batch to not be applied, got %v", err)
+	}
+
+	wg := new(sync.WaitGroup)
+
+	for i := 0; i < 50; i++ {
+
+		wg.Add(1)
+
+		go func(id int64) {
+
+			defer wg.Done()
+
+			err := lu.AppendBatch(ctx, []*Gallery{&Gallery{WhosOnFirstId: id, MapId: "Z5"}, &Gallery{WhosOnFirstId: id, MapId: "Z5"}})
+
+			if err != nil {
+				t.Errorf("Failed to append gallery %d, %v", id, err)
+			}
+
+		}(int64(1000000100 + i))
+	}
+
+	wg.Wait()
+
+	rsp, err = lu.Find(ctx, "Z5")
+
+	if err != nil {
+		t.Fatalf("Failed to find Z5, %v", err)
+	}
+
+	if len(rsp) != 50 {
+		t.Fatalf("Expected 50 concurrently appended galleries, got %d", len(rsp))
+	}
+
+	count, err := lu.Len(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to count galleries, %v", err)
+	}
+
+	if count != 51 {
+		t.Fatalf("Unexpected number of galleries, %d", count)
+	}
+}
+
+func TestGalleriesLookupFindAllForRange(t *testing.T) {
+
+	ctx := context.Background()
+
+	lu, err := NewGalleriesLookup(ctx, "galleries://")
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	tests := map[[3]string][]int64{
+		[3]string{"2D", "2020", "2020"}: []int64{1729813701},
+		[3]string{"2D", "2030", ".."}:   []int64{1947304801, 1947304803},
+	}
+
+	for q, expected := range tests {
+
+		rsp, err := lu.FindAllForRange(ctx, q[0], q[1], q[2])
+
+		if err != nil {
+			t.Fatalf("Failed to find %s for %s - %s, %v", q[0], q[1], q[2], err)
+		}
+
+		if len(rsp) != len(expected) {
+			t.Fatalf("Unexpected count for %s for %s - %s, expected %d but got %d", q[0], q[1], q[2], len(expected), len(rsp))
+		}
+
+		ids := make(map[int64]bool)
+
+		for _, r := range rsp {
+			ids[r.WhosOnFirstId] = true
+		}
+
+		for _, id := range expected {
+
+			if !ids[id] {
+				t.Fatalf("Expected %d in results for %s for %s - %s", id, q[0], q[1], q[2])
+			}
+		}
+	}
+
+	_, err = lu.FindAllForRange(ctx, "2D", "bogus", "..")
+
+	if err == nil {
+		t.Fatalf("Expected invalid range to fail")
+	}
+}
+
+func TestGalleriesLookupWriteTo(t *testing.T) {
+
+	ctx := context.Background()
+
+	lu, err := NewGalleriesLookup(ctx, "")
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	err = lu.Append(ctx, &Gallery{WhosOnFirstId: 1000000001, MapId: "Z1", IsCurrent: 1, Inception: "2024", Cessation: ".."})
+
+	if err != nil {
+		t.Fatalf("Failed to append gallery, %v", err)
+	}
+
+	var buf_a bytes.Buffer
+
+	err = lu.WriteTo(ctx, &buf_a)
+
+	if err != nil {
+		t.Fatalf("Failed to write lookup, %v", err)
+	}
+
+	lookup_func := NewLookupFuncWithReader(ctx, io.NopCloser(bytes.NewReader(buf_a.Bytes())))
+
+	lu_b, err := NewGalleriesLookupWithLookupFunc(ctx, lookup_func)
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup from exported data, %v", err)
+	}
+
+	_, err = lu_b.Find(ctx, "Z1")
+
+	if err != nil {
+		t.Fatalf("Failed to find appended gallery in exported data, %v", err)
+	}
+
+	count_a, _ := lu.Len(ctx)
+	count_b, _ := lu_b.Len(ctx)
+
+	if count_a != count_b {
+		t.Fatalf("Unexpected number of galleries in exported data, expected %d but got %d", count_a, count_b)
+	}
+
+	var buf_b bytes.Buffer
+
+	err = lu_b.WriteTo(ctx, &buf_b)
+
+	if err != nil {
+		t.Fatalf("Failed to write exported lookup, %v", err)
+	}
+
+	if !bytes.Equal(buf_a.Bytes(), buf_b.Bytes()) {
+		t.Fatalf("Expected exported data to round-trip exactly")
+	}
+}
+
+func TestGalleriesLookupGzip(t *testing.T) {
+
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		DATA_JSON: &fstest.MapFile{Data: uncompressedData(t)},
+	}
+
+	json_lu, err := NewGalleriesLookupWithFS(ctx, fsys, DATA_JSON)
+
+	if err != nil {
+		t.Fatalf("Failed to create JSON lookup, %v", err)
+	}
+
+	gzip_lu, err := NewGalleriesLookupWithFS(ctx, data.FS, DATA_JSON_GZIP)
+
+	if err != nil {
+		t.Fatalf("Failed to create gzip lookup, %v", err)
+	}
+
+	json_rsp, err := json_lu.Find(ctx, "2E")
+
+	if err != nil {
+		t.Fatalf("Failed to find 2E in JSON lookup, %v", err)
+	}
+
+	gzip_rsp, err := gzip_lu.Find(ctx, "2E")
+
+	if err != nil {
+		t.Fatalf("Failed to find 2E in gzip lookup, %v", err)
+	}
+
+	if len(json_rsp) != len(gzip_rsp) {
+		t.Fatalf("Unexpected count for 2E in gzip lookup, expected %d but got %d", len(json_rsp), len(gzip_rsp))
+	}
+
+	for i, r := range json_rsp {
+
+		if gzip_rsp[i].WhosOnFirstId != r.WhosOnFirstId {
+			t.Fatalf("Unexpected record at position %d in gzip lookup", i)
+		}
+	}
+}
+
+// uncompressedData returns the uncompressed copy of the precompiled (embedded) data.
+func uncompressedData(t testing.TB) []byte {
+
+	fh, err := data.FS.Open(DATA_JSON_GZIP)
+
+	if err != nil {
+		t.Fatalf("Failed to open embedded data, %v", err)
+	}
+
+	defer fh.Close()
+
+	gz, err := gzip.NewReader(fh)
+
+	if err != nil {
+		t.Fatalf("Failed to create gzip reader, %v", err)
+	}
+
+	body, err := io.ReadAll(gz)
+
+	if err != nil {
+		t.Fatalf("Failed to decompress embedded data, %v", err)
+	}
+
+	return body
+}
+
+func benchmarkGalleriesLookupWithFS(b *testing.B, fsys fs.FS, filename string) {
+
+	ctx := context.Background()
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+
+		_, err := NewGalleriesLookupWithFS(ctx, fsys, filename)
+
+		if err != nil {
+			b.Fatalf("Failed to create lookup, %v", err)
+		}
+	}
+}
+
+func BenchmarkGalleriesLookupJSON(b *testing.B) {
+
+	fsys := fstest.MapFS{
+		DATA_JSON: &fstest.MapFile{Data: uncompressedData(b)},
+	}
+
+	benchmarkGalleriesLookupWithFS(b, fsys, DATA_JSON)
+}
+
+func BenchmarkGalleriesLookupGzip(b *testing.B) {
+	benchmarkGalleriesLookupWithFS(b, data.FS, DATA_JSON_GZIP)
+}
+
+func TestGalleriesList(t *testing.T) {
+
+	ctx := context.Background()
+
+	count, err := Len(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to count galleries, %v", err)
+	}
+
+	all, err := All(ctx, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to list galleries, %v", err)
+	}
+
+	listed := 0
+
+	for range all {
+		listed += 1
+	}
+
+	if count == 0 || listed != count {
+		t.Fatalf("Unexpected number of galleries, listed %d but expected %d", listed, count)
+	}
+
+	current, err := All(ctx, &architecture.ListFilter{Current: architecture.LIST_CURRENT})
+
+	if err != nil {
+		t.Fatalf("Failed to list current galleries, %v", err)
+	}
+
+	for g := range current {
+
+		if g.IsCurrent != 1 {
+			t.Fatalf("Gallery %d is not current", g.WhosOnFirstId)
+		}
+	}
+
+	codes, err := Codes(ctx, &architecture.ListFilter{Date: "2024-07-25"})
+
+	if err != nil {
+		t.Fatalf("Failed to list codes, %v", err)
+	}
+
+	if !slices.Contains(codes, "2E") || !slices.IsSorted(codes) {
+		t.Fatalf("Unexpected codes for date, %v", codes)
+	}
+
+	_, err = Codes(ctx, &architecture.ListFilter{Date: "bogus"})
+
+	if err == nil {
+		t.Fatalf("Expected invalid date to fail")
+	}
+}
+
+func TestSQLiteGalleriesLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	features := map[string]string{
+		"1000000001.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000001, "wof:name": "Z1 Gallery", "sfomuseum:placetype": "gallery", "sfomuseum:gallery_id": 999, "sfomuseum:map_id": "Z01", "mz:is_current": 0, "edtf:inception": "2000", "edtf:cessation": "2010"}),
+		"1000000002.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000002, "wof:name": "Z1 Gallery", "sfomuseum:placetype": "gallery", "sfomuseum:gallery_id": 999, "sfomuseum:map_id": "Z01", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+		"1000000003.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000003, "wof:name": "Z01", "sfomuseum:placetype": "gate", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+	}
+
+	dsn := testutil.NewSQLiteDatabase(t, features)
+
+	lu, err := architecture.NewLookup(ctx, "galleries://sqlite?dsn="+url.QueryEscape(dsn))
+
+	if err != nil {
+		t.Fatalf("Failed to create SQLite lookup, %v", err)
+	}
+
+	typed := architecture.NewTypedLookup[*Gallery](lu)
+
+	defer typed.(*SQLiteGalleriesLookup).Close()
+
+	tests := map[string][]int64{
+		"Z01":        []int64{1000000001, 1000000002},
+		"999":        []int64{1000000001, 1000000002},
+		"1000000002": []int64{1000000002},
+	}
+
+	for code, expected := range tests {
+
+		rsp, err := typed.Find(ctx, code)
+
+		if err != nil {
+			t.Fatalf("Failed to find %s, %v", code, err)
+		}
+
+		if len(rsp) != len(expected) {
+			t.Fatalf("Unexpected number of results for %s, expected %d but got %d", code, len(expected), len(rsp))
+		}
+
+		for idx, g := range rsp {
+
+			if g.WhosOnFirstId != expected[idx] {
+				t.Fatalf("Unexpected result %d for %s, expected %d but got %d", idx, code, expected[idx], g.WhosOnFirstId)
+			}
+		}
+	}
+
+	_, err = typed.Find(ctx, "1000000003")
+
+	if !IsNotFound(err) {
+		t.Fatalf("Expected gate ID to not be found, got %v", err)
+	}
+
+	// Normalized codes can not be indexed so they are not matched by SQLite lookups
+
+	for _, code := range []string{"z-1"} {
+
+		_, err = typed.Find(ctx, code)
+
+		if !IsNotFound(err) {
+			t.Fatalf("Expected %s to not be found, got %v", code, err)
+		}
+	}
+
+	current, err := FindCurrentGalleryWithTypedLookup(ctx, typed, "Z01")
+
+	if err != nil {
+		t.Fatalf("Failed to find current gallery, %v", err)
+	}
+
+	if current.WhosOnFirstId != 1000000002 {
+		t.Fatalf("Unexpected current gallery %d", current.WhosOnFirstId)
+	}
+
+	g, err := FindGalleryForDateWithTypedLookup(ctx, typed, "Z01", "2005-06-01")
+
+	if err != nil {
+		t.Fatalf("Failed to find gallery for date, %v", err)
+	}
+
+	if g.WhosOnFirstId != 1000000001 {
+		t.Fatalf("Unexpected gallery for date %d", g.WhosOnFirstId)
+	}
+
+	count, err := lu.(architecture.ListableLookup).Len(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to count galleries, %v", err)
+	}
+
+	if count != 2 {
+		t.Fatalf("Unexpected number of galleries, %d", count)
+	}
+
+	all, err := lu.(architecture.ListableLookup).All(ctx, &architecture.ListFilter{Date: "2005-06-01"})
+
+	if err != nil {
+		t.Fatalf("Failed to list galleries, %v", err)
+	}
+
+	ids := make([]int64, 0)
+
+	for r := range all {
+		ids = append(ids, r.(*Gallery).WhosOnFirstId)
+	}
+
+	if len(ids) != 1 || ids[0] != 1000000001 {
+		t.Fatalf("Unexpected galleries for date, %v", ids)
+	}
+
+	err = typed.Append(ctx, g)
+
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("Expected append to be unsupported, got %v", err)
+	}
+
+	_, err = architecture.NewLookup(ctx, "galleries://sqlite")
+
+	if err == nil {
+		t.Fatalf("Expected sqlite URI without a DSN to fail")
+	}
+}
+
+func TestSQLiteGalleriesLookupCodes(t *testing.T) {
+
+	ctx := context.Background()
+
+	features := map[string]string{
+		"1000000001.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000001, "wof:name": "Z1 Gallery", "sfomuseum:placetype": "gallery", "sfomuseum:gallery_id": 999, "sfomuseum:map_id": "Z01", "mz:is_current": 0, "edtf:inception": "2000", "edtf:cessation": "2010"}),
+		"1000000002.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000002, "wof:name": "Z1 Gallery", "sfomuseum:placetype": "gallery", "sfomuseum:gallery_id": 999, "sfomuseum:map_id": "Z01", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+		"1000000003.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000003, "wof:name": "Z2 Gallery", "sfomuseum:placetype": "gallery", "sfomuseum:gallery_id": 998, "mz:is_current": 0, "edtf:inception": "2000", "edtf:cessation": "2010"}),
+	}
+
+	dsn := testutil.NewSQLiteDatabase(t, features)
+
+	lu, err := NewSQLiteGalleriesLookup(ctx, "galleries://sqlite?dsn="+url.QueryEscape(dsn))
+
+	if err != nil {
+		t.Fatalf("Failed to create SQLite lookup, %v", err)
+	}
+
+	defer lu.Close()
+
+	codes, err := lu.Codes(ctx, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to list codes, %v", err)
+	}
+
+	if !slices.Equal(codes, []string{"998", "Z01"}) {
+		t.Fatalf("Unexpected codes, %v", codes)
+	}
+
+	// The codes selected by the database should be the same as the codes derived from the records themselves
+
+	all, err := lu.All(ctx, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to list galleries, %v", err)
+	}
+
+	if record_codes := architecture.RecordCodes(slices.Collect(all)); !slices.Equal(codes, record_codes) {
+		t.Fatalf("Expected codes to match the records' codes, %v", record_codes)
+	}
+
+	codes, err = lu.Codes(ctx, &architecture.ListFilter{Current: architecture.LIST_CURRENT})
+
+	if err != nil {
+		t.Fatalf("Failed to list current codes, %v", err)
+	}
+
+	if !slices.Equal(codes, []string{"Z01"}) {
+		t.Fatalf("Unexpected current codes, %v", codes)
+	}
+}
+
+func TestReaderGalleriesLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	features := map[int64]string{
+		1000000001: testutil.Feature(t, map[string]any{"wof:id": 1000000001, "wof:name": "Z1 Gallery", "sfomuseum:placetype": "gallery", "sfomuseum:gallery_id": 999, "sfomuseum:map_id": "Z01", "mz:is_current": 0, "edtf:inception": "2000", "edtf:cessation": "2010"}),
+		1000000002: testutil.Feature(t, map[string]any{"wof:id": 1000000002, "wof:name": "Z1 Gallery", "sfomuseum:placetype": "gallery", "sfomuseum:gallery_id": 999, "sfomuseum:map_id": "Z01", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+	}
+
+	reader_uri := testutil.NewReaderData(t, features)
+
+	index := []byte(`[{"wof:id": 1000000001, "sfomuseum:id": 999, "map_id": "Z01"}, {"wof:id": 1000000002, "sfomuseum:id": 999, "map_id": "Z01"}, {"wof:id": 1000000003, "sfomuseum:id": 998, "map_id": "Z02"}]`)
+
+	index_path := filepath.Join(t.TempDir(), "galleries.json")
+
+	err := os.WriteFile(index_path, index, 0644)
+
+	if err != nil {
+		t.Fatalf("Failed to write index, %v", err)
+	}
+
+	q := url.Values{}
+	q.Set("reader", reader_uri)
+	q.Set("index", "galleries://file?path="+url.QueryEscape(index_path))
+
+	lu, err := architecture.NewLookup(ctx, "galleries://reader?"+q.Encode())
+
+	if err != nil {
+		t.Fatalf("Failed to create reader lookup, %v", err)
+	}
+
+	typed := architecture.NewTypedLookup[*Gallery](lu)
+
+	rsp, err := typed.Find(ctx, "z-1")
+
+	if err != nil {
+		t.Fatalf("Failed to find Z01, %v", err)
+	}
+
+	if len(rsp) != 2 || rsp[0].WhosOnFirstId != 1000000001 || rsp[1].WhosOnFirstId != 1000000002 {
+		t.Fatalf("Unexpected results for Z01, %v", rsp)
+	}
+
+	for _, g := range rsp {
+
+		if g.Feature == nil || g.Feature.Geometry.GeoJSONType() != "Point" {
+			t.Fatalf("Expected gallery %d to have a point geometry", g.WhosOnFirstId)
+		}
+
+		if g.Feature.Properties.MustString("sfomuseum:placetype") != "gallery" {
+			t.Fatalf("Expected gallery %d to have all its properties", g.WhosOnFirstId)
+		}
+	}
+
+	current, err := FindCurrentGalleryWithTypedLookup(ctx, typed, "Z01")
+
+	if err != nil {
+		t.Fatalf("Failed to find current gallery, %v", err)
+	}
+
+	if current != rsp[1] {
+		t.Fatalf("Expected current gallery to be read from the cache")
+	}
+
+	_, err = typed.Find(ctx, "Z02")
+
+	if err == nil {
+		t.Fatalf("Expected gallery missing from reader to fail")
+	}
+
+	_, err = typed.Find(ctx, "Z03")
+
+	if !IsNotFound(err) {
+		t.Fatalf("Expected Z03 to not be found, got %v", err)
+	}
+
+	err = typed.Append(ctx, &Gallery{WhosOnFirstId: 1000000004, MapId: "Z03"})
+
+	if err != nil {
+		t.Fatalf("Failed to append gallery, %v", err)
+	}
+
+	rsp, err = typed.Find(ctx, "Z03")
+
+	if err != nil || len(rsp) != 1 || rsp[0].WhosOnFirstId != 1000000004 {
+		t.Fatalf("Failed to find appended gallery, %v", err)
+	}
+
+	_, err = architecture.NewLookup(ctx, "galleries://reader")
+
+	if err == nil {
+		t.Fatalf("Expected reader URI without a reader to fail")
+	}
+}
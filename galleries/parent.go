@@ -0,0 +1,20 @@
+package galleries
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+	"github.com/sfomuseum/go-sfomuseum-architecture/terminals"
+)
+
+// Terminal returns the terminal that the gallery belonged to, for the period the gallery existed, using 'lookup'.
+// See `terminals.FindTerminalForParentWithLookup` for details.
+func (g *Gallery) Terminal(ctx context.Context, lookup architecture.TypedLookup[*terminals.Terminal]) (*terminals.Terminal, error) {
+
+	if g.TerminalId <= 0 {
+		return nil, fmt.Errorf("Gallery %d does not have a terminal ID", g.WhosOnFirstId)
+	}
+
+	return terminals.FindTerminalForParentWithLookup(ctx, lookup, g.TerminalId, g.Inception, g.Cessation)
+}
@@ -0,0 +1,64 @@
+package galleries
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+	"github.com/whosonfirst/go-reader"
+)
+
+// ReaderGalleriesLookup implements the `architecture.TypedLookup[*Gallery]` interface for galleries whose records are loaded, on demand, from a
+// `whosonfirst/go-reader` instance. Only an index of codes and Who's On First IDs is kept in memory. Records are read the first time
+// they are found and cached after that. Records returned by this lookup have their `Feature` property populated. See `architecture.ReaderLookup`
+// for details.
+type ReaderGalleriesLookup struct {
+	*architecture.ReaderLookup[*Gallery]
+}
+
+// NewReaderGalleriesLookup will return a `ReaderGalleriesLookup` instance derived from 'uri' which is expected to take the form:
+//
+//	`galleries://reader?reader={READER_URI}&index={INDEX_URI}`
+//
+// Where `{READER_URI}` is a valid `whosonfirst/go-reader` URI and `{INDEX_URI}` is an optional galleries lookup URI, in any of the forms
+// that produce an in-memory lookup table (see `NewLookup`), whose records are used to derive the index of codes. If `{INDEX_URI}` is
+// empty the precompiled (embedded) data is used.
+func NewReaderGalleriesLookup(ctx context.Context, uri string) (*ReaderGalleriesLookup, error) {
+
+	r, index_uri, err := architecture.NewReaderWithURI(ctx, uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	lookup_func, err := NewLookupFuncWithURI(ctx, index_uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive index lookup function, %w", err)
+	}
+
+	return NewReaderGalleriesLookupWithLookupFunc(ctx, r, lookup_func)
+}
+
+// NewReaderGalleriesLookupWithLookupFunc will return a `ReaderGalleriesLookup` instance which reads records from 'r' and whose index of codes
+// is derived from the records compiled using `lookup_func`. Those records are discarded once the index has been built.
+func NewReaderGalleriesLookupWithLookupFunc(ctx context.Context, r reader.Reader, lookup_func GalleriesLookupFunc) (*ReaderGalleriesLookup, error) {
+
+	galleries_list, err := lookup_func(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive index, %w", err)
+	}
+
+	reader_lookup, err := architecture.NewReaderLookup(ctx, record_kind, r, galleries_list)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := &ReaderGalleriesLookup{
+		ReaderLookup: reader_lookup,
+	}
+
+	return l, nil
+}
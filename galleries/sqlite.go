@@ -0,0 +1,74 @@
+package galleries
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+)
+
+// The indices, on the `geojson` table, used by `SQLiteGalleriesLookup` in addition to the placetype index and those created by `campus.NewDatabaseWithIterator`.
+var sqlite_indices = map[string]string{
+	"geojson_by_sfomuseum_gallery_id": sqlite_gallery_id_expr,
+	"geojson_by_sfomuseum_map_id":     sqlite_map_id_expr,
+}
+
+// The queries used by `SQLiteGalleriesLookup` to find galleries by their Who's On First ID, SFO Museum gallery ID or map ID.
+var sqlite_queries = []*architecture.SQLiteCodeQuery{
+	architecture.SQLITE_QUERY_BY_ID,
+	architecture.NewSQLiteCodeQuery("SFO Museum ID", sqlite_gallery_id_expr, true),
+	architecture.NewSQLiteCodeQuery("map ID", sqlite_map_id_expr, false),
+}
+
+const sqlite_gallery_id_expr string = `JSON_EXTRACT(body, '$.properties."sfomuseum:gallery_id"')`
+
+const sqlite_map_id_expr string = `JSON_EXTRACT(body, '$.properties."sfomuseum:map_id"')`
+
+// The SQL expression that derives the same value as `Gallery.Code`, the gallery's map ID or else its SFO Museum gallery ID.
+const sqlite_code_expr string = `COALESCE(NULLIF(CAST(JSON_EXTRACT(body, '$.properties."sfomuseum:map_id"') AS TEXT), ''), CAST(JSON_EXTRACT(body, '$.properties."sfomuseum:gallery_id"') AS INTEGER))`
+
+// SQLiteGalleriesLookup implements the `architecture.TypedLookup[*Gallery]` interface for galleries stored in a Who's On First SQLite database,
+// as produced by `campus.NewDatabaseWithIterator`. Records are queried from the database, by their Who's On First ID, SFO Museum gallery ID or map ID, each time
+// `Find` is called rather than being loaded in to memory ahead of time. The current and date-based finders (for example `FindCurrentGalleryWithLookup`)
+// filter the records returned by `Find` for a code so they only ever evaluate the handful of records sharing that code. See `architecture.SQLiteLookup`
+// for details.
+type SQLiteGalleriesLookup struct {
+	*architecture.SQLiteLookup[*Gallery]
+}
+
+// NewSQLiteGalleriesLookup will return a `SQLiteGalleriesLookup` instance derived from 'uri' which is expected to take the form:
+//
+//	`galleries://sqlite?dsn={DSN}`
+//
+// Where `{DSN}` is the data source name of a Who's On First SQLite database. See `architecture.NewSQLiteDatabaseWithURL` for details
+// on the other parameters.
+func NewSQLiteGalleriesLookup(ctx context.Context, uri string) (*SQLiteGalleriesLookup, error) {
+
+	sqlite_lookup, err := architecture.NewSQLiteLookup(ctx, record_kind, uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := &SQLiteGalleriesLookup{
+		SQLiteLookup: sqlite_lookup,
+	}
+
+	return l, nil
+}
+
+// NewSQLiteGalleriesLookupWithDatabase will return a `SQLiteGalleriesLookup` instance for 'db'. No indices are created.
+func NewSQLiteGalleriesLookupWithDatabase(ctx context.Context, db *sql.DB) (*SQLiteGalleriesLookup, error) {
+
+	sqlite_lookup, err := architecture.NewSQLiteLookupWithDatabase(ctx, record_kind, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := &SQLiteGalleriesLookup{
+		SQLiteLookup: sqlite_lookup,
+	}
+
+	return l, nil
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/sfomuseum/go-sfomuseum-architecture"
 	"github.com/whosonfirst/go-whosonfirst-feature/properties"
 	"github.com/whosonfirst/go-whosonfirst-iterate/v2/iterator"
 	"github.com/whosonfirst/go-whosonfirst-uri"
@@ -49,33 +50,10 @@ func CompileGatesData(ctx context.Context, iterator_uri string, iterator_sources
 			return fmt.Errorf("Failed to read %s, %w", path, err)
 		}
 
-		wof_id, err := properties.Id(body)
+		g, err := newGateFromFeature(body)
 
 		if err != nil {
-			return fmt.Errorf("Failed to derive ID for %s, %w", path, err)
-		}
-
-		wof_name, err := properties.Name(body)
-
-		if err != nil {
-			return fmt.Errorf("Failed to derive name for %s, %w", path, err)
-		}
-
-		fl, err := properties.IsCurrent(body)
-
-		if err != nil {
-			return fmt.Errorf("Failed to determine is current for %s, %v", path, err)
-		}
-
-		inception := properties.Inception(body)
-		cessation := properties.Cessation(body)
-
-		g := &Gate{
-			WhosOnFirstId: wof_id,
-			Name:          wof_name,
-			IsCurrent:     fl.Flag(),
-			Inception:     inception,
-			Cessation:     cessation,
+			return fmt.Errorf("Failed to derive gate from %s, %w", path, err)
 		}
 
 		mu.Lock()
@@ -99,3 +77,58 @@ func CompileGatesData(ctx context.Context, iterator_uri string, iterator_sources
 
 	return lookup, nil
 }
+
+// newGateFromFeature returns a new `Gate` instance derived from the Who's On First GeoJSON Feature 'body'.
+func newGateFromFeature(body []byte) (*Gate, error) {
+
+	wof_id, err := properties.Id(body)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive ID, %w", err)
+	}
+
+	wof_name, err := properties.Name(body)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive name, %w", err)
+	}
+
+	fl, err := properties.IsCurrent(body)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to determine is current, %w", err)
+	}
+
+	inception := properties.Inception(body)
+	cessation := properties.Cessation(body)
+
+	parents := architecture.DeriveParents(body)
+
+	g := &Gate{
+		WhosOnFirstId:  wof_id,
+		Name:           wof_name,
+		IsCurrent:      fl.Flag(),
+		Inception:      inception,
+		Cessation:      cessation,
+		ParentId:       parents.ParentId,
+		BoardingAreaId: parents.BoardingAreaId,
+		TerminalId:     parents.TerminalId,
+		Supersedes:     properties.Supersedes(body),
+		SupersededBy:   properties.SupersededBy(body),
+		Deprecated:     properties.Deprecated(body),
+	}
+
+	return g, nil
+}
+
+// newGateRecord derives a `Gate` record from 'body' for use as an `architecture.FeatureRecordFunc`.
+func newGateRecord(ctx context.Context, body []byte) (interface{}, error) {
+
+	r, err := newGateFromFeature(body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
@@ -4,15 +4,40 @@ package gates
 import (
 	"context"
 	"fmt"
+	"iter"
 	"log/slog"
 
+	"github.com/paulmach/orb/geojson"
 	"github.com/sfomuseum/go-edtf/cmp"
 	"github.com/sfomuseum/go-sfomuseum-architecture"
 )
 
-// To do: Make these sortable by inception/cessation
+// The default `architecture.ResolutionPolicy` used by the date-based finders which is to return the first (earliest) gate matching a date.
+var DEFAULT_RESOLUTION_POLICY architecture.ResolutionPolicy = architecture.RESOLVE_FIRST_MATCH
+
+// type Gates is a list of `Gate` records that can be sorted in chronological order. See `architecture.CompareDates` for details.
+// Records whose dates can not be distinguished are sorted by their Who's On First IDs.
 type Gates []*Gate
 
+func (c Gates) Len() int {
+	return len(c)
+}
+
+func (c Gates) Less(i, j int) bool {
+
+	cmp := architecture.CompareDates(c[i].Inception, c[i].Cessation, c[j].Inception, c[j].Cessation)
+
+	if cmp != 0 {
+		return cmp < 0
+	}
+
+	return c[i].WhosOnFirstId < c[j].WhosOnFirstId
+}
+
+func (c Gates) Swap(i, j int) {
+	c[i], c[j] = c[j], c[i]
+}
+
 // type Gate is a struct representing a passenger gate at SFO.
 type Gate struct {
 	// The Who's On First ID associated with this gate.
@@ -25,6 +50,21 @@ type Gate struct {
 	Inception string `json:"edtf:inception"`
 	// The (EDTF) cessation date for the gallery
 	Cessation string `json:"edtf:cessation"`
+	// The Who's On First ID of the gate's parent (typically a boarding area).
+	ParentId int64 `json:"wof:parent_id,omitempty"`
+	// The Who's On First ID of the boarding area the gate belongs to.
+	BoardingAreaId int64 `json:"boardingarea_id,omitempty"`
+	// The Who's On First ID of the terminal the gate belongs to.
+	TerminalId int64 `json:"terminal_id,omitempty"`
+	// The list of Who's On First IDs that this gate supersedes.
+	Supersedes []int64 `json:"wof:supersedes,omitempty"`
+	// The list of Who's On First IDs that this gate is superseded by.
+	SupersededBy []int64 `json:"wof:superseded_by,omitempty"`
+	// The (EDTF) deprecated date for the gate, if it has been deprecated.
+	Deprecated string `json:"edtf:deprecated,omitempty"`
+	// The Who's On First GeoJSON Feature for the gate, including its geometry and all of its properties. It is only populated by
+	// lookups that load records on demand, for example `ReaderGatesLookup`, and is never encoded.
+	Feature *geojson.Feature `json:"-"`
 }
 
 // String() will return the name of the gate.
@@ -32,46 +72,148 @@ func (g *Gate) String() string {
 	return fmt.Sprintf("%d %s %s-%s (%d)", g.WhosOnFirstId, g.Name, g.Inception, g.Cessation, g.IsCurrent)
 }
 
+// Code returns the primary code for the gate which is its name.
+func (g *Gate) Code() string {
+	return g.Name
+}
+
+// Dates returns the (EDTF) inception and cessation dates for the gate.
+func (g *Gate) Dates() (string, string) {
+	return g.Inception, g.Cessation
+}
+
+// Id returns the Who's On First ID for the gate.
+func (g *Gate) Id() int64 {
+	return g.WhosOnFirstId
+}
+
+// Placetype returns the SFO Museum placetype for the gate which is always `PLACETYPE`.
+func (g *Gate) Placetype() string {
+	return PLACETYPE
+}
+
+// Status returns whether the gate is current, superseded or deprecated.
+func (g *Gate) Status() *architecture.Status {
+	return architecture.NewStatus(g.IsCurrent, g.SupersededBy, g.Deprecated)
+}
+
 // Return the Gate matching 'code' that was active for 'date'. Multiple matches throw an error.
 func FindGateForDate(ctx context.Context, code string, date string) (*Gate, error) {
 
-	lookup, err := NewLookup(ctx, "")
+	lookup, err := defaultLookup()
 
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
 	}
 
-	return FindGateForDateWithLookup(ctx, lookup, code, date)
+	return FindGateForDateWithTypedLookup(ctx, lookup, code, date)
 }
 
 // Return all the Gates matching 'code' that were active for 'date'.
 func FindAllGatesForDate(ctx context.Context, code string, date string) ([]*Gate, error) {
 
-	lookup, err := NewLookup(ctx, "")
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return FindAllGatesForDateWithTypedLookup(ctx, lookup, code, date)
+}
+
+// Return all the Gates matching 'code' that existed at any point between 'start' and 'end'. See `GatesLookup.FindAllForRange` for details.
+func FindAllGatesForRange(ctx context.Context, code string, start string, end string) ([]*Gate, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.FindAllForRange(ctx, code, start, end)
+}
+
+// SnapshotForDate returns a snapshot of all the Gates that were active on 'date' keyed by their codes. See `GatesLookup.SnapshotForDate` for details.
+func SnapshotForDate(ctx context.Context, date string) (*architecture.Snapshot[*Gate], error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.SnapshotForDate(ctx, date)
+}
+
+// Suggest returns a ranked list of gate codes that are similar to 'code'. See `GatesLookup.Suggest` for details.
+func Suggest(ctx context.Context, code string) ([]*architecture.Suggestion, error) {
+
+	lookup, err := defaultLookup()
 
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
 	}
 
-	return FindAllGatesForDateWithLookup(ctx, lookup, code, date)
+	return lookup.Suggest(ctx, code)
+}
+
+// Codes returns the sorted list of (primary) codes for all the Gates matching 'filter'. See `GatesLookup.Codes` for details.
+func Codes(ctx context.Context, filter *architecture.ListFilter) ([]string, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Codes(ctx, filter)
+}
+
+// All returns an iterator over all the Gates matching 'filter' in chronological order. See `GatesLookup.All` for details.
+func All(ctx context.Context, filter *architecture.ListFilter) (iter.Seq[*Gate], error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.All(ctx, filter)
+}
+
+// Len returns the total number of Gates.
+func Len(ctx context.Context) (int, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return 0, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Len(ctx)
 }
 
 // Return the current Gate matching 'code'. Multiple matches throw an error.
 func FindCurrentGate(ctx context.Context, code string) (*Gate, error) {
 
-	lookup, err := NewLookup(ctx, "")
+	lookup, err := defaultLookup()
 
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
 	}
 
-	return FindCurrentGateWithLookup(ctx, lookup, code)
+	return FindCurrentGateWithTypedLookup(ctx, lookup, code)
 }
 
 // Return the current Gate matching 'code' with a custom architecture.Lookup instance. Multiple matches throw an error.
 func FindCurrentGateWithLookup(ctx context.Context, lookup architecture.Lookup, code string) (*Gate, error) {
+	return FindCurrentGateWithTypedLookup(ctx, architecture.NewTypedLookup[*Gate](lookup), code)
+}
+
+// FindCurrentGateWithTypedLookup is the same as `FindCurrentGateWithLookup` but with a custom architecture.TypedLookup[*Gate] instance.
+func FindCurrentGateWithTypedLookup(ctx context.Context, lookup architecture.TypedLookup[*Gate], code string) (*Gate, error) {
 
-	current, err := FindGatesCurrentWithLookup(ctx, lookup, code)
+	current, err := FindGatesCurrentWithTypedLookup(ctx, lookup, code)
 
 	if err != nil {
 		return nil, err
@@ -79,11 +221,11 @@ func FindCurrentGateWithLookup(ctx context.Context, lookup architecture.Lookup,
 
 	switch len(current) {
 	case 0:
-		return nil, NotFound{code}
+		return nil, NotFound{Code: code, Reason: architecture.REASON_NO_CURRENT}
 	case 1:
 		return current[0], nil
 	default:
-		return nil, MultipleCandidates{code}
+		return nil, MultipleCandidates{Code: code, Reason: architecture.REASON_MULTIPLE_CURRENT, Candidates: current}
 	}
 
 }
@@ -91,17 +233,22 @@ func FindCurrentGateWithLookup(ctx context.Context, lookup architecture.Lookup,
 // Returns all Gate instances matching 'code' that are marked as current.
 func FindGatesCurrent(ctx context.Context, code string) ([]*Gate, error) {
 
-	lookup, err := NewLookup(ctx, "")
+	lookup, err := defaultLookup()
 
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
 	}
 
-	return FindGatesCurrentWithLookup(ctx, lookup, code)
+	return FindGatesCurrentWithTypedLookup(ctx, lookup, code)
 }
 
 // Returns all Gate instances matching 'code' that are marked as current with a custom architecture.Lookup instance.
 func FindGatesCurrentWithLookup(ctx context.Context, lookup architecture.Lookup, code string) ([]*Gate, error) {
+	return FindGatesCurrentWithTypedLookup(ctx, architecture.NewTypedLookup[*Gate](lookup), code)
+}
+
+// FindGatesCurrentWithTypedLookup is the same as `FindGatesCurrentWithLookup` but with a custom architecture.TypedLookup[*Gate] instance.
+func FindGatesCurrentWithTypedLookup(ctx context.Context, lookup architecture.TypedLookup[*Gate], code string) ([]*Gate, error) {
 
 	rsp, err := lookup.Find(ctx, code)
 
@@ -111,9 +258,7 @@ func FindGatesCurrentWithLookup(ctx context.Context, lookup architecture.Lookup,
 
 	current := make([]*Gate, 0)
 
-	for _, r := range rsp {
-
-		g := r.(*Gate)
+	for _, g := range rsp {
 
 		// if g.IsCurrent == 0 {
 		if g.IsCurrent != 1 {
@@ -126,10 +271,20 @@ func FindGatesCurrentWithLookup(ctx context.Context, lookup architecture.Lookup,
 	return current, nil
 }
 
-// Return the Gate matching 'code' that was active for 'date' using 'lookup'. Multiple matches throw an error.
+// Return the Gate matching 'code' that was active for 'date' using the architecture.Lookup instance 'lookup' and `DEFAULT_RESOLUTION_POLICY`. Multiple matches throw an error.
 func FindGateForDateWithLookup(ctx context.Context, lookup architecture.Lookup, code string, date string) (*Gate, error) {
+	return FindGateForDateWithTypedLookup(ctx, architecture.NewTypedLookup[*Gate](lookup), code, date)
+}
 
-	gates, err := FindAllGatesForDateWithLookup(ctx, lookup, code, date)
+// FindGateForDateWithTypedLookup is the same as `FindGateForDateWithLookup` but with a custom architecture.TypedLookup[*Gate] instance.
+func FindGateForDateWithTypedLookup(ctx context.Context, lookup architecture.TypedLookup[*Gate], code string, date string) (*Gate, error) {
+	return FindGateForDateWithPolicy(ctx, lookup, code, date, DEFAULT_RESOLUTION_POLICY)
+}
+
+// Return the Gate matching 'code' that was active for 'date' using 'lookup' and 'policy'. Multiple matches throw an error.
+func FindGateForDateWithPolicy(ctx context.Context, lookup architecture.TypedLookup[*Gate], code string, date string, policy architecture.ResolutionPolicy) (*Gate, error) {
+
+	gates, err := FindAllGatesForDateWithPolicy(ctx, lookup, code, date, policy)
 
 	if err != nil {
 		return nil, err
@@ -137,17 +292,27 @@ func FindGateForDateWithLookup(ctx context.Context, lookup architecture.Lookup,
 
 	switch len(gates) {
 	case 0:
-		return nil, NotFound{code}
+		return nil, NotFound{Code: code, Date: date, Reason: architecture.REASON_NO_DATE_MATCH}
 	case 1:
 		return gates[0], nil
 	default:
-		return nil, MultipleCandidates{code}
+		return nil, MultipleCandidates{Code: code, Date: date, Reason: architecture.REASON_MULTIPLE_DATE_MATCH, Candidates: gates}
 	}
-
 }
 
-// Return all the Gates matching 'code' that were active for 'date' using 'lookup'.
+// Return all the Gates matching 'code' that were active for 'date' using the architecture.Lookup instance 'lookup' and `DEFAULT_RESOLUTION_POLICY`.
 func FindAllGatesForDateWithLookup(ctx context.Context, lookup architecture.Lookup, code string, date string) ([]*Gate, error) {
+	return FindAllGatesForDateWithTypedLookup(ctx, architecture.NewTypedLookup[*Gate](lookup), code, date)
+}
+
+// FindAllGatesForDateWithTypedLookup is the same as `FindAllGatesForDateWithLookup` but with a custom architecture.TypedLookup[*Gate] instance.
+func FindAllGatesForDateWithTypedLookup(ctx context.Context, lookup architecture.TypedLookup[*Gate], code string, date string) ([]*Gate, error) {
+	return FindAllGatesForDateWithPolicy(ctx, lookup, code, date, DEFAULT_RESOLUTION_POLICY)
+}
+
+// Return all the Gates matching 'code' that were active for 'date' using 'lookup', choosing between multiple matches using 'policy'.
+// See `architecture.ResolutionPolicy` for details.
+func FindAllGatesForDateWithPolicy(ctx context.Context, lookup architecture.TypedLookup[*Gate], code string, date string, policy architecture.ResolutionPolicy) ([]*Gate, error) {
 
 	rsp, err := lookup.Find(ctx, code)
 
@@ -157,9 +322,7 @@ func FindAllGatesForDateWithLookup(ctx context.Context, lookup architecture.Look
 
 	gates := make([]*Gate, 0)
 
-	for _, r := range rsp {
-
-		g := r.(*Gate)
+	for _, g := range rsp {
 
 		inception := g.Inception
 		cessation := g.Cessation
@@ -177,11 +340,22 @@ func FindAllGatesForDateWithLookup(ctx context.Context, lookup architecture.Look
 		}
 
 		slog.Debug("Gate DOES match date conditions", "id", g.WhosOnFirstId, "code", code, "date", date, "gate", g.Name, "inception", inception, "cessation", cessation)
-
 		gates = append(gates, g)
-		break
 	}
 
-	slog.Debug("Return gates", "code", code, "date", date, "count", len(gates))
+	gates = architecture.ApplyResolutionPolicy(policy, date, gates, resolutionCandidate)
+
+	slog.Debug("Return gates", "code", code, "date", date, "policy", policy, "count", len(gates))
 	return gates, nil
 }
+
+func resolutionCandidate(g *Gate) *architecture.ResolutionCandidate {
+
+	c := &architecture.ResolutionCandidate{
+		IsCurrent: g.IsCurrent,
+		Inception: g.Inception,
+		Cessation: g.Cessation,
+	}
+
+	return c
+}
@@ -2,8 +2,12 @@ package gates
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"sort"
 	"testing"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
 )
 
 type gateTest struct {
@@ -38,6 +42,28 @@ func TestFindCurrentGate(t *testing.T) {
 	}
 }
 
+func TestFindGatesCurrentCancelledContext(t *testing.T) {
+
+	// Make sure the default lookup is created by this test
+
+	default_lookup_mu.Lock()
+	default_lookup = nil
+	default_lookup_mu.Unlock()
+
+	cancelled_ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	FindGatesCurrent(cancelled_ctx, "A9")
+
+	ctx := context.Background()
+
+	_, err := FindGatesCurrent(ctx, "A9")
+
+	if err != nil {
+		t.Fatalf("Expected default lookup to be usable after being created by a cancelled context, %v", err)
+	}
+}
+
 func TestFindGateForDate(t *testing.T) {
 
 	slog.SetLogLoggerLevel(slog.LevelDebug)
@@ -66,3 +92,127 @@ func TestFindGateForDate(t *testing.T) {
 	}
 
 }
+
+func TestFindGateNormalized(t *testing.T) {
+
+	ctx := context.Background()
+
+	expected, err := FindGateForDate(ctx, "A9", "2024-07-25")
+
+	if err != nil {
+		t.Fatalf("Failed to find gate A9, %v", err)
+	}
+
+	for _, code := range []string{"a9", "Gate A9", "A09", "A-9"} {
+
+		g, err := FindGateForDate(ctx, code, "2024-07-25")
+
+		if err != nil {
+			t.Fatalf("Failed to find gate for %s, %v", code, err)
+		}
+
+		if g.WhosOnFirstId != expected.WhosOnFirstId {
+			t.Fatalf("Unexpected ID for gate %s. Got %d but expected %d", code, g.WhosOnFirstId, expected.WhosOnFirstId)
+		}
+	}
+
+	suggestions, err := Suggest(ctx, "A9Z")
+
+	if err != nil {
+		t.Fatalf("Failed to derive suggestions, %v", err)
+	}
+
+	if len(suggestions) == 0 || suggestions[0].Distance != 1 {
+		t.Fatalf("Unexpected suggestions for A9Z")
+	}
+}
+
+func TestFindGateNotFound(t *testing.T) {
+
+	ctx := context.Background()
+
+	_, err := FindGateForDate(ctx, "ZZZ99", "2020")
+
+	if !errors.Is(err, architecture.ErrNotFound) {
+		t.Fatalf("Expected unknown code to return a not found error, got %v", err)
+	}
+
+	_, err = FindGateForDate(ctx, "A9", "1900")
+
+	var nf NotFound
+
+	if !errors.As(err, &nf) {
+		t.Fatalf("Expected a NotFound error, got %v", err)
+	}
+
+	if nf.Date != "1900" || nf.Reason != architecture.REASON_NO_DATE_MATCH {
+		t.Fatalf("Unexpected NotFound error, %v", nf)
+	}
+}
+
+func TestSortGates(t *testing.T) {
+
+	gates_list := Gates{
+		&Gate{WhosOnFirstId: 5, Inception: "bogus", Cessation: ".."},
+		&Gate{WhosOnFirstId: 4, Inception: "2020", Cessation: ".."},
+		&Gate{WhosOnFirstId: 3, Inception: "2020", Cessation: "2021"},
+		&Gate{WhosOnFirstId: 2, Inception: "2020~", Cessation: "2020"},
+		&Gate{WhosOnFirstId: 1, Inception: "", Cessation: "2000"},
+		&Gate{WhosOnFirstId: 0, Inception: "2020", Cessation: "2021"},
+	}
+
+	sort.Sort(gates_list)
+
+	expected := []int64{1, 2, 0, 3, 4, 5}
+
+	for i, id := range expected {
+
+		if gates_list[i].WhosOnFirstId != id {
+			t.Fatalf("Unexpected gate at position %d, expected %d but got %d", i, id, gates_list[i].WhosOnFirstId)
+		}
+	}
+}
+
+func TestFindGateForDateWithPolicy(t *testing.T) {
+
+	ctx := context.Background()
+
+	gates_list := []*Gate{
+		&Gate{WhosOnFirstId: 1000000001, Name: "Z1", Inception: "2020", Cessation: "2021-05-25"},
+		&Gate{WhosOnFirstId: 1000000002, Name: "Z1", Inception: "2021-05-25", Cessation: ".."},
+	}
+
+	lu, err := NewGatesLookupWithLookupFunc(ctx, NewLookupFuncWithGates(ctx, gates_list))
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	g, err := FindGateForDateWithTypedLookup(ctx, lu, "Z1", "2021-05-25")
+
+	if err != nil {
+		t.Fatalf("Failed to find gate with default policy, %v", err)
+	}
+
+	if g.WhosOnFirstId != 1000000001 {
+		t.Fatalf("Unexpected gate with default policy, %d", g.WhosOnFirstId)
+	}
+
+	g, err = FindGateForDateWithPolicy(ctx, lu, "Z1", "2021-05-25", architecture.RESOLVE_PREFER_STARTING_ON_DATE)
+
+	if err != nil {
+		t.Fatalf("Failed to find gate with prefer starting policy, %v", err)
+	}
+
+	if g.WhosOnFirstId != 1000000002 {
+		t.Fatalf("Unexpected gate with prefer starting policy, %d", g.WhosOnFirstId)
+	}
+
+	_, err = FindGateForDateWithPolicy(ctx, lu, "Z1", "2021-05-25", architecture.RESOLVE_STRICT)
+
+	var mc MultipleCandidates
+
+	if !errors.As(err, &mc) || len(mc.Candidates) != 2 {
+		t.Fatalf("Expected multiple candidates error with strict policy, got %v", err)
+	}
+}
@@ -2,52 +2,92 @@ package gates
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
+	"io/fs"
 	"net/url"
-	"sort"
+	"os"
+	"path/filepath"
 	"strconv"
-	"strings"
 	"sync"
-	"sync/atomic"
 
+	"github.com/paulmach/orb/geojson"
 	"github.com/sfomuseum/go-sfomuseum-architecture"
 	"github.com/sfomuseum/go-sfomuseum-architecture/data"
 )
 
-const DATA_JSON string = "gates.json"
+// The SFO Museum placetype for gates.
+const PLACETYPE string = "gate"
 
-var lookup_table *sync.Map
-var lookup_idx int64
+// The name of the compiled data file, in the `architecture.DataEnvelope` format.
+const DATA_JSON string = "gates.json"
 
-var lookup_init sync.Once
-var lookup_init_err error
+// The name of the gzip-compressed copy of `DATA_JSON`. This is the only copy that is embedded, and published alongside the `SHA256SUMS`
+// checksum manifest, and the one read by default.
+const DATA_JSON_GZIP string = DATA_JSON + ".gz"
+
+// The maximum edit distance for codes returned by `Suggest`.
+const SUGGEST_MAX_DISTANCE int = 2
+
+var default_lookup *GatesLookup
+var default_lookup_mu = new(sync.Mutex)
+
+// record_kind describes gates to the generic lookups in the architecture package.
+var record_kind = &architecture.RecordKind[*Gate]{
+	Placetype:          PLACETYPE,
+	Label:              "gate",
+	Codes:              possibleCodes,
+	Candidate:          resolutionCandidate,
+	NotFound:           notFound,
+	FromFeature:        newGateFromFeature,
+	WithFeature:        withFeature,
+	LineageNode:        lineageNode,
+	SuggestMaxDistance: SUGGEST_MAX_DISTANCE,
+	SQLiteQueries:      sqlite_queries,
+	SQLiteCodeExpr:     sqlite_code_expr,
+}
 
-type GatesLookupFunc func(context.Context)
+// GatesLookupFunc is a function that, when invoked, returns the list of `Gate` records to be used by a `GatesLookup` instance.
+type GatesLookupFunc func(context.Context) ([]*Gate, error)
 
+// GatesLookup implements the `architecture.TypedLookup[*Gate]` interface for gates. Each instance has its own lookup table. See
+// `architecture.TableLookup` for details of the methods used to query, list, change and export the records in the lookup table.
 type GatesLookup struct {
-	architecture.Lookup
+	*architecture.TableLookup[*Gate]
 }
 
 func init() {
 	ctx := context.Background()
 	architecture.RegisterLookup(ctx, "gates", NewLookup)
-
-	lookup_idx = int64(0)
+	architecture.RegisterFeatureRecordFunc(ctx, PLACETYPE, "gates", newGateRecord)
 }
 
-// NewLookup will return an `architecture.Lookup` instance. By default the lookup table is derived from precompiled (embedded) data in `data/gates.json`
+// NewLookup will return an `architecture.Lookup` instance. By default the lookup table is derived from precompiled (embedded) gzip-compressed data in `data/gates.json.gz`
 // by passing in `sfomuseum://` as the URI. It is also possible to create a new lookup table with the following URI options:
 //
 //	`sfomuseum://github`
 //
-// This will cause the lookup table to be derived from the data stored at https://raw.githubusercontent.com/sfomuseum/go-sfomuseum-architecture/main/data/gates.json. This might be desirable if there have been updates to the underlying data that are not reflected in the locally installed package's pre-compiled data.
+// This will cause the lookup table to be derived from the data stored at https://raw.githubusercontent.com/sfomuseum/go-sfomuseum-architecture/main/data/gates.json.gz. This might be desirable if there have been updates to the underlying data that are not reflected in the locally installed package's pre-compiled data.
+//
+//	`sfomuseum://remote?url={URL}`
+//
+// This will cause the lookup table to be derived from the data stored at `{URL}/gates.json.gz`. Both the `github` and `remote` options verify the data against the `SHA256SUMS` checksum manifest published alongside it and accept optional `cache`, `verify`, `fallback` and `timeout` parameters. See `architecture.NewRemoteSourceWithURL` for details.
 //
 //	`sfomuseum://iterator?uri={URI}&source={SOURCE}`
 //
 // This will cause the lookup table to be derived, at runtime, from data emitted by a `whosonfirst/go-whosonfirst-iterate` instance. `{URI}` should be a valid `whosonfirst/go-whosonfirst-iterate/iterator` URI and `{SOURCE}` is one or more URIs for the iterator to process.
+//
+//	`sfomuseum://file?path={PATH}`
+//
+// This will cause the lookup table to be derived from the data stored in the local file `{PATH}`. It is assumed that the data in `{PATH}` will be formatted in the same way as the precompiled (embedded) data. This might be desirable if you want to pin a specific release of the data.
+//
+//	`sfomuseum://sqlite?dsn={DSN}`
+//
+// This will cause gates to be queried, as needed, from the Who's On First SQLite database `{DSN}` rather than being loaded in to memory. See `NewSQLiteGatesLookup` for details.
+//
+//	`sfomuseum://reader?reader={READER_URI}`
+//
+// This will cause gates to be read, as needed, from the `whosonfirst/go-reader` URI `{READER_URI}` and cached, keeping only an index of their codes in memory. See `NewReaderGatesLookup` for details.
 func NewLookup(ctx context.Context, uri string) (architecture.Lookup, error) {
 
 	u, err := url.Parse(uri)
@@ -56,6 +96,70 @@ func NewLookup(ctx context.Context, uri string) (architecture.Lookup, error) {
 		return nil, fmt.Errorf("Failed to parse URI, %w", err)
 	}
 
+	switch u.Host {
+	case "sqlite":
+
+		l, err := NewSQLiteGatesLookup(ctx, uri)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return architecture.NewUntypedLookup[*Gate](l), nil
+
+	case "reader":
+
+		l, err := NewReaderGatesLookup(ctx, uri)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return architecture.NewUntypedLookup[*Gate](l), nil
+	}
+
+	l, err := NewGatesLookup(ctx, uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return architecture.NewUntypedLookup[*Gate](l), nil
+}
+
+// NewGatesLookup will return a `GatesLookup` instance derived from 'uri'. See `NewLookup` for details on the URI options.
+func NewGatesLookup(ctx context.Context, uri string) (*GatesLookup, error) {
+
+	lookup_func, err := NewLookupFuncWithURI(ctx, uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewGatesLookupWithLookupFunc(ctx, lookup_func)
+}
+
+// NewGatesLookupWithFS will return a `GatesLookup` instance derived from the data stored in 'filename' in 'fsys'. See `NewLookupFuncWithFS` for details.
+func NewGatesLookupWithFS(ctx context.Context, fsys fs.FS, filename string) (*GatesLookup, error) {
+
+	lookup_func, err := NewLookupFuncWithFS(ctx, fsys, filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewGatesLookupWithLookupFunc(ctx, lookup_func)
+}
+
+// NewLookupFuncWithURI will return a `GatesLookupFunc` function instance derived from 'uri'. See `NewLookup` for details on the URI options.
+func NewLookupFuncWithURI(ctx context.Context, uri string) (GatesLookupFunc, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
 	// Reminder: u.Scheme is used by the architecture.Lookup constructor
 
 	switch u.Host {
@@ -66,50 +170,87 @@ func NewLookup(ctx context.Context, uri string) (architecture.Lookup, error) {
 		iterator_uri := q.Get("uri")
 		iterator_sources := q["source"]
 
-		return NewLookupFromIterator(ctx, iterator_uri, iterator_sources...)
+		gates_list, err := CompileGatesData(ctx, iterator_uri, iterator_sources...)
 
-	case "github":
+		if err != nil {
+			return nil, fmt.Errorf("Failed to compile gates data, %w", err)
+		}
+
+		return NewLookupFuncWithGates(ctx, gates_list), nil
 
-		data_url := fmt.Sprintf("https://raw.githubusercontent.com/sfomuseum/go-sfomuseum-architecture/main/data/%s", DATA_JSON)
-		rsp, err := http.Get(data_url)
+	case "github", "remote":
+
+		src, err := architecture.NewRemoteSourceWithURL(u, data.FS)
 
 		if err != nil {
-			return nil, fmt.Errorf("Failed to load remote data from Github, %w", err)
+			return nil, fmt.Errorf("Failed to create remote source, %w", err)
 		}
 
-		lookup_func := NewLookupFuncWithReader(ctx, rsp.Body)
-		return NewLookupWithLookupFunc(ctx, lookup_func)
+		fh, err := src.Open(ctx, DATA_JSON_GZIP)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load remote data, %w", err)
+		}
+
+		return NewLookupFuncWithReader(ctx, fh), nil
+
+	case "file":
+
+		path := u.Query().Get("path")
+
+		if path == "" {
+			return nil, fmt.Errorf("Missing ?path= parameter")
+		}
+
+		return NewLookupFuncWithFS(ctx, os.DirFS(filepath.Dir(path)), filepath.Base(path))
+
+	case "sqlite":
+
+		return nil, fmt.Errorf("The sqlite host can not be used to derive a lookup table, use NewSQLiteGatesLookup instead")
+
+	case "reader":
+
+		return nil, fmt.Errorf("The reader host can not be used to derive a lookup table, use NewReaderGatesLookup instead")
 
 	default:
 
-		fs := data.FS
-		fh, err := fs.Open(DATA_JSON)
+		lookup_func, err := NewLookupFuncWithFS(ctx, data.FS, DATA_JSON_GZIP)
 
 		if err != nil {
 			return nil, fmt.Errorf("Failed to load local precompiled data, %w", err)
 		}
 
-		lookup_func := NewLookupFuncWithReader(ctx, fh)
-		return NewLookupWithLookupFunc(ctx, lookup_func)
+		return lookup_func, nil
 	}
 }
 
+// NewLookupFuncWithFS will return a `GatesLookupFunc` function instance that, when invoked, will populate an `architecture.Lookup` instance with data stored in
+// 'filename' in 'fsys'. It is assumed that the data in 'filename' will be formatted in the same way as the precompiled (embedded) data stored in `data/gates.json.gz`, with or without gzip compression.
+func NewLookupFuncWithFS(ctx context.Context, fsys fs.FS, filename string) (GatesLookupFunc, error) {
+
+	fh, err := fsys.Open(filename)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open %s, %w", filename, err)
+	}
+
+	return NewLookupFuncWithReader(ctx, fh), nil
+}
+
 // NewLookupWithReader will return an `GatesLookupFunc` function instance that, when invoked, will populate an `architecture.Lookup` instance with data stored in `r`.
 // `r` will be closed when the `GatesLookupFunc` function instance is invoked.
-// It is assumed that the data in `r` will be formatted in the same way as the procompiled (embedded) data stored in `data/sfomuseum.json`.
+// It is assumed that the data in `r` will be formatted in the same way as the procompiled (embedded) data stored in `data/gates.json.gz`, with or without gzip compression. Both the versioned
+// `architecture.DataEnvelope` format and the legacy bare JSON array format are supported. See `architecture.DecodeData` for details.
 func NewLookupFuncWithReader(ctx context.Context, r io.ReadCloser) GatesLookupFunc {
 
 	defer r.Close()
 
-	var gates_list []*Gate
-
-	dec := json.NewDecoder(r)
-	err := dec.Decode(&gates_list)
+	gates_list, _, err := architecture.DecodeData[*Gate](r, PLACETYPE)
 
 	if err != nil {
 
-		lookup_func := func(ctx context.Context) {
-			lookup_init_err = err
+		lookup_func := func(ctx context.Context) ([]*Gate, error) {
+			return nil, fmt.Errorf("Failed to decode data, %w", err)
 		}
 
 		return lookup_func
@@ -121,154 +262,157 @@ func NewLookupFuncWithReader(ctx context.Context, r io.ReadCloser) GatesLookupFu
 // NewLookupFuncWithGates will return an `GatesLookupFunc` function instance that, when invoked, will populate an `architecture.Lookup` instance with data stored in `gates_list`.
 func NewLookupFuncWithGates(ctx context.Context, gates_list []*Gate) GatesLookupFunc {
 
-	lookup_func := func(ctx context.Context) {
-
-		table := new(sync.Map)
-
-		for _, data := range gates_list {
-
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				// pass
-			}
-
-			appendData(ctx, table, data)
-		}
-
-		lookup_table = table
+	lookup_func := func(ctx context.Context) ([]*Gate, error) {
+		return gates_list, nil
 	}
 
 	return lookup_func
 }
 
 // NewLookupWithLookupFunc will return an `architecture.Lookup` instance derived by data compiled using `lookup_func`.
+// Each call to `lookup_func` produces a new lookup table so the instances returned by this method do not share any data.
 func NewLookupWithLookupFunc(ctx context.Context, lookup_func GatesLookupFunc) (architecture.Lookup, error) {
 
-	fn := func() {
-		lookup_func(ctx)
-	}
-
-	lookup_init.Do(fn)
+	l, err := NewGatesLookupWithLookupFunc(ctx, lookup_func)
 
-	if lookup_init_err != nil {
-		return nil, lookup_init_err
+	if err != nil {
+		return nil, err
 	}
 
-	l := GatesLookup{}
-	return &l, nil
+	return architecture.NewUntypedLookup[*Gate](l), nil
 }
 
-func NewLookupFromIterator(ctx context.Context, iterator_uri string, iterator_sources ...string) (architecture.Lookup, error) {
+// NewGatesLookupWithLookupFunc will return a `GatesLookup` instance derived by data compiled using `lookup_func`.
+func NewGatesLookupWithLookupFunc(ctx context.Context, lookup_func GatesLookupFunc) (*GatesLookup, error) {
 
-	gates_list, err := CompileGatesData(ctx, iterator_uri, iterator_sources...)
+	gates_list, err := lookup_func(ctx)
 
 	if err != nil {
-		return nil, fmt.Errorf("Failed to compile gates data, %w", err)
+		return nil, err
 	}
 
-	lookup_func := NewLookupFuncWithGates(ctx, gates_list)
-	return NewLookupWithLookupFunc(ctx, lookup_func)
-}
-
-func (l *GatesLookup) Find(ctx context.Context, code string) ([]interface{}, error) {
+	table, err := architecture.NewTableLookup(ctx, record_kind, gates_list)
 
-	pointers, ok := lookup_table.Load(code)
+	if err != nil {
+		return nil, err
+	}
 
-	if !ok {
-		return nil, fmt.Errorf("Code '%s' not found", code)
+	l := &GatesLookup{
+		TableLookup: table,
 	}
 
-	gates := make([]*Gate, 0)
+	return l, nil
+}
 
-	for _, p := range pointers.([]string) {
+// defaultLookup returns a package-level `GatesLookup` instance derived from the precompiled (embedded) data. It is created lazily the first
+// time it is needed with a background context, rather than the context of the first caller which may be cancelled while it is still
+// being used, and a failure to create it is not retained so that it is attempted again by the next caller.
+func defaultLookup() (*GatesLookup, error) {
 
-		if !strings.HasPrefix(p, "pointer:") {
-			return nil, fmt.Errorf("Invalid pointer '%s'", p)
-		}
+	default_lookup_mu.Lock()
+	defer default_lookup_mu.Unlock()
 
-		row, ok := lookup_table.Load(p)
+	if default_lookup != nil {
+		return default_lookup, nil
+	}
 
-		if !ok {
-			return nil, fmt.Errorf("Invalid pointer '%s'", p)
-		}
+	lookup, err := NewGatesLookup(context.Background(), "")
 
-		gates = append(gates, row.(*Gate))
+	if err != nil {
+		return nil, err
 	}
 
-	sort.Slice(gates, func(i, j int) bool {
+	default_lookup = lookup
+	return default_lookup, nil
+}
+
+// Reload replaces the data used by the package-level `Find*` methods with data derived from 'uri'. See `NewLookup` for details on the URI options.
+func Reload(ctx context.Context, uri string) error {
 
-		inception_i := gates[i].Inception
-		cessation_i := gates[i].Cessation
+	lookup, err := defaultLookup()
 
-		inception_j := gates[j].Inception
-		cessation_j := gates[j].Cessation
+	if err != nil {
+		return err
+	}
 
-		date_i := fmt.Sprintf("%s - %s", inception_i, cessation_i)
-		date_j := fmt.Sprintf("%s - %s", inception_j, cessation_j)
+	return lookup.Reload(ctx, uri)
+}
 
-		return date_i < date_j
-	})
+// NewLookupFromIterator will return an `architecture.Lookup` instance derived from data compiled by `CompileGatesData`.
+func NewLookupFromIterator(ctx context.Context, iterator_uri string, iterator_sources ...string) (architecture.Lookup, error) {
 
-	gates_list := make([]interface{}, len(gates))
+	l, err := NewGatesLookupFromIterator(ctx, iterator_uri, iterator_sources...)
 
-	for idx, g := range gates {
-		gates_list[idx] = g
+	if err != nil {
+		return nil, err
 	}
 
-	return gates_list, nil
+	return architecture.NewUntypedLookup[*Gate](l), nil
 }
 
-func (l *GatesLookup) Append(ctx context.Context, data interface{}) error {
-	return appendData(ctx, lookup_table, data.(*Gate))
-}
+// NewGatesLookupFromIterator will return a `GatesLookup` instance derived from data compiled by `CompileGatesData`.
+func NewGatesLookupFromIterator(ctx context.Context, iterator_uri string, iterator_sources ...string) (*GatesLookup, error) {
 
-func appendData(ctx context.Context, table *sync.Map, data *Gate) error {
+	gates_list, err := CompileGatesData(ctx, iterator_uri, iterator_sources...)
 
-	idx := atomic.AddInt64(&lookup_idx, 1)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to compile gates data, %w", err)
+	}
+
+	lookup_func := NewLookupFuncWithGates(ctx, gates_list)
+	return NewGatesLookupWithLookupFunc(ctx, lookup_func)
+}
 
-	pointer := fmt.Sprintf("pointer:%d", idx)
-	table.Store(pointer, data)
+// Reload replaces the lookup table with a new table derived from 'uri'. See `NewLookup` for details on the URI options.
+// The new table is built in full before it replaces the current table so calls to `Find` will see either the old data or the
+// new data but never a mix of both. If the new table can not be built the current table is left in place and an error is returned.
+// Any changes made to the table (for example with `Append`) while the new table is being built are lost.
+func (l *GatesLookup) Reload(ctx context.Context, uri string) error {
 
-	str_wofid := strconv.FormatInt(data.WhosOnFirstId, 10)
+	lookup_func, err := NewLookupFuncWithURI(ctx, uri)
 
-	possible_codes := []string{
-		data.Name,
-		str_wofid,
+	if err != nil {
+		return fmt.Errorf("Failed to derive lookup function, %w", err)
 	}
 
-	for _, code := range possible_codes {
+	return l.ReloadWithLookupFunc(ctx, lookup_func)
+}
 
-		if code == "" {
-			continue
-		}
+// ReloadWithLookupFunc replaces the lookup table with a new table derived from data compiled using `lookup_func`.
+func (l *GatesLookup) ReloadWithLookupFunc(ctx context.Context, lookup_func GatesLookupFunc) error {
 
-		pointers := make([]string, 0)
-		has_pointer := false
+	gates_list, err := lookup_func(ctx)
 
-		others, ok := table.Load(code)
+	if err != nil {
+		return fmt.Errorf("Failed to reload lookup table, %w", err)
+	}
 
-		if ok {
+	err = l.Replace(ctx, gates_list)
 
-			pointers = others.([]string)
-		}
+	if err != nil {
+		return fmt.Errorf("Failed to reload lookup table, %w", err)
+	}
 
-		for _, dupe := range pointers {
+	return nil
+}
 
-			if dupe == pointer {
-				has_pointer = true
-				break
-			}
-		}
+// possibleCodes returns the list of codes that 'data' can be found by.
+func possibleCodes(data *Gate) []string {
 
-		if has_pointer {
-			continue
-		}
+	str_wofid := strconv.FormatInt(data.WhosOnFirstId, 10)
 
-		pointers = append(pointers, pointer)
-		table.Store(code, pointers)
+	possible_codes := []string{
+		data.Name,
+		str_wofid,
 	}
 
-	return nil
+	return possible_codes
+}
+
+func notFound(code string, reason string) error {
+	return NotFound{Code: code, Reason: reason}
+}
+
+func withFeature(g *Gate, f *geojson.Feature) {
+	g.Feature = f
 }
@@ -1,10 +1,28 @@
 package gates
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
 
 	"github.com/sfomuseum/go-sfomuseum-architecture"
+	"github.com/sfomuseum/go-sfomuseum-architecture/data"
+	"github.com/sfomuseum/go-sfomuseum-architecture/internal/testutil"
+	"github.com/sfomuseum/go-sfomuseum-architecture/terminals"
 )
 
 func TestGatesLookup(t *testing.T) {
@@ -38,3 +56,1390 @@ func TestGatesLookup(t *testing.T) {
 		}
 	}
 }
+
+func TestGatesLookupInstances(t *testing.T) {
+
+	ctx := context.Background()
+
+	g := &Gate{
+		WhosOnFirstId: 1000000001,
+		Name:          "Z1",
+		IsCurrent:     1,
+		Inception:     "2024",
+		Cessation:     "..",
+	}
+
+	lookup_func := NewLookupFuncWithGates(ctx, []*Gate{g})
+
+	lu_a, err := NewLookupWithLookupFunc(ctx, lookup_func)
+
+	if err != nil {
+		t.Fatalf("Failed to create first lookup, %v", err)
+	}
+
+	lu_b, err := NewLookupWithLookupFunc(ctx, lookup_func)
+
+	if err != nil {
+		t.Fatalf("Failed to create second lookup, %v", err)
+	}
+
+	g2 := &Gate{
+		WhosOnFirstId: 1000000002,
+		Name:          "Z1",
+		IsCurrent:     0,
+		Inception:     "2020",
+		Cessation:     "2024",
+	}
+
+	err = lu_a.Append(ctx, g2)
+
+	if err != nil {
+		t.Fatalf("Failed to append gate, %v", err)
+	}
+
+	results_a, err := lu_a.Find(ctx, "1000000002")
+
+	if err != nil {
+		t.Fatalf("Expected to find appended gate in first lookup, %v", err)
+	}
+
+	if len(results_a) != 1 {
+		t.Fatalf("Unexpected results for first lookup, %d", len(results_a))
+	}
+
+	_, err = lu_b.Find(ctx, "1000000002")
+
+	if err == nil {
+		t.Fatalf("Did not expect to find appended gate in second lookup")
+	}
+
+	_, err = lu_b.Find(ctx, "1000000001")
+
+	if err != nil {
+		t.Fatalf("Failed to find gate in second lookup, %v", err)
+	}
+
+	embedded_lu, err := NewLookup(ctx, "gates://")
+
+	if err != nil {
+		t.Fatalf("Failed to create embedded lookup, %v", err)
+	}
+
+	_, err = embedded_lu.Find(ctx, "1000000001")
+
+	if err == nil {
+		t.Fatalf("Did not expect to find custom gate in embedded lookup")
+	}
+}
+
+func TestGatesTypedLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	lu, err := architecture.NewLookup(ctx, "gates://")
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	g, err := FindGateForDateWithLookup(ctx, lu, "F5", "2022")
+
+	if err != nil {
+		t.Fatalf("Failed to find gate, %v", err)
+	}
+
+	if g.WhosOnFirstId != 1763588293 {
+		t.Fatalf("Unexpected ID for gate F5, %d", g.WhosOnFirstId)
+	}
+
+	other_lu, err := architecture.NewLookup(ctx, "terminals://")
+
+	if err != nil {
+		t.Fatalf("Failed to create terminals lookup, %v", err)
+	}
+
+	_, err = FindGatesCurrentWithLookup(ctx, other_lu, "T1")
+
+	if err == nil {
+		t.Fatalf("Expected error using terminals lookup")
+	}
+}
+
+func TestGatesLookupReload(t *testing.T) {
+
+	ctx := context.Background()
+
+	g := &Gate{
+		WhosOnFirstId: 1000000001,
+		Name:          "Z1",
+		IsCurrent:     1,
+		Inception:     "2024",
+		Cessation:     "..",
+	}
+
+	lookup_func := NewLookupFuncWithGates(ctx, []*Gate{g})
+
+	lu, err := NewGatesLookupWithLookupFunc(ctx, lookup_func)
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	_, err = lu.Find(ctx, "F5")
+
+	if err == nil {
+		t.Fatalf("Did not expect to find F5 before reloading")
+	}
+
+	err = lu.Reload(ctx, "gates://")
+
+	if err != nil {
+		t.Fatalf("Failed to reload lookup, %v", err)
+	}
+
+	_, err = lu.Find(ctx, "F5")
+
+	if err != nil {
+		t.Fatalf("Failed to find F5 after reloading, %v", err)
+	}
+
+	_, err = lu.Find(ctx, "1000000001")
+
+	if err == nil {
+		t.Fatalf("Did not expect to find custom gate after reloading")
+	}
+
+	r := io.NopCloser(strings.NewReader("{"))
+
+	err = lu.ReloadWithLookupFunc(ctx, NewLookupFuncWithReader(ctx, r))
+
+	if err == nil {
+		t.Fatalf("Expected reload with invalid data to fail")
+	}
+
+	_, err = lu.Find(ctx, "F5")
+
+	if err != nil {
+		t.Fatalf("Failed to find F5 after failed reload, %v", err)
+	}
+}
+
+func TestGatesLookupMutate(t *testing.T) {
+
+	ctx := context.Background()
+
+	lookup_func := NewLookupFuncWithGates(ctx, []*Gate{
+		&Gate{WhosOnFirstId: 1000000001, Name: "Z1"},
+		&Gate{WhosOnFirstId: 1000000002, Name: "Z2"},
+	})
+
+	lu, err := NewGatesLookupWithLookupFunc(ctx, lookup_func)
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	err = lu.Append(ctx, &Gate{WhosOnFirstId: 1000000001, Name: "Z1"})
+
+	if err != nil {
+		t.Fatalf("Failed to append gate, %v", err)
+	}
+
+	rsp, err := lu.Find(ctx, "Z1")
+
+	if err != nil {
+		t.Fatalf("Failed to find Z1, %v", err)
+	}
+
+	if len(rsp) != 1 {
+		t.Fatalf("Expected re-appended gate to replace the original, got %d results", len(rsp))
+	}
+
+	err = lu.Update(ctx, &Gate{WhosOnFirstId: 1000000001, Name: "Z9"})
+
+	if err != nil {
+		t.Fatalf("Failed to update gate, %v", err)
+	}
+
+	_, err = lu.Find(ctx, "Z1")
+
+	if !IsNotFound(err) {
+		t.Fatalf("Expected old code to be removed by update, got %v", err)
+	}
+
+	for _, code := range []string{"Z9", "gate z-09", "1000000001"} {
+
+		rsp, err := lu.Find(ctx, code)
+
+		if err != nil {
+			t.Fatalf("Failed to find updated gate by %s, %v", code, err)
+		}
+
+		if len(rsp) != 1 || rsp[0].Name != "Z9" {
+			t.Fatalf("Unexpected results for %s, %v", code, rsp)
+		}
+	}
+
+	err = lu.Update(ctx, &Gate{WhosOnFirstId: 1000000003, Name: "Z3"})
+
+	if !errors.Is(err, architecture.ErrNotFound) {
+		t.Fatalf("Expected update of unknown gate to fail, got %v", err)
+	}
+
+	err = lu.Remove(ctx, 1000000002)
+
+	if err != nil {
+		t.Fatalf("Failed to remove gate, %v", err)
+	}
+
+	for _, code := range []string{"Z2", "gate z-02", "1000000002"} {
+
+		_, err := lu.Find(ctx, code)
+
+		if !IsNotFound(err) {
+			t.Fatalf("Expected removed gate to not be found by %s, got %v", code, err)
+		}
+	}
+
+	err = lu.Remove(ctx, 1000000002)
+
+	if !errors.Is(err, architecture.ErrNotFound) {
+		t.Fatalf("Expected removal of unknown gate to fail, got %v", err)
+	}
+
+	err = lu.AppendBatch(ctx, []*Gate{&Gate{WhosOnFirstId: 1000000004, Name: "Z4"}, nil})
+
+	if err == nil {
+		t.Fatalf("Expected batch with invalid gate to fail")
+	}
+
+	_, err = lu.Find(ctx, "Z4")
+
+	if !IsNotFound(err) {
+		t.Fatalf("Expected failed batch to not be applied, got %v", err)
+	}
+
+	wg := new(sync.WaitGroup)
+
+	for i := 0; i < 50; i++ {
+
+		wg.Add(1)
+
+		go func(id int64) {
+
+			defer wg.Done()
+
+			err := lu.AppendBatch(ctx, []*Gate{&Gate{WhosOnFirstId: id, Name: "Z5"}, &Gate{WhosOnFirstId: id, Name: "Z5"}})
+
+			if err != nil {
+				t.Errorf("Failed to append gate %d, %v", id, err)
+			}
+
+		}(int64(1000000100 + i))
+	}
+
+	wg.Wait()
+
+	rsp, err = lu.Find(ctx, "Z5")
+
+	if err != nil {
+		t.Fatalf("Failed to find Z5, %v", err)
+	}
+
+	if len(rsp) != 50 {
+		t.Fatalf("Expected 50 concurrently appended gates, got %d", len(rsp))
+	}
+
+	count, err := lu.Len(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to count gates, %v", err)
+	}
+
+	if count != 51 {
+		t.Fatalf("Unexpected number of gates, %d", count)
+	}
+}
+
+func TestGatesLookupFindAllForRange(t *testing.T) {
+
+	ctx := context.Background()
+
+	lu, err := NewGatesLookup(ctx, "gates://")
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	tests := map[[3]string][]int64{
+		[3]string{"F5", "2019", "2022"}:     []int64{1477930417, 1729792511, 1745882259, 1763588293},
+		[3]string{"F5", "2024-07-01", ".."}: []int64{1930457221},
+		[3]string{"F5", "..", "2019-01-01"}: []int64{},
+	}
+
+	for q, expected := range tests {
+
+		rsp, err := lu.FindAllForRange(ctx, q[0], q[1], q[2])
+
+		if err != nil {
+			t.Fatalf("Failed to find %s for %s - %s, %v", q[0], q[1], q[2], err)
+		}
+
+		if len(rsp) != len(expected) {
+			t.Fatalf("Unexpected count for %s for %s - %s, expected %d but got %d", q[0], q[1], q[2], len(expected), len(rsp))
+		}
+
+		ids := make(map[int64]bool)
+
+		for _, r := range rsp {
+			ids[r.WhosOnFirstId] = true
+		}
+
+		for _, id := range expected {
+
+			if !ids[id] {
+				t.Fatalf("Expected %d in results for %s for %s - %s", id, q[0], q[1], q[2])
+			}
+		}
+	}
+
+	changed, err := lu.FindAllChangedForRange(ctx, "2024-06-17", "2024-06-17")
+
+	if err != nil {
+		t.Fatalf("Failed to find changed gates, %v", err)
+	}
+
+	overlapping, err := lu.FindAllOverlappingRange(ctx, "2024-06-17", "2024-06-17")
+
+	if err != nil {
+		t.Fatalf("Failed to find overlapping gates, %v", err)
+	}
+
+	if len(changed) == 0 || len(changed) > len(overlapping) {
+		t.Fatalf("Unexpected count for changed gates, %d (%d overlapping)", len(changed), len(overlapping))
+	}
+
+	_, err = lu.FindAllForRange(ctx, "F5", "bogus", "..")
+
+	if err == nil {
+		t.Fatalf("Expected invalid range to fail")
+	}
+}
+
+func TestGatesLookupMutateIntervalIndex(t *testing.T) {
+
+	ctx := context.Background()
+
+	gates_list := []*Gate{
+		&Gate{WhosOnFirstId: 1000000001, Name: "Z1", Inception: "2000", Cessation: "2010"},
+		&Gate{WhosOnFirstId: 1000000002, Name: "Z2", Inception: "2005", Cessation: "2015"},
+	}
+
+	lu, err := NewGatesLookupWithLookupFunc(ctx, NewLookupFuncWithGates(ctx, gates_list))
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	overlapping := func(start string, end string) []int64 {
+
+		rsp, err := lu.FindAllOverlappingRange(ctx, start, end)
+
+		if err != nil && !IsNotFound(err) {
+			t.Fatalf("Failed to find overlapping gates, %v", err)
+		}
+
+		ids := make([]int64, len(rsp))
+
+		for i, g := range rsp {
+			ids[i] = g.WhosOnFirstId
+		}
+
+		return ids
+	}
+
+	if ids := overlapping("2012", "2013"); !slices.Equal(ids, []int64{1000000002}) {
+		t.Fatalf("Unexpected gates for 2012-2013, %v", ids)
+	}
+
+	err = lu.Append(ctx, &Gate{WhosOnFirstId: 1000000003, Name: "Z3", Inception: "2012", Cessation: ".."})
+
+	if err != nil {
+		t.Fatalf("Failed to append gate, %v", err)
+	}
+
+	err = lu.Update(ctx, &Gate{WhosOnFirstId: 1000000001, Name: "Z1", Inception: "2000", Cessation: "2013"})
+
+	if err != nil {
+		t.Fatalf("Failed to update gate, %v", err)
+	}
+
+	err = lu.Remove(ctx, 1000000002)
+
+	if err != nil {
+		t.Fatalf("Failed to remove gate, %v", err)
+	}
+
+	if ids := overlapping("2012", "2013"); !slices.Equal(ids, []int64{1000000001, 1000000003}) {
+		t.Fatalf("Unexpected gates for 2012-2013 after mutations, %v", ids)
+	}
+}
+
+func TestGatesSnapshotForDate(t *testing.T) {
+
+	ctx := context.Background()
+
+	s, err := SnapshotForDate(ctx, "2024-07-25")
+
+	if err != nil {
+		t.Fatalf("Failed to derive snapshot, %v", err)
+	}
+
+	f5, ok := s.Records["F5"]
+
+	if !ok {
+		t.Fatalf("Missing F5 in snapshot")
+	}
+
+	if len(f5) != 1 || f5[0].WhosOnFirstId != 1930457221 {
+		t.Fatalf("Unexpected records for F5 in snapshot")
+	}
+
+	s, err = SnapshotForDate(ctx, "2024-06-17")
+
+	if err != nil {
+		t.Fatalf("Failed to derive snapshot, %v", err)
+	}
+
+	if !s.IsMultiple("F5") {
+		t.Fatalf("Expected multiple records for F5 on 2024-06-17")
+	}
+
+	lu, err := NewGatesLookup(ctx, "gates://")
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	s, err = lu.SnapshotForDateWithPolicy(ctx, "2024-06-17", DEFAULT_RESOLUTION_POLICY)
+
+	if err != nil {
+		t.Fatalf("Failed to derive snapshot with default policy, %v", err)
+	}
+
+	if s.IsMultiple("F5") {
+		t.Fatalf("Did not expect multiple records for F5 on 2024-06-17 with the default policy")
+	}
+
+	_, err = SnapshotForDate(ctx, "bogus")
+
+	if err == nil {
+		t.Fatalf("Expected invalid date to fail")
+	}
+}
+
+func TestGatesSnapshotForDateMultiple(t *testing.T) {
+
+	ctx := context.Background()
+
+	lookup_func := NewLookupFuncWithGates(ctx, []*Gate{
+		&Gate{WhosOnFirstId: 1000000001, Name: "Z1", Inception: "2005", Cessation: "2015"},
+		&Gate{WhosOnFirstId: 1000000002, Name: "Z1", Inception: "2008", Cessation: "2012"},
+		&Gate{WhosOnFirstId: 1000000003, Name: "Z2", Inception: "2008", Cessation: "2012"},
+	})
+
+	lu, err := NewGatesLookupWithLookupFunc(ctx, lookup_func)
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	s, err := lu.SnapshotForDate(ctx, "2010")
+
+	if err != nil {
+		t.Fatalf("Failed to derive snapshot, %v", err)
+	}
+
+	if len(s.Records["Z1"]) != 2 {
+		t.Fatalf("Expected both Z1 gates in snapshot, got %v", s.Records["Z1"])
+	}
+
+	multiple := s.Multiple()
+
+	if len(multiple) != 1 || multiple[0] != "Z1" {
+		t.Fatalf("Unexpected codes with multiple records, %v", multiple)
+	}
+
+	_, err = FindGateForDateWithPolicy(ctx, lu, "Z1", "2010", architecture.RESOLVE_STRICT)
+
+	if !IsMultipleCandidates(err) {
+		t.Fatalf("Expected Z1 to have multiple candidates for 2010, got %v", err)
+	}
+}
+
+func TestGatesSnapshotForHandoverDate(t *testing.T) {
+
+	ctx := context.Background()
+
+	lu, err := NewGatesLookup(ctx, "gates://")
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	// Gates were renamed and relabeled on these dates so the records on either side of them share the same inception and cessation dates
+
+	for _, date := range []string{"2019-07-23", "2021-05-25"} {
+
+		s, err := lu.SnapshotForDateWithPolicy(ctx, date, DEFAULT_RESOLUTION_POLICY)
+
+		if err != nil {
+			t.Fatalf("Failed to derive snapshot for %s, %v", date, err)
+		}
+
+		if len(s.Codes()) == 0 {
+			t.Fatalf("Expected gates in snapshot for %s", date)
+		}
+
+		for _, code := range s.Codes() {
+
+			g, err := FindGateForDateWithTypedLookup(ctx, lu, code, date)
+
+			if err != nil {
+				t.Fatalf("Failed to find %s for %s, %v", code, date, err)
+			}
+
+			if s.IsMultiple(code) || s.Records[code][0] != g {
+				t.Fatalf("Snapshot for %s does not match FindGateForDate for %s", date, code)
+			}
+		}
+	}
+}
+
+func TestGatesList(t *testing.T) {
+
+	ctx := context.Background()
+
+	count, err := Len(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to count gates, %v", err)
+	}
+
+	all, err := All(ctx, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to list gates, %v", err)
+	}
+
+	listed := 0
+
+	for range all {
+		listed += 1
+	}
+
+	if count == 0 || listed != count {
+		t.Fatalf("Unexpected number of gates, listed %d but expected %d", listed, count)
+	}
+
+	current, err := All(ctx, &architecture.ListFilter{Current: architecture.LIST_CURRENT})
+
+	if err != nil {
+		t.Fatalf("Failed to list current gates, %v", err)
+	}
+
+	for g := range current {
+
+		if g.IsCurrent != 1 {
+			t.Fatalf("Gate %d is not current", g.WhosOnFirstId)
+		}
+	}
+
+	codes, err := Codes(ctx, &architecture.ListFilter{Date: "2024-07-25"})
+
+	if err != nil {
+		t.Fatalf("Failed to list codes, %v", err)
+	}
+
+	if !slices.Contains(codes, "A9") || !slices.IsSorted(codes) {
+		t.Fatalf("Unexpected codes for date, %v", codes)
+	}
+
+	_, err = Codes(ctx, &architecture.ListFilter{Date: "bogus"})
+
+	if err == nil {
+		t.Fatalf("Expected invalid date to fail")
+	}
+}
+
+func TestGateTerminal(t *testing.T) {
+
+	ctx := context.Background()
+
+	terminals_lookup, err := terminals.NewTerminalsLookup(ctx, "terminals://")
+
+	if err != nil {
+		t.Fatalf("Failed to create terminals lookup, %v", err)
+	}
+
+	tests := map[[2]string]int64{
+		[2]string{"2022-01-01", "2023-01-01"}: 1763588123,
+		[2]string{"2025-01-01", "2025-02-01"}: 1947304591,
+	}
+
+	for dates, expected := range tests {
+
+		g := &Gate{
+			WhosOnFirstId: 1000000001,
+			Name:          "Z1",
+			Inception:     dates[0],
+			Cessation:     dates[1],
+			TerminalId:    1763588123,
+		}
+
+		tm, err := g.Terminal(ctx, terminals_lookup)
+
+		if err != nil {
+			t.Fatalf("Failed to derive terminal for %s - %s, %v", dates[0], dates[1], err)
+		}
+
+		if tm.WhosOnFirstId != expected {
+			t.Fatalf("Unexpected terminal for %s - %s, expected %d but got %d", dates[0], dates[1], expected, tm.WhosOnFirstId)
+		}
+	}
+
+	g := &Gate{
+		WhosOnFirstId: 1000000002,
+		Name:          "Z2",
+	}
+
+	_, err = g.Terminal(ctx, terminals_lookup)
+
+	if err == nil {
+		t.Fatalf("Expected gate without a terminal ID to fail")
+	}
+}
+
+func TestGateTerminalEmbedded(t *testing.T) {
+
+	ctx := context.Background()
+
+	terminals_lookup, err := terminals.NewTerminalsLookup(ctx, "terminals://")
+
+	if err != nil {
+		t.Fatalf("Failed to create terminals lookup, %v", err)
+	}
+
+	g, err := FindCurrentGate(ctx, "A9")
+
+	if err != nil {
+		t.Fatalf("Failed to find current gate A9, %v", err)
+	}
+
+	if g.TerminalId <= 0 {
+		t.Fatalf("Embedded gates data does not contain hierarchy properties, it needs to be recompiled with 'make compile-gates'")
+	}
+
+	if g.ParentId <= 0 || g.BoardingAreaId <= 0 {
+		t.Fatalf("Expected gate A9 (%d) to have parent and boarding area IDs", g.WhosOnFirstId)
+	}
+
+	_, err = g.Terminal(ctx, terminals_lookup)
+
+	if err != nil {
+		t.Fatalf("Failed to derive terminal for gate A9 (%d), %v", g.WhosOnFirstId, err)
+	}
+
+	current, err := All(ctx, &architecture.ListFilter{Current: architecture.LIST_CURRENT})
+
+	if err != nil {
+		t.Fatalf("Failed to list current gates, %v", err)
+	}
+
+	for g := range current {
+
+		_, err := g.Terminal(ctx, terminals_lookup)
+
+		if err != nil {
+			t.Fatalf("Failed to derive terminal for gate %s (%d), %v", g.Name, g.WhosOnFirstId, err)
+		}
+	}
+}
+
+func TestGatesLookupLineage(t *testing.T) {
+
+	ctx := context.Background()
+
+	gates_list := []*Gate{
+		&Gate{WhosOnFirstId: 1000000001, Name: "Z1", Inception: "2000", Cessation: "2010", SupersededBy: []int64{1000000002}},
+		&Gate{WhosOnFirstId: 1000000002, Name: "Z1", Inception: "2010", Cessation: "2020", Supersedes: []int64{1000000001}, SupersededBy: []int64{1000000003}},
+		&Gate{WhosOnFirstId: 1000000003, Name: "Z1", Inception: "2020", Cessation: "..", Supersedes: []int64{1000000002}},
+	}
+
+	lu, err := NewGatesLookupWithLookupFunc(ctx, NewLookupFuncWithGates(ctx, gates_list))
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	predecessors, err := lu.Predecessors(ctx, 1000000003)
+
+	if err != nil {
+		t.Fatalf("Failed to derive predecessors, %v", err)
+	}
+
+	if len(predecessors) != 2 || predecessors[0].WhosOnFirstId != 1000000001 || predecessors[1].WhosOnFirstId != 1000000002 {
+		t.Fatalf("Unexpected predecessors, %v", predecessors)
+	}
+
+	successors, err := lu.Successors(ctx, 1000000001)
+
+	if err != nil {
+		t.Fatalf("Failed to derive successors, %v", err)
+	}
+
+	if len(successors) != 2 || successors[0].WhosOnFirstId != 1000000002 || successors[1].WhosOnFirstId != 1000000003 {
+		t.Fatalf("Unexpected successors, %v", successors)
+	}
+
+	gates_list[0].Supersedes = []int64{1000000003}
+	gates_list[2].SupersededBy = []int64{1000000001}
+
+	_, err = lu.Lineage(ctx, 1000000002)
+
+	if !errors.Is(err, architecture.ErrLineageCycle) {
+		t.Fatalf("Expected cycle error, got %v", err)
+	}
+}
+
+func TestGatesLineageEmbedded(t *testing.T) {
+
+	ctx := context.Background()
+
+	g, err := FindCurrentGate(ctx, "A9")
+
+	if err != nil {
+		t.Fatalf("Failed to find current gate A9, %v", err)
+	}
+
+	if len(g.Supersedes) == 0 {
+		t.Fatalf("Embedded gates data does not contain supersession properties, it needs to be recompiled with 'make compile-gates'")
+	}
+
+	predecessors, err := Predecessors(ctx, g.WhosOnFirstId)
+
+	if err != nil {
+		t.Fatalf("Failed to derive predecessors for gate A9 (%d), %v", g.WhosOnFirstId, err)
+	}
+
+	if len(predecessors) == 0 {
+		t.Fatalf("Expected predecessors for gate A9 (%d)", g.WhosOnFirstId)
+	}
+
+	successors, err := Successors(ctx, predecessors[0].WhosOnFirstId)
+
+	if err != nil {
+		t.Fatalf("Failed to derive successors for gate %d, %v", predecessors[0].WhosOnFirstId, err)
+	}
+
+	if len(successors) == 0 || successors[len(successors)-1].WhosOnFirstId != g.WhosOnFirstId {
+		t.Fatalf("Expected gate A9 (%d) to be the last successor of gate %d", g.WhosOnFirstId, predecessors[0].WhosOnFirstId)
+	}
+}
+
+func TestGatesLookupWithFS(t *testing.T) {
+
+	ctx := context.Background()
+
+	body := []byte(`[{"wof:id": 1000000001, "wof:name": "Z1", "mz:is_current": 1, "edtf:inception": "2020", "edtf:cessation": ".."}]`)
+
+	fsys := fstest.MapFS{
+		"gates.json": &fstest.MapFile{Data: body},
+		"bunk.json":  &fstest.MapFile{Data: []byte(`{"bunk"`)},
+	}
+
+	lu, err := NewGatesLookupWithFS(ctx, fsys, "gates.json")
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup from FS, %v", err)
+	}
+
+	rsp, err := lu.Find(ctx, "Z1")
+
+	if err != nil {
+		t.Fatalf("Failed to find Z1, %v", err)
+	}
+
+	if len(rsp) != 1 || rsp[0].WhosOnFirstId != 1000000001 {
+		t.Fatalf("Unexpected results for Z1")
+	}
+
+	_, err = NewGatesLookupWithFS(ctx, fsys, "bunk.json")
+
+	if err == nil {
+		t.Fatalf("Expected invalid data to fail")
+	}
+
+	_, err = NewGatesLookupWithFS(ctx, fsys, "missing.json")
+
+	if err == nil {
+		t.Fatalf("Expected missing file to fail")
+	}
+
+	path := filepath.Join(t.TempDir(), "gates.json")
+
+	err = os.WriteFile(path, body, 0644)
+
+	if err != nil {
+		t.Fatalf("Failed to write %s, %v", path, err)
+	}
+
+	file_lu, err := architecture.NewLookup(ctx, "gates://file?path="+url.QueryEscape(path))
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup from file, %v", err)
+	}
+
+	_, err = file_lu.Find(ctx, "Z1")
+
+	if err != nil {
+		t.Fatalf("Failed to find Z1 in file lookup, %v", err)
+	}
+
+	_, err = architecture.NewLookup(ctx, "gates://file")
+
+	if err == nil {
+		t.Fatalf("Expected file URI without a path to fail")
+	}
+}
+
+func TestGatesLookupRemote(t *testing.T) {
+
+	ctx := context.Background()
+
+	srv := httptest.NewServer(http.FileServer(http.Dir("../data")))
+	defer srv.Close()
+
+	lu, err := NewGatesLookup(ctx, "gates://remote?url="+url.QueryEscape(srv.URL))
+
+	if err != nil {
+		t.Fatalf("Failed to create remote lookup, %v", err)
+	}
+
+	_, err = lu.Find(ctx, "A9")
+
+	if err != nil {
+		t.Fatalf("Failed to find A9 in remote lookup, %v", err)
+	}
+
+	_, err = NewGatesLookup(ctx, "gates://remote?url="+url.QueryEscape(srv.URL+"/missing"))
+
+	if err == nil {
+		t.Fatalf("Expected missing remote data to fail")
+	}
+
+	_, err = NewGatesLookup(ctx, "gates://remote?fallback=true&url="+url.QueryEscape(srv.URL+"/missing"))
+
+	if err != nil {
+		t.Fatalf("Expected missing remote data to fall back to embedded data, %v", err)
+	}
+}
+
+func TestGatesLookupWriteTo(t *testing.T) {
+
+	ctx := context.Background()
+
+	lu, err := NewGatesLookup(ctx, "")
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	err = lu.Append(ctx, &Gate{WhosOnFirstId: 1000000001, Name: "Z1", IsCurrent: 1, Inception: "2024", Cessation: ".."})
+
+	if err != nil {
+		t.Fatalf("Failed to append gate, %v", err)
+	}
+
+	var buf_a bytes.Buffer
+
+	err = lu.WriteTo(ctx, &buf_a)
+
+	if err != nil {
+		t.Fatalf("Failed to write lookup, %v", err)
+	}
+
+	lookup_func := NewLookupFuncWithReader(ctx, io.NopCloser(bytes.NewReader(buf_a.Bytes())))
+
+	lu_b, err := NewGatesLookupWithLookupFunc(ctx, lookup_func)
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup from exported data, %v", err)
+	}
+
+	_, err = lu_b.Find(ctx, "Z1")
+
+	if err != nil {
+		t.Fatalf("Failed to find appended gate in exported data, %v", err)
+	}
+
+	count_a, _ := lu.Len(ctx)
+	count_b, _ := lu_b.Len(ctx)
+
+	if count_a != count_b {
+		t.Fatalf("Unexpected number of gates in exported data, expected %d but got %d", count_a, count_b)
+	}
+
+	var buf_b bytes.Buffer
+
+	err = lu_b.WriteTo(ctx, &buf_b)
+
+	if err != nil {
+		t.Fatalf("Failed to write exported lookup, %v", err)
+	}
+
+	if !bytes.Equal(buf_a.Bytes(), buf_b.Bytes()) {
+		t.Fatalf("Expected exported data to round-trip exactly")
+	}
+}
+
+func TestGatesLookupGzip(t *testing.T) {
+
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		DATA_JSON: &fstest.MapFile{Data: uncompressedData(t)},
+	}
+
+	json_lu, err := NewGatesLookupWithFS(ctx, fsys, DATA_JSON)
+
+	if err != nil {
+		t.Fatalf("Failed to create JSON lookup, %v", err)
+	}
+
+	gzip_lu, err := NewGatesLookupWithFS(ctx, data.FS, DATA_JSON_GZIP)
+
+	if err != nil {
+		t.Fatalf("Failed to create gzip lookup, %v", err)
+	}
+
+	json_rsp, err := json_lu.Find(ctx, "A9")
+
+	if err != nil {
+		t.Fatalf("Failed to find A9 in JSON lookup, %v", err)
+	}
+
+	gzip_rsp, err := gzip_lu.Find(ctx, "A9")
+
+	if err != nil {
+		t.Fatalf("Failed to find A9 in gzip lookup, %v", err)
+	}
+
+	if len(json_rsp) != len(gzip_rsp) {
+		t.Fatalf("Unexpected count for A9 in gzip lookup, expected %d but got %d", len(json_rsp), len(gzip_rsp))
+	}
+
+	for i, r := range json_rsp {
+
+		if gzip_rsp[i].WhosOnFirstId != r.WhosOnFirstId {
+			t.Fatalf("Unexpected record at position %d in gzip lookup", i)
+		}
+	}
+}
+
+// uncompressedData returns the uncompressed copy of the precompiled (embedded) data.
+func uncompressedData(t testing.TB) []byte {
+
+	fh, err := data.FS.Open(DATA_JSON_GZIP)
+
+	if err != nil {
+		t.Fatalf("Failed to open embedded data, %v", err)
+	}
+
+	defer fh.Close()
+
+	gz, err := gzip.NewReader(fh)
+
+	if err != nil {
+		t.Fatalf("Failed to create gzip reader, %v", err)
+	}
+
+	body, err := io.ReadAll(gz)
+
+	if err != nil {
+		t.Fatalf("Failed to decompress embedded data, %v", err)
+	}
+
+	return body
+}
+
+func benchmarkGatesLookupWithFS(b *testing.B, fsys fs.FS, filename string) {
+
+	ctx := context.Background()
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+
+		_, err := NewGatesLookupWithFS(ctx, fsys, filename)
+
+		if err != nil {
+			b.Fatalf("Failed to create lookup, %v", err)
+		}
+	}
+}
+
+func BenchmarkGatesLookupJSON(b *testing.B) {
+
+	fsys := fstest.MapFS{
+		DATA_JSON: &fstest.MapFile{Data: uncompressedData(b)},
+	}
+
+	benchmarkGatesLookupWithFS(b, fsys, DATA_JSON)
+}
+
+func BenchmarkGatesLookupGzip(b *testing.B) {
+	benchmarkGatesLookupWithFS(b, data.FS, DATA_JSON_GZIP)
+}
+
+func BenchmarkGatesLookupAppend(b *testing.B) {
+
+	ctx := context.Background()
+
+	lu, err := NewGatesLookupWithFS(ctx, data.FS, DATA_JSON_GZIP)
+
+	if err != nil {
+		b.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	// Derive the interval index so that it needs to be kept up to date by each call to Append
+
+	_, err = lu.FindAllOverlappingRange(ctx, "..", "..")
+
+	if err != nil {
+		b.Fatalf("Failed to find overlapping gates, %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+
+		g := &Gate{WhosOnFirstId: 1000000001, Name: "Z1", Inception: "2020", Cessation: ".."}
+
+		err := lu.Append(ctx, g)
+
+		if err != nil {
+			b.Fatalf("Failed to append gate, %v", err)
+		}
+	}
+}
+
+func TestGatesLookupEnvelope(t *testing.T) {
+
+	ctx := context.Background()
+
+	gates_list := []*Gate{
+		&Gate{WhosOnFirstId: 1000000001, Name: "Z1", Inception: "2020", Cessation: ".."},
+	}
+
+	enc, err := json.Marshal(architecture.NewDataEnvelope(PLACETYPE, gates_list))
+
+	if err != nil {
+		t.Fatalf("Failed to marshal envelope, %v", err)
+	}
+
+	r := io.NopCloser(bytes.NewReader(enc))
+
+	lu, err := NewGatesLookupWithLookupFunc(ctx, NewLookupFuncWithReader(ctx, r))
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	_, err = lu.Find(ctx, "Z1")
+
+	if err != nil {
+		t.Fatalf("Failed to find Z1, %v", err)
+	}
+
+	enc = bytes.Replace(enc, []byte(`"schema_version":1`), []byte(`"schema_version":99`), 1)
+	r = io.NopCloser(bytes.NewReader(enc))
+
+	_, err = NewGatesLookupWithLookupFunc(ctx, NewLookupFuncWithReader(ctx, r))
+
+	if err == nil || !strings.Contains(err.Error(), "Unsupported schema version") {
+		t.Fatalf("Expected newer schema version to fail, got %v", err)
+	}
+}
+
+func TestSQLiteGatesLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	features := map[string]string{
+		"1000000001.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000001, "wof:name": "Z1", "sfomuseum:placetype": "gate", "mz:is_current": 0, "edtf:inception": "2000", "edtf:cessation": "2010"}),
+		"1000000002.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000002, "wof:name": "Z1", "sfomuseum:placetype": "gate", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+		"1000000003.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000003, "wof:name": "Z1", "sfomuseum:placetype": "gallery", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+	}
+
+	dsn := testutil.NewSQLiteDatabase(t, features)
+
+	lu, err := architecture.NewLookup(ctx, "gates://sqlite?dsn="+url.QueryEscape(dsn))
+
+	if err != nil {
+		t.Fatalf("Failed to create SQLite lookup, %v", err)
+	}
+
+	typed := architecture.NewTypedLookup[*Gate](lu)
+
+	defer typed.(*SQLiteGatesLookup).Close()
+
+	tests := map[string][]int64{
+		"Z1":         []int64{1000000001, 1000000002},
+		"1000000002": []int64{1000000002},
+	}
+
+	for code, expected := range tests {
+
+		rsp, err := typed.Find(ctx, code)
+
+		if err != nil {
+			t.Fatalf("Failed to find %s, %v", code, err)
+		}
+
+		if len(rsp) != len(expected) {
+			t.Fatalf("Unexpected number of results for %s, expected %d but got %d", code, len(expected), len(rsp))
+		}
+
+		for idx, g := range rsp {
+
+			if g.WhosOnFirstId != expected[idx] {
+				t.Fatalf("Unexpected result %d for %s, expected %d but got %d", idx, code, expected[idx], g.WhosOnFirstId)
+			}
+		}
+	}
+
+	_, err = typed.Find(ctx, "1000000003")
+
+	if !IsNotFound(err) {
+		t.Fatalf("Expected gallery ID to not be found, got %v", err)
+	}
+
+	// Normalized codes can not be indexed so they are not matched by SQLite lookups
+
+	for _, code := range []string{"gate z-01"} {
+
+		_, err = typed.Find(ctx, code)
+
+		if !IsNotFound(err) {
+			t.Fatalf("Expected %s to not be found, got %v", code, err)
+		}
+	}
+
+	current, err := FindCurrentGateWithTypedLookup(ctx, typed, "Z1")
+
+	if err != nil {
+		t.Fatalf("Failed to find current gate, %v", err)
+	}
+
+	if current.WhosOnFirstId != 1000000002 {
+		t.Fatalf("Unexpected current gate %d", current.WhosOnFirstId)
+	}
+
+	g, err := FindGateForDateWithTypedLookup(ctx, typed, "Z1", "2005-06-01")
+
+	if err != nil {
+		t.Fatalf("Failed to find gate for date, %v", err)
+	}
+
+	if g.WhosOnFirstId != 1000000001 {
+		t.Fatalf("Unexpected gate for date %d", g.WhosOnFirstId)
+	}
+
+	count, err := lu.(architecture.ListableLookup).Len(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to count gates, %v", err)
+	}
+
+	if count != 2 {
+		t.Fatalf("Unexpected number of gates, %d", count)
+	}
+
+	all, err := lu.(architecture.ListableLookup).All(ctx, &architecture.ListFilter{Date: "2005-06-01"})
+
+	if err != nil {
+		t.Fatalf("Failed to list gates, %v", err)
+	}
+
+	ids := make([]int64, 0)
+
+	for r := range all {
+		ids = append(ids, r.(*Gate).WhosOnFirstId)
+	}
+
+	if len(ids) != 1 || ids[0] != 1000000001 {
+		t.Fatalf("Unexpected gates for date, %v", ids)
+	}
+
+	err = typed.Append(ctx, g)
+
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("Expected append to be unsupported, got %v", err)
+	}
+
+	_, err = architecture.NewLookup(ctx, "gates://sqlite")
+
+	if err == nil {
+		t.Fatalf("Expected sqlite URI without a DSN to fail")
+	}
+}
+
+func TestSQLiteGatesLookupCodes(t *testing.T) {
+
+	ctx := context.Background()
+
+	features := map[string]string{
+		"1000000001.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000001, "wof:name": "Z1", "sfomuseum:placetype": "gate", "mz:is_current": 0, "edtf:inception": "2000", "edtf:cessation": "2010"}),
+		"1000000002.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000002, "wof:name": "Z1", "sfomuseum:placetype": "gate", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+		"1000000003.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000003, "wof:name": "Z2", "sfomuseum:placetype": "gate", "mz:is_current": 0, "edtf:inception": "2000", "edtf:cessation": "2010"}),
+	}
+
+	dsn := testutil.NewSQLiteDatabase(t, features)
+
+	lu, err := NewSQLiteGatesLookup(ctx, "gates://sqlite?dsn="+url.QueryEscape(dsn))
+
+	if err != nil {
+		t.Fatalf("Failed to create SQLite lookup, %v", err)
+	}
+
+	defer lu.Close()
+
+	codes, err := lu.Codes(ctx, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to list codes, %v", err)
+	}
+
+	if !slices.Equal(codes, []string{"Z1", "Z2"}) {
+		t.Fatalf("Unexpected codes, %v", codes)
+	}
+
+	// The codes selected by the database should be the same as the codes derived from the records themselves
+
+	all, err := lu.All(ctx, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to list gates, %v", err)
+	}
+
+	if record_codes := architecture.RecordCodes(slices.Collect(all)); !slices.Equal(codes, record_codes) {
+		t.Fatalf("Expected codes to match the records' codes, %v", record_codes)
+	}
+
+	codes, err = lu.Codes(ctx, &architecture.ListFilter{Current: architecture.LIST_CURRENT})
+
+	if err != nil {
+		t.Fatalf("Failed to list current codes, %v", err)
+	}
+
+	if !slices.Equal(codes, []string{"Z1"}) {
+		t.Fatalf("Unexpected current codes, %v", codes)
+	}
+}
+
+func TestReaderGatesLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	features := map[int64]string{
+		1000000001: testutil.Feature(t, map[string]any{"wof:id": 1000000001, "wof:name": "Z1", "sfomuseum:placetype": "gate", "mz:is_current": 0, "edtf:inception": "2000", "edtf:cessation": "2010"}),
+		1000000002: testutil.Feature(t, map[string]any{"wof:id": 1000000002, "wof:name": "Z1", "sfomuseum:placetype": "gate", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+	}
+
+	reader_uri := testutil.NewReaderData(t, features)
+
+	index := []byte(`[{"wof:id": 1000000001, "wof:name": "Z1"}, {"wof:id": 1000000002, "wof:name": "Z1"}, {"wof:id": 1000000003, "wof:name": "Z2"}]`)
+
+	index_path := filepath.Join(t.TempDir(), "gates.json")
+
+	err := os.WriteFile(index_path, index, 0644)
+
+	if err != nil {
+		t.Fatalf("Failed to write index, %v", err)
+	}
+
+	q := url.Values{}
+	q.Set("reader", reader_uri)
+	q.Set("index", "gates://file?path="+url.QueryEscape(index_path))
+
+	lu, err := architecture.NewLookup(ctx, "gates://reader?"+q.Encode())
+
+	if err != nil {
+		t.Fatalf("Failed to create reader lookup, %v", err)
+	}
+
+	typed := architecture.NewTypedLookup[*Gate](lu)
+
+	rsp, err := typed.Find(ctx, "gate z-01")
+
+	if err != nil {
+		t.Fatalf("Failed to find Z1, %v", err)
+	}
+
+	if len(rsp) != 2 || rsp[0].WhosOnFirstId != 1000000001 || rsp[1].WhosOnFirstId != 1000000002 {
+		t.Fatalf("Unexpected results for Z1, %v", rsp)
+	}
+
+	for _, g := range rsp {
+
+		if g.Feature == nil || g.Feature.Geometry.GeoJSONType() != "Point" {
+			t.Fatalf("Expected gate %d to have a point geometry", g.WhosOnFirstId)
+		}
+
+		if g.Feature.Properties.MustString("sfomuseum:placetype") != "gate" {
+			t.Fatalf("Expected gate %d to have all its properties", g.WhosOnFirstId)
+		}
+	}
+
+	current, err := FindCurrentGateWithTypedLookup(ctx, typed, "Z1")
+
+	if err != nil {
+		t.Fatalf("Failed to find current gate, %v", err)
+	}
+
+	if current != rsp[1] {
+		t.Fatalf("Expected current gate to be read from the cache")
+	}
+
+	_, err = typed.Find(ctx, "Z2")
+
+	if err == nil {
+		t.Fatalf("Expected gate missing from reader to fail")
+	}
+
+	_, err = typed.Find(ctx, "Z3")
+
+	if !IsNotFound(err) {
+		t.Fatalf("Expected Z3 to not be found, got %v", err)
+	}
+
+	err = typed.Append(ctx, &Gate{WhosOnFirstId: 1000000004, Name: "Z3"})
+
+	if err != nil {
+		t.Fatalf("Failed to append gate, %v", err)
+	}
+
+	rsp, err = typed.Find(ctx, "Z3")
+
+	if err != nil || len(rsp) != 1 || rsp[0].WhosOnFirstId != 1000000004 {
+		t.Fatalf("Failed to find appended gate, %v", err)
+	}
+
+	_, err = architecture.NewLookup(ctx, "gates://reader")
+
+	if err == nil {
+		t.Fatalf("Expected reader URI without a reader to fail")
+	}
+}
@@ -0,0 +1,63 @@
+package gates
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+)
+
+// The queries used by `SQLiteGatesLookup` to find gates by their Who's On First ID or name.
+var sqlite_queries = []*architecture.SQLiteCodeQuery{
+	architecture.SQLITE_QUERY_BY_ID,
+	architecture.SQLITE_QUERY_BY_NAME,
+}
+
+// The SQL expression that derives the same value as `Gate.Code`, the gate's name.
+const sqlite_code_expr string = `JSON_EXTRACT(body, '$.properties."wof:name"')`
+
+// SQLiteGatesLookup implements the `architecture.TypedLookup[*Gate]` interface for gates stored in a Who's On First SQLite database,
+// as produced by `campus.NewDatabaseWithIterator`. Records are queried from the database, by their Who's On First ID or name, each time
+// `Find` is called rather than being loaded in to memory ahead of time. The current and date-based finders (for example `FindCurrentGateWithLookup`)
+// filter the records returned by `Find` for a code so they only ever evaluate the handful of records sharing that code. See `architecture.SQLiteLookup`
+// for details.
+type SQLiteGatesLookup struct {
+	*architecture.SQLiteLookup[*Gate]
+}
+
+// NewSQLiteGatesLookup will return a `SQLiteGatesLookup` instance derived from 'uri' which is expected to take the form:
+//
+//	`gates://sqlite?dsn={DSN}`
+//
+// Where `{DSN}` is the data source name of a Who's On First SQLite database. See `architecture.NewSQLiteDatabaseWithURL` for details
+// on the other parameters.
+func NewSQLiteGatesLookup(ctx context.Context, uri string) (*SQLiteGatesLookup, error) {
+
+	sqlite_lookup, err := architecture.NewSQLiteLookup(ctx, record_kind, uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := &SQLiteGatesLookup{
+		SQLiteLookup: sqlite_lookup,
+	}
+
+	return l, nil
+}
+
+// NewSQLiteGatesLookupWithDatabase will return a `SQLiteGatesLookup` instance for 'db'. No indices are created.
+func NewSQLiteGatesLookupWithDatabase(ctx context.Context, db *sql.DB) (*SQLiteGatesLookup, error) {
+
+	sqlite_lookup, err := architecture.NewSQLiteLookupWithDatabase(ctx, record_kind, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := &SQLiteGatesLookup{
+		SQLiteLookup: sqlite_lookup,
+	}
+
+	return l, nil
+}
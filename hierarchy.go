@@ -0,0 +1,56 @@
+package architecture
+
+import (
+	"github.com/whosonfirst/go-whosonfirst-feature/properties"
+)
+
+// The `wof:hierarchy` key for the (Who's On First) ID of the terminal an architectural element belongs to.
+// Terminals are recorded with a "wing" Who's On First placetype.
+const HIERARCHY_TERMINAL_KEY string = "wing_id"
+
+// The `wof:hierarchy` key for the (Who's On First) ID of the boarding area an architectural element belongs to.
+// Boarding areas are recorded with a "concourse" Who's On First placetype.
+const HIERARCHY_BOARDINGAREA_KEY string = "concourse_id"
+
+// type Parents is a struct containing the parent IDs for an architectural element derived from its Who's On First record.
+type Parents struct {
+	// The Who's On First ID of the element's immediate parent.
+	ParentId int64
+	// The Who's On First ID of the boarding area the element belongs to, or 0 if unknown.
+	BoardingAreaId int64
+	// The Who's On First ID of the terminal the element belongs to, or 0 if unknown.
+	TerminalId int64
+}
+
+// DeriveParents returns a `Parents` instance derived from the `wof:parent_id` and `wof:hierarchy` properties in 'body'.
+// Missing or invalid parent IDs are reported as -1 (the Who's On First "unknown" parent ID). When there are multiple
+// hierarchies the first one to define a boarding area or terminal ID is used.
+func DeriveParents(body []byte) *Parents {
+
+	p := &Parents{
+		ParentId: -1,
+	}
+
+	parent_id, err := properties.ParentId(body)
+
+	if err == nil {
+		p.ParentId = parent_id
+	}
+
+	for _, h := range properties.Hierarchies(body) {
+
+		ba_id, ok := h[HIERARCHY_BOARDINGAREA_KEY]
+
+		if ok && ba_id > 0 && p.BoardingAreaId == 0 {
+			p.BoardingAreaId = ba_id
+		}
+
+		t_id, ok := h[HIERARCHY_TERMINAL_KEY]
+
+		if ok && t_id > 0 && p.TerminalId == 0 {
+			p.TerminalId = t_id
+		}
+	}
+
+	return p
+}
@@ -0,0 +1,30 @@
+package architecture
+
+import (
+	"testing"
+)
+
+func TestDeriveParents(t *testing.T) {
+
+	body := []byte(`{"properties": {"wof:parent_id": 1763588101, "wof:hierarchy": [{"campus_id": 102527513, "wing_id": 1763588123, "concourse_id": 1763588101}]}}`)
+
+	p := DeriveParents(body)
+
+	if p.ParentId != 1763588101 {
+		t.Fatalf("Unexpected parent ID, %d", p.ParentId)
+	}
+
+	if p.BoardingAreaId != 1763588101 {
+		t.Fatalf("Unexpected boarding area ID, %d", p.BoardingAreaId)
+	}
+
+	if p.TerminalId != 1763588123 {
+		t.Fatalf("Unexpected terminal ID, %d", p.TerminalId)
+	}
+
+	p = DeriveParents([]byte(`{"properties": {}}`))
+
+	if p.ParentId != -1 || p.BoardingAreaId != 0 || p.TerminalId != 0 {
+		t.Fatalf("Unexpected parents for empty record, %v", p)
+	}
+}
@@ -0,0 +1,144 @@
+// Package testutil provides helper functions, shared by the tests in this module, for creating Who's On First GeoJSON Features and
+// the SQLite databases and directories used to store them.
+package testutil
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aaronland/go-sqlite"
+	aa_database "github.com/aaronland/go-sqlite/database"
+	"github.com/whosonfirst/go-whosonfirst-sqlite-features/tables"
+	"github.com/whosonfirst/go-whosonfirst-uri"
+)
+
+// Feature returns an encoded Who's On First GeoJSON Feature with a point geometry whose properties are 'props' combined
+// with the minimum set of default properties needed to index the Feature.
+func Feature(t *testing.T, props map[string]any) string {
+
+	properties := map[string]any{
+		"wof:placetype":    "venue",
+		"wof:parent_id":    -1,
+		"wof:country":      "US",
+		"wof:repo":         "sfomuseum-data-architecture",
+		"wof:lastmodified": 1,
+	}
+
+	for k, v := range props {
+		properties[k] = v
+	}
+
+	f := map[string]any{
+		"type":       "Feature",
+		"id":         properties["wof:id"],
+		"properties": properties,
+		"geometry": map[string]any{
+			"type":        "Point",
+			"coordinates": []float64{-122.386, 37.616},
+		},
+	}
+
+	enc, err := json.Marshal(f)
+
+	if err != nil {
+		t.Fatalf("Failed to marshal feature, %v", err)
+	}
+
+	return string(enc)
+}
+
+// NewSQLiteDatabase creates a new Who's On First SQLite database, in a temporary directory, with `geojson` and `spr` tables
+// containing 'features' and returns its data source name. 'features' is a dictionary of filenames and encoded GeoJSON Features.
+func NewSQLiteDatabase(t *testing.T, features map[string]string) string {
+
+	ctx := context.Background()
+
+	dsn := filepath.Join(t.TempDir(), "architecture.db")
+
+	db, err := aa_database.NewDB(ctx, dsn)
+
+	if err != nil {
+		t.Fatalf("Failed to create database, %v", err)
+	}
+
+	defer db.Close()
+
+	geojson_table, err := tables.NewGeoJSONTableWithDatabase(ctx, db)
+
+	if err != nil {
+		t.Fatalf("Failed to create geojson table, %v", err)
+	}
+
+	spr_table, err := tables.NewSPRTableWithDatabase(ctx, db)
+
+	if err != nil {
+		t.Fatalf("Failed to create spr table, %v", err)
+	}
+
+	for fname, body := range features {
+
+		for _, table := range []sqlite.Table{geojson_table, spr_table} {
+
+			err := table.IndexRecord(ctx, db, []byte(body))
+
+			if err != nil {
+				t.Fatalf("Failed to index %s in %s table, %v", fname, table.Name(), err)
+			}
+		}
+	}
+
+	return dsn
+}
+
+// NewReaderData writes 'features', a dictionary of Who's On First IDs and encoded GeoJSON Features, to a temporary directory
+// using the standard Who's On First directory structure and returns a `fs://` go-reader URI for that directory.
+func NewReaderData(t *testing.T, features map[int64]string) string {
+
+	root := t.TempDir()
+
+	for id, body := range features {
+
+		rel_path, err := uri.Id2RelPath(id)
+
+		if err != nil {
+			t.Fatalf("Failed to derive path for %d, %v", id, err)
+		}
+
+		path := filepath.Join(root, rel_path)
+
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+
+		if err != nil {
+			t.Fatalf("Failed to create directory for %d, %v", id, err)
+		}
+
+		err = os.WriteFile(path, []byte(body), 0644)
+
+		if err != nil {
+			t.Fatalf("Failed to write %d, %v", id, err)
+		}
+	}
+
+	return "fs://" + root
+}
+
+// NewDirectoryData writes 'features', a dictionary of filenames and encoded GeoJSON Features, to a temporary directory and
+// returns the path to that directory.
+func NewDirectoryData(t *testing.T, features map[string]string) string {
+
+	root := t.TempDir()
+
+	for fname, body := range features {
+
+		err := os.WriteFile(filepath.Join(root, fname), []byte(body), 0644)
+
+		if err != nil {
+			t.Fatalf("Failed to write %s, %v", fname, err)
+		}
+	}
+
+	return root
+}
@@ -0,0 +1,336 @@
+package architecture
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/sfomuseum/go-edtf"
+	"github.com/sfomuseum/go-edtf/parser"
+)
+
+// type Span represents the period of time, derived from a pair of EDTF inception and cessation dates, during which an
+// architectural element existed. Bounds are stored as Unix timestamps and are the widest possible interpretation of the
+// underlying EDTF dates: the lower bound is the earliest possible inception time and the upper bound is the latest possible
+// cessation time.
+//
+// Open (`..`) dates are treated as unbounded. Unknown (empty) dates are also treated as unbounded, since it is not possible
+// to rule out that the element existed at any given time on that side of the span, but are flagged separately so that
+// callers can distinguish between the two cases.
+type Span struct {
+	// The (EDTF) inception date for the span.
+	Inception string
+	// The (EDTF) cessation date for the span.
+	Cessation string
+	// The earliest possible inception time.
+	InceptionLower int64
+	// The latest possible inception time.
+	InceptionUpper int64
+	// The earliest possible cessation time.
+	CessationLower int64
+	// The latest possible cessation time.
+	CessationUpper int64
+	// Boolean flag signaling that the inception date is open (`..`).
+	OpenInception bool
+	// Boolean flag signaling that the cessation date is open (`..`).
+	OpenCessation bool
+	// Boolean flag signaling that the inception date is unknown (empty).
+	UnknownInception bool
+	// Boolean flag signaling that the cessation date is unknown (empty).
+	UnknownCessation bool
+}
+
+// NewSpan returns a new `Span` instance derived from 'inception' and 'cessation'. An error is returned if either
+// date is not a valid EDTF string.
+func NewSpan(inception string, cessation string) (*Span, error) {
+
+	s := &Span{
+		Inception: inception,
+		Cessation: cessation,
+	}
+
+	switch {
+	case edtf.IsOpen(inception):
+		s.OpenInception = true
+	case edtf.IsUnknown(inception):
+		s.UnknownInception = true
+	default:
+
+		lower, upper, err := edtfBounds(inception)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to derive bounds for inception date '%s', %w", inception, err)
+		}
+
+		s.InceptionLower = lower
+		s.InceptionUpper = upper
+	}
+
+	switch {
+	case edtf.IsOpen(cessation):
+		s.OpenCessation = true
+	case edtf.IsUnknown(cessation):
+		s.UnknownCessation = true
+	default:
+
+		lower, upper, err := edtfBounds(cessation)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to derive bounds for cessation date '%s', %w", cessation, err)
+		}
+
+		s.CessationLower = lower
+		s.CessationUpper = upper
+	}
+
+	return s, nil
+}
+
+// NewSpanForDate returns a new `Span` instance spanning the EDTF string 'date'.
+func NewSpanForDate(date string) (*Span, error) {
+	return NewSpan(date, date)
+}
+
+// Lower returns the earliest possible time for the span, or `math.MinInt64` if the lower bound is open or unknown.
+func (s *Span) Lower() int64 {
+
+	if s.OpenInception || s.UnknownInception {
+		return math.MinInt64
+	}
+
+	return s.InceptionLower
+}
+
+// Upper returns the latest possible time for the span, or `math.MaxInt64` if the upper bound is open or unknown.
+func (s *Span) Upper() int64 {
+
+	if s.OpenCessation || s.UnknownCessation {
+		return math.MaxInt64
+	}
+
+	return s.CessationUpper
+}
+
+// Overlaps reports whether any part of 's' overlaps any part of 'other'.
+func (s *Span) Overlaps(other *Span) bool {
+	return s.Lower() <= other.Upper() && other.Lower() <= s.Upper()
+}
+
+// ChangedDuring reports whether either the inception or the cessation date for 's' overlaps 'other'. Open and
+// unknown dates are never considered to have changed.
+func (s *Span) ChangedDuring(other *Span) bool {
+
+	if !s.OpenInception && !s.UnknownInception {
+
+		if s.InceptionLower <= other.Upper() && other.Lower() <= s.InceptionUpper {
+			return true
+		}
+	}
+
+	if !s.OpenCessation && !s.UnknownCessation {
+
+		if s.CessationLower <= other.Upper() && other.Lower() <= s.CessationUpper {
+			return true
+		}
+	}
+
+	return false
+}
+
+func edtfBounds(date string) (int64, int64, error) {
+
+	d, err := parser.ParseString(date)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	lower, err := d.Lower()
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("Failed to derive lower bound, %w", err)
+	}
+
+	upper, err := d.Upper()
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("Failed to derive upper bound, %w", err)
+	}
+
+	return lower.Unix(), upper.Unix(), nil
+}
+
+type intervalEntry[T comparable] struct {
+	lower int64
+	upper int64
+	value T
+}
+
+// type IntervalIndex is an index of values keyed by their `Span`, sorted by lower bound, which can be queried for all the
+// values whose spans overlap a given span. It is safe for concurrent use.
+type IntervalIndex[T comparable] struct {
+	mu      *sync.RWMutex
+	entries []*intervalEntry[T]
+	// max_upper[i] is the largest upper bound of entries[0:i+1] which allows queries to stop scanning early.
+	max_upper []int64
+	spans     map[T]*Span
+}
+
+// NewIntervalIndex returns a new, empty, `IntervalIndex` instance.
+func NewIntervalIndex[T comparable]() *IntervalIndex[T] {
+
+	idx := &IntervalIndex[T]{
+		mu:        new(sync.RWMutex),
+		entries:   make([]*intervalEntry[T], 0),
+		max_upper: make([]int64, 0),
+		spans:     make(map[T]*Span),
+	}
+
+	return idx
+}
+
+// Clone returns a copy of 'idx' that can be modified without affecting 'idx'. Values and their spans are shared with 'idx' so no
+// dates are parsed, or entries sorted, again.
+func (idx *IntervalIndex[T]) Clone() *IntervalIndex[T] {
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	clone := &IntervalIndex[T]{
+		mu:        new(sync.RWMutex),
+		entries:   slices.Clone(idx.entries),
+		max_upper: slices.Clone(idx.max_upper),
+		spans:     maps.Clone(idx.spans),
+	}
+
+	return clone
+}
+
+// Add adds 'v' to the index for 'span'. If 'v' is already present in the index it is replaced.
+func (idx *IntervalIndex[T]) Add(span *Span, v T) {
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	_, exists := idx.spans[v]
+
+	if exists {
+		idx.remove(v)
+	}
+
+	e := &intervalEntry[T]{
+		lower: span.Lower(),
+		upper: span.Upper(),
+		value: v,
+	}
+
+	i := sort.Search(len(idx.entries), func(i int) bool {
+		return idx.entries[i].lower > e.lower
+	})
+
+	idx.entries = append(idx.entries, nil)
+	copy(idx.entries[i+1:], idx.entries[i:])
+	idx.entries[i] = e
+
+	idx.max_upper = append(idx.max_upper, 0)
+	idx.spans[v] = span
+
+	idx.reindex(i)
+}
+
+// Remove removes 'v' from the index.
+func (idx *IntervalIndex[T]) Remove(v T) {
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(v)
+}
+
+// Span returns the `Span` that 'v' was indexed with.
+func (idx *IntervalIndex[T]) Span(v T) (*Span, bool) {
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	s, ok := idx.spans[v]
+	return s, ok
+}
+
+// Overlapping returns all the values whose spans overlap 'span', sorted by lower bound.
+func (idx *IntervalIndex[T]) Overlapping(span *Span) []T {
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	lower := span.Lower()
+	upper := span.Upper()
+
+	// Everything at or after k starts after 'span' ends
+
+	k := sort.Search(len(idx.entries), func(i int) bool {
+		return idx.entries[i].lower > upper
+	})
+
+	matches := make([]T, 0)
+
+	for i := k - 1; i >= 0; i-- {
+
+		if idx.max_upper[i] < lower {
+			break
+		}
+
+		e := idx.entries[i]
+
+		if e.upper >= lower {
+			matches = append(matches, e.value)
+		}
+	}
+
+	for i, j := 0, len(matches)-1; i < j; i, j = i+1, j-1 {
+		matches[i], matches[j] = matches[j], matches[i]
+	}
+
+	return matches
+}
+
+func (idx *IntervalIndex[T]) remove(v T) {
+
+	_, exists := idx.spans[v]
+
+	if !exists {
+		return
+	}
+
+	delete(idx.spans, v)
+
+	for i, e := range idx.entries {
+
+		if e.value != v {
+			continue
+		}
+
+		idx.entries = append(idx.entries[:i], idx.entries[i+1:]...)
+		idx.max_upper = idx.max_upper[:len(idx.entries)]
+
+		idx.reindex(i)
+		break
+	}
+}
+
+func (idx *IntervalIndex[T]) reindex(start int) {
+
+	for i := start; i < len(idx.entries); i++ {
+
+		upper := idx.entries[i].upper
+
+		if i > 0 && idx.max_upper[i-1] > upper {
+			upper = idx.max_upper[i-1]
+		}
+
+		idx.max_upper[i] = upper
+	}
+}
@@ -0,0 +1,178 @@
+package architecture
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSpan(t *testing.T) {
+
+	s, err := NewSpan("2020~", "..")
+
+	if err != nil {
+		t.Fatalf("Failed to create span, %v", err)
+	}
+
+	if !s.OpenCessation || s.UnknownCessation {
+		t.Fatalf("Expected open cessation")
+	}
+
+	if s.Upper() != math.MaxInt64 {
+		t.Fatalf("Expected unbounded upper value")
+	}
+
+	s, err = NewSpan("", "2021-05-25")
+
+	if err != nil {
+		t.Fatalf("Failed to create span, %v", err)
+	}
+
+	if !s.UnknownInception || s.OpenInception {
+		t.Fatalf("Expected unknown inception")
+	}
+
+	if s.Lower() != math.MinInt64 {
+		t.Fatalf("Expected unbounded lower value")
+	}
+
+	_, err = NewSpan("bogus", "..")
+
+	if err == nil {
+		t.Fatalf("Expected invalid inception date to fail")
+	}
+
+	tests_overlaps := [][4]string{
+		[4]string{"2019-10-16", "2020-~05", "2019", "2022"},
+		[4]string{"2021-11-09", "2024-06-17", "2024-06-17", "2024-06-17"},
+		[4]string{"2024-06-17", "..", "2030", ".."},
+		[4]string{"", "2001", "1900", "1900"},
+	}
+
+	for _, test := range tests_overlaps {
+
+		a, _ := NewSpan(test[0], test[1])
+		b, _ := NewSpan(test[2], test[3])
+
+		if !a.Overlaps(b) {
+			t.Fatalf("Expected %s/%s to overlap %s/%s", test[0], test[1], test[2], test[3])
+		}
+	}
+
+	tests_not_overlaps := [][4]string{
+		[4]string{"2019-10-16", "2020-~05", "2021", "2022"},
+		[4]string{"2024-06-17", "..", "2020", "2024-06-16"},
+	}
+
+	for _, test := range tests_not_overlaps {
+
+		a, _ := NewSpan(test[0], test[1])
+		b, _ := NewSpan(test[2], test[3])
+
+		if a.Overlaps(b) {
+			t.Fatalf("Did not expect %s/%s to overlap %s/%s", test[0], test[1], test[2], test[3])
+		}
+	}
+
+	a, _ := NewSpan("2021-11-09", "..")
+	b, _ := NewSpan("2021", "2021")
+	c, _ := NewSpan("2022", "2023")
+
+	if !a.ChangedDuring(b) {
+		t.Fatalf("Expected span to have changed during 2021")
+	}
+
+	if a.ChangedDuring(c) {
+		t.Fatalf("Did not expect span to have changed during 2022-2023")
+	}
+}
+
+func TestIntervalIndex(t *testing.T) {
+
+	spans := map[string][2]string{
+		"a": [2]string{"2000", "2005"},
+		"b": [2]string{"2003", "2010"},
+		"c": [2]string{"2011", ".."},
+		"d": [2]string{"", "1999"},
+		"e": [2]string{"1990", "2020"},
+	}
+
+	idx := NewIntervalIndex[string]()
+
+	for k, v := range spans {
+
+		s, err := NewSpan(v[0], v[1])
+
+		if err != nil {
+			t.Fatalf("Failed to create span for %s, %v", k, err)
+		}
+
+		idx.Add(s, k)
+	}
+
+	tests := map[[2]string]string{
+		[2]string{"2004", "2004"}: "eab",
+		[2]string{"2012", "2013"}: "ec",
+		[2]string{"1950", "1960"}: "d",
+		[2]string{"2021", ".."}:   "c",
+		[2]string{"..", ".."}:     "deabc",
+	}
+
+	for q, expected := range tests {
+
+		s, _ := NewSpan(q[0], q[1])
+
+		str_matches := ""
+
+		for _, m := range idx.Overlapping(s) {
+			str_matches += m
+		}
+
+		if str_matches != expected {
+			t.Fatalf("Unexpected results for %s/%s, expected '%s' but got '%s'", q[0], q[1], expected, str_matches)
+		}
+	}
+
+	idx.Remove("e")
+
+	s, _ := NewSpan("2012", "2013")
+	matches := idx.Overlapping(s)
+
+	if len(matches) != 1 || matches[0] != "c" {
+		t.Fatalf("Unexpected results after removing 'e', %v", matches)
+	}
+
+	_, ok := idx.Span("e")
+
+	if ok {
+		t.Fatalf("Did not expect span for 'e' after removing it")
+	}
+
+	// Changes to a clone should not affect the original index and vice versa
+
+	clone := idx.Clone()
+
+	clone.Remove("c")
+
+	f, _ := NewSpan("2012", "2012")
+	clone.Add(f, "f")
+
+	matches = idx.Overlapping(s)
+
+	if len(matches) != 1 || matches[0] != "c" {
+		t.Fatalf("Unexpected results for original index after modifying clone, %v", matches)
+	}
+
+	matches = clone.Overlapping(s)
+
+	if len(matches) != 1 || matches[0] != "f" {
+		t.Fatalf("Unexpected results for clone, %v", matches)
+	}
+
+	idx.Remove("c")
+
+	_, ok = clone.Span("a")
+
+	if !ok {
+		t.Fatalf("Expected clone to retain span for 'a'")
+	}
+}
@@ -0,0 +1,215 @@
+package architecture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+)
+
+// ErrLineageCycle is the error returned when a supersession chain refers back to itself.
+var ErrLineageCycle = errors.New("Supersession cycle")
+
+// type LineageNode is a struct containing the supersession properties for a single architectural element.
+type LineageNode struct {
+	// The Who's On First ID of the element.
+	Id int64
+	// The list of Who's On First IDs that the element supersedes.
+	Supersedes []int64
+	// The list of Who's On First IDs that the element is superseded by.
+	SupersededBy []int64
+	// The (EDTF) inception date for the element.
+	Inception string
+	// The (EDTF) cessation date for the element.
+	Cessation string
+}
+
+// LineageFetchFunc is a function that returns the record for a Who's On First ID. It should return false if the record can not be found.
+type LineageFetchFunc[T any] func(context.Context, int64) (T, bool, error)
+
+// LineageNodeFunc is a function that returns the `LineageNode` for a record.
+type LineageNodeFunc[T any] func(T) *LineageNode
+
+// type Lineage is the supersession chain for an architectural element.
+type Lineage[T any] struct {
+	// The record whose lineage is being described.
+	Record T
+	// The records that 'Record' supersedes, directly or indirectly, in date order.
+	Predecessors []T
+	// The records that supersede 'Record', directly or indirectly, in date order.
+	Successors []T
+	// The Who's On First IDs of the records in the chain which supersede, or are superseded by, more than one record.
+	Branches []int64
+}
+
+// All returns the predecessors, the record itself and the successors in date order.
+func (l *Lineage[T]) All() []T {
+
+	all := make([]T, 0, len(l.Predecessors)+len(l.Successors)+1)
+	all = append(all, l.Predecessors...)
+	all = append(all, l.Record)
+	all = append(all, l.Successors...)
+
+	return all
+}
+
+// IsBranched reports whether any record in the chain supersedes, or is superseded by, more than one record.
+func (l *Lineage[T]) IsBranched() bool {
+	return len(l.Branches) > 0
+}
+
+// DeriveLineage returns the `Lineage` for the record with Who's On First ID 'id' by walking its `wof:supersedes` and `wof:superseded_by`
+// properties in both directions. Records that are referenced but that can not be fetched (for example deprecated records that were
+// excluded from the lookup) are skipped. An error wrapping `ErrLineageCycle` is returned if the chain refers back to itself.
+func DeriveLineage[T any](ctx context.Context, id int64, fetch LineageFetchFunc[T], node LineageNodeFunc[T]) (*Lineage[T], error) {
+
+	r, ok, err := fetch(ctx, id)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to fetch record %d, %w", id, err)
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("Failed to find record %d", id)
+	}
+
+	branches := make(map[int64]bool)
+
+	walk := func(next func(*LineageNode) []int64) ([]T, error) {
+
+		records := make([]T, 0)
+
+		// 1 = currently being visited, 2 = done
+		state := map[int64]int{
+			id: 1,
+		}
+
+		var visit func(T) error
+
+		visit = func(r T) error {
+
+			n := node(r)
+
+			if len(n.Supersedes) > 1 || len(n.SupersededBy) > 1 {
+				branches[n.Id] = true
+			}
+
+			for _, next_id := range next(n) {
+
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				default:
+					// pass
+				}
+
+				switch state[next_id] {
+				case 1:
+					return fmt.Errorf("Record %d refers back to %d, %w", n.Id, next_id, ErrLineageCycle)
+				case 2:
+					continue
+				}
+
+				next_r, ok, err := fetch(ctx, next_id)
+
+				if err != nil {
+					return fmt.Errorf("Failed to fetch record %d, %w", next_id, err)
+				}
+
+				if !ok {
+					slog.Debug("Failed to find record in supersession chain, skipping", "id", next_id, "from", n.Id)
+					state[next_id] = 2
+					continue
+				}
+
+				state[next_id] = 1
+
+				err = visit(next_r)
+
+				if err != nil {
+					return err
+				}
+
+				state[next_id] = 2
+
+				records = append(records, next_r)
+			}
+
+			return nil
+		}
+
+		err := visit(r)
+
+		if err != nil {
+			return nil, err
+		}
+
+		sortLineage(records, node)
+		return records, nil
+	}
+
+	predecessors, err := walk(func(n *LineageNode) []int64 { return n.Supersedes })
+
+	if err != nil {
+		return nil, err
+	}
+
+	successors, err := walk(func(n *LineageNode) []int64 { return n.SupersededBy })
+
+	if err != nil {
+		return nil, err
+	}
+
+	// A record that is both a predecessor and a successor means the chain loops back on itself
+
+	seen := make(map[int64]bool)
+
+	for _, p := range predecessors {
+		seen[node(p).Id] = true
+	}
+
+	for _, s := range successors {
+
+		s_id := node(s).Id
+
+		if seen[s_id] {
+			return nil, fmt.Errorf("Record %d both supersedes and is superseded by %d, %w", s_id, id, ErrLineageCycle)
+		}
+	}
+
+	l := &Lineage[T]{
+		Record:       r,
+		Predecessors: predecessors,
+		Successors:   successors,
+		Branches:     make([]int64, 0, len(branches)),
+	}
+
+	for b_id := range branches {
+		l.Branches = append(l.Branches, b_id)
+	}
+
+	sort.Slice(l.Branches, func(i, j int) bool {
+		return l.Branches[i] < l.Branches[j]
+	})
+
+	return l, nil
+}
+
+// sortLineage sorts 'records' in chronological order, and then by ID. See `CompareDates` for details.
+func sortLineage[T any](records []T, node LineageNodeFunc[T]) {
+
+	sort.SliceStable(records, func(i, j int) bool {
+
+		n_i := node(records[i])
+		n_j := node(records[j])
+
+		c := CompareDates(n_i.Inception, n_i.Cessation, n_j.Inception, n_j.Cessation)
+
+		if c != 0 {
+			return c < 0
+		}
+
+		return n_i.Id < n_j.Id
+	})
+}
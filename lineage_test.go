@@ -0,0 +1,83 @@
+package architecture
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDeriveLineage(t *testing.T) {
+
+	ctx := context.Background()
+
+	nodes := map[int64]*LineageNode{
+		1: &LineageNode{Id: 1, SupersededBy: []int64{2}, Inception: "2000", Cessation: "2005"},
+		2: &LineageNode{Id: 2, Supersedes: []int64{1}, SupersededBy: []int64{3, 4}, Inception: "2005", Cessation: "2010"},
+		3: &LineageNode{Id: 3, Supersedes: []int64{2}, Inception: "2010", Cessation: ".."},
+		4: &LineageNode{Id: 4, Supersedes: []int64{2}, SupersededBy: []int64{5}, Inception: "2010", Cessation: "2015"},
+		// 5 is missing and should be skipped
+	}
+
+	fetch := func(ctx context.Context, id int64) (*LineageNode, bool, error) {
+		n, ok := nodes[id]
+		return n, ok, nil
+	}
+
+	node := func(n *LineageNode) *LineageNode {
+		return n
+	}
+
+	l, err := DeriveLineage(ctx, 2, fetch, node)
+
+	if err != nil {
+		t.Fatalf("Failed to derive lineage, %v", err)
+	}
+
+	ids := make([]int64, 0)
+
+	for _, n := range l.All() {
+		ids = append(ids, n.Id)
+	}
+
+	expected := []int64{1, 2, 4, 3}
+
+	if len(ids) != len(expected) {
+		t.Fatalf("Unexpected lineage, %v", ids)
+	}
+
+	for i, id := range expected {
+
+		if ids[i] != id {
+			t.Fatalf("Unexpected lineage, %v", ids)
+		}
+	}
+
+	if !l.IsBranched() || len(l.Branches) != 1 || l.Branches[0] != 2 {
+		t.Fatalf("Unexpected branches, %v", l.Branches)
+	}
+
+	l, err = DeriveLineage(ctx, 1, fetch, node)
+
+	if err != nil {
+		t.Fatalf("Failed to derive lineage for 1, %v", err)
+	}
+
+	if len(l.Predecessors) != 0 || len(l.Successors) != 3 {
+		t.Fatalf("Unexpected lineage for 1, %d predecessors and %d successors", len(l.Predecessors), len(l.Successors))
+	}
+
+	nodes[3].SupersededBy = []int64{1}
+	nodes[1].Supersedes = []int64{3}
+
+	_, err = DeriveLineage(ctx, 2, fetch, node)
+
+	if !errors.Is(err, ErrLineageCycle) {
+		t.Fatalf("Expected cycle error, got %v", err)
+	}
+
+	_, err = DeriveLineage(ctx, 99, fetch, node)
+
+	if err == nil {
+		t.Fatalf("Expected missing record to fail")
+	}
+}
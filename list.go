@@ -0,0 +1,108 @@
+package architecture
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"log/slog"
+
+	"github.com/sfomuseum/go-edtf/cmp"
+)
+
+// type CurrentFilter describes which records to include, based on their `mz:is_current` flag, when enumerating the records in a lookup.
+type CurrentFilter uint8
+
+// Include records regardless of whether they are current.
+const LIST_ANY_CURRENT CurrentFilter = 0
+
+// Include only records that are marked as current.
+const LIST_CURRENT CurrentFilter = 1
+
+// Include only records that are not marked as current (including records whose status is unknown).
+const LIST_NOT_CURRENT CurrentFilter = 2
+
+// type ListFilter describes which records to include when enumerating the records in a lookup. A nil `ListFilter` includes every record.
+type ListFilter struct {
+	// Which records to include based on whether they are current. Default is `LIST_ANY_CURRENT`.
+	Current CurrentFilter
+	// If not empty only records that were active for this (EDTF) date are included, using the same rules as the date-based finders
+	// in the gates, galleries and terminals packages. No `ResolutionPolicy` is applied.
+	Date string
+}
+
+// ListableLookup is implemented by lookups that can enumerate all of their records.
+type ListableLookup interface {
+	// Codes returns the sorted list of (primary) codes for the records matching a `ListFilter`.
+	Codes(context.Context, *ListFilter) ([]string, error)
+	// All returns an iterator over the records matching a `ListFilter`.
+	All(context.Context, *ListFilter) (iter.Seq[interface{}], error)
+	// Len returns the total number of records in the lookup.
+	Len(context.Context) (int, error)
+}
+
+// Validate returns an error if 'f' can not be used to filter records, for example because its date is not a valid EDTF date.
+func (f *ListFilter) Validate() error {
+
+	if f == nil {
+		return nil
+	}
+
+	switch f.Current {
+	case LIST_ANY_CURRENT, LIST_CURRENT, LIST_NOT_CURRENT:
+		// pass
+	default:
+		return fmt.Errorf("Invalid current filter (%d)", f.Current)
+	}
+
+	if f.Date != "" {
+
+		_, err := NewSpanForDate(f.Date)
+
+		if err != nil {
+			return fmt.Errorf("Invalid date, %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Include reports whether a record with the Who's On First "existential" flag 'is_current' and the (EDTF) inception and cessation dates
+// 'inception' and 'cessation' matches 'f'. Records whose dates can not be compared to the filter's date are excluded.
+func (f *ListFilter) Include(is_current int64, inception string, cessation string) bool {
+
+	if f == nil {
+		return true
+	}
+
+	switch f.Current {
+	case LIST_CURRENT:
+
+		if is_current != 1 {
+			return false
+		}
+
+	case LIST_NOT_CURRENT:
+
+		if is_current == 1 {
+			return false
+		}
+	}
+
+	if f.Date == "" {
+		return true
+	}
+
+	is_between, err := cmp.IsBetween(f.Date, inception, cessation)
+
+	if err != nil {
+		slog.Debug("Failed to determine whether record matches date conditions", "date", f.Date, "inception", inception, "cessation", cessation, "error", err)
+		return false
+	}
+
+	return is_between
+}
+
+// includesAll reports whether 'f' includes every record, in which case records do not need to be read to be filtered.
+func (f *ListFilter) includesAll() bool {
+	return f == nil || (f.Current == LIST_ANY_CURRENT && f.Date == "")
+}
@@ -2,7 +2,11 @@ package architecture
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"net/url"
+	"sort"
+	"strings"
 
 	"github.com/aaronland/go-roster"
 )
@@ -12,6 +16,27 @@ type Lookup interface {
 	Append(context.Context, interface{}) error
 }
 
+// ReloadableLookup is implemented by lookups whose data can be replaced, atomically, at runtime using a URI
+// in the same format as the one used to create the lookup.
+type ReloadableLookup interface {
+	Reload(context.Context, string) error
+}
+
+// MutableLookup is implemented by lookups whose records can be replaced and removed, by Who's On First ID, at runtime.
+type MutableLookup interface {
+	// Update replaces the record with the same Who's On First ID as the record passed in.
+	Update(context.Context, interface{}) error
+	// Remove removes the record with a given Who's On First ID.
+	Remove(context.Context, int64) error
+	// AppendBatch adds multiple records together, replacing any records with the same Who's On First IDs.
+	AppendBatch(context.Context, []interface{}) error
+}
+
+// ExportableLookup is implemented by lookups that can write all of their records in the same format as the precompiled data.
+type ExportableLookup interface {
+	WriteTo(context.Context, io.Writer) error
+}
+
 var lookup_roster roster.Roster
 
 type LookupInitializationFunc func(ctx context.Context, uri string) (Lookup, error)
@@ -43,6 +68,27 @@ func ensureLookupRoster() error {
 	return nil
 }
 
+// Schemes returns the list of schemes that have been registered with `RegisterLookup`.
+func Schemes() []string {
+
+	ctx := context.Background()
+	schemes := []string{}
+
+	err := ensureLookupRoster()
+
+	if err != nil {
+		return schemes
+	}
+
+	for _, dr := range lookup_roster.Drivers(ctx) {
+		scheme := fmt.Sprintf("%s://", strings.ToLower(dr))
+		schemes = append(schemes, scheme)
+	}
+
+	sort.Strings(schemes)
+	return schemes
+}
+
 func NewLookup(ctx context.Context, uri string) (Lookup, error) {
 
 	u, err := url.Parse(uri)
@@ -0,0 +1,154 @@
+package architecture
+
+import (
+	"sync"
+)
+
+// The maximum number of entries in each generation of span_cache.
+const SPAN_CACHE_SIZE int = 4096
+
+// A cache of spans (and the errors for dates that can not be parsed) keyed by their inception and cessation dates since the same pairs
+// of dates are compared over and over again when sorting and many records share the same dates.
+var span_cache = newSpanCache(SPAN_CACHE_SIZE)
+
+type cachedSpanResult struct {
+	span *Span
+	err  error
+}
+
+// spanCache is a two-generation cache bounded to (2 * size) entries. When the current generation is full it becomes the previous
+// generation, replacing (and discarding) the existing previous generation. Entries found in the previous generation are promoted
+// to the current generation so the dates being used most often are retained.
+type spanCache struct {
+	mu       *sync.RWMutex
+	size     int
+	current  map[string]*cachedSpanResult
+	previous map[string]*cachedSpanResult
+}
+
+func newSpanCache(size int) *spanCache {
+
+	c := &spanCache{
+		mu:       new(sync.RWMutex),
+		size:     size,
+		current:  make(map[string]*cachedSpanResult),
+		previous: make(map[string]*cachedSpanResult),
+	}
+
+	return c
+}
+
+func (c *spanCache) Load(key string) (*cachedSpanResult, bool) {
+
+	c.mu.RLock()
+	r, ok := c.current[key]
+	c.mu.RUnlock()
+
+	if ok {
+		return r, true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r, ok = c.previous[key]
+
+	if ok {
+		c.store(key, r)
+	}
+
+	return r, ok
+}
+
+func (c *spanCache) Store(key string, r *cachedSpanResult) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.store(key, r)
+}
+
+func (c *spanCache) Len() int {
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.current) + len(c.previous)
+}
+
+func (c *spanCache) store(key string, r *cachedSpanResult) {
+
+	if len(c.current) >= c.size {
+		c.previous = c.current
+		c.current = make(map[string]*cachedSpanResult)
+	}
+
+	c.current[key] = r
+}
+
+// CompareDates compares the period defined by 'inception_a' and 'cessation_a' with the period defined by 'inception_b' and 'cessation_b'
+// in chronological order, returning -1 if 'a' comes before 'b', 1 if 'a' comes after 'b' and 0 if they can not be distinguished.
+//
+// Periods are ordered by the earliest possible time of their (EDTF) inception dates and then by the latest possible time of their
+// cessation dates, as derived by `go-edtf`, so that "198X" comes before "1985" and "2020-~05" comes before "2020-06". Open (`..`)
+// and unknown (empty) inception dates come before all others and open and unknown cessation dates come after all others. Periods
+// with dates that can not be parsed come after all the periods that can be parsed.
+func CompareDates(inception_a string, cessation_a string, inception_b string, cessation_b string) int {
+
+	span_a, _ := CachedSpan(inception_a, cessation_a)
+	span_b, _ := CachedSpan(inception_b, cessation_b)
+
+	switch {
+	case span_a == nil && span_b == nil:
+		return 0
+	case span_a == nil:
+		return 1
+	case span_b == nil:
+		return -1
+	}
+
+	lower_a := span_a.Lower()
+	lower_b := span_b.Lower()
+
+	if lower_a != lower_b {
+
+		if lower_a < lower_b {
+			return -1
+		}
+
+		return 1
+	}
+
+	upper_a := span_a.Upper()
+	upper_b := span_b.Upper()
+
+	if upper_a != upper_b {
+
+		if upper_a < upper_b {
+			return -1
+		}
+
+		return 1
+	}
+
+	return 0
+}
+
+// CachedSpan returns the same `Span` instance, or error, as `NewSpan` for 'inception' and 'cessation' reusing the result of any previous
+// call for the same pair of dates. Spans returned by CachedSpan are shared and must not be modified. At most (2 * SPAN_CACHE_SIZE) results
+// are retained, after which results that have not been used recently are discarded.
+func CachedSpan(inception string, cessation string) (*Span, error) {
+
+	key := inception + "\x00" + cessation
+
+	r, ok := span_cache.Load(key)
+
+	if ok {
+		return r.span, r.err
+	}
+
+	s, err := NewSpan(inception, cessation)
+
+	span_cache.Store(key, &cachedSpanResult{span: s, err: err})
+	return s, err
+}
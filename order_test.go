@@ -0,0 +1,113 @@
+package architecture
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestCompareDates(t *testing.T) {
+
+	tests := []struct {
+		A        [2]string
+		B        [2]string
+		Expected int
+	}{
+		{[2]string{"2019", "2020"}, [2]string{"2020", "2021"}, -1},
+		{[2]string{"198X", "1990"}, [2]string{"1985", "1990"}, -1},
+		{[2]string{"2020-~05", "2021"}, [2]string{"2020-06", "2021"}, -1},
+		{[2]string{"2020~", "2021"}, [2]string{"2020", "2021"}, 0},
+		{[2]string{"2020", "2021"}, [2]string{"2020", ".."}, -1},
+		{[2]string{"2020", ""}, [2]string{"2020", "2021"}, 1},
+		{[2]string{"..", "2000"}, [2]string{"1900", "2000"}, -1},
+		{[2]string{"", "2000"}, [2]string{"1900", "2000"}, -1},
+		{[2]string{"bogus", "2000"}, [2]string{"2020", ".."}, 1},
+		{[2]string{"2020", ".."}, [2]string{"bogus", "2000"}, -1},
+		{[2]string{"bogus", "2000"}, [2]string{"nonsense", ".."}, 0},
+		{[2]string{"2020", "2021"}, [2]string{"2020", "2021"}, 0},
+	}
+
+	for _, test := range tests {
+
+		c := CompareDates(test.A[0], test.A[1], test.B[0], test.B[1])
+
+		if c != test.Expected {
+			t.Fatalf("Unexpected comparison for %v and %v, expected %d but got %d", test.A, test.B, test.Expected, c)
+		}
+	}
+}
+
+func TestCachedSpan(t *testing.T) {
+
+	a, err := CachedSpan("2020", "2021")
+
+	if err != nil {
+		t.Fatalf("Failed to derive span, %v", err)
+	}
+
+	b, err := CachedSpan("2020", "2021")
+
+	if err != nil {
+		t.Fatalf("Failed to derive cached span, %v", err)
+	}
+
+	if a != b {
+		t.Fatalf("Expected cached span to be reused")
+	}
+
+	for i := 0; i < 2; i++ {
+
+		_, err = CachedSpan("bogus", "2021")
+
+		if err == nil {
+			t.Fatalf("Expected invalid date to fail")
+		}
+	}
+}
+
+func TestSpanCacheSize(t *testing.T) {
+
+	c := newSpanCache(10)
+
+	store := func(start int, end int) {
+
+		for i := start; i < end; i++ {
+			c.Store(strconv.Itoa(i), &cachedSpanResult{})
+		}
+	}
+
+	store(0, 100)
+
+	if c.Len() > 20 {
+		t.Fatalf("Expected span cache to be bounded, %d", c.Len())
+	}
+
+	_, ok := c.Load("0")
+
+	if ok {
+		t.Fatalf("Expected 0 to be discarded")
+	}
+
+	// Move 90-99 to the previous generation and then use 99 so that it is promoted to the current generation
+
+	store(100, 110)
+
+	_, ok = c.Load("99")
+
+	if !ok {
+		t.Fatalf("Expected 99 to be cached")
+	}
+
+	store(110, 119)
+
+	_, ok = c.Load("99")
+
+	if !ok {
+		t.Fatalf("Expected 99 to be retained after being used")
+	}
+
+	_, ok = c.Load("95")
+
+	if ok {
+		t.Fatalf("Expected 95 to be discarded")
+	}
+}
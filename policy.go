@@ -0,0 +1,159 @@
+package architecture
+
+import (
+	"fmt"
+	"strings"
+)
+
+// type ResolutionPolicy is a set of flags describing how to choose between multiple records matching a code for a given date.
+// Preferences (`RESOLVE_PREFER_*`) are tried in the order current, starting on date, ending on date and the first one to match
+// at least one record is used. If there is still more than one record and `RESOLVE_FIRST_MATCH` is set then only the first
+// (earliest) record is kept.
+type ResolutionPolicy uint8
+
+// Return all the records matching a date.
+const RESOLVE_STRICT ResolutionPolicy = 0
+
+// Prefer records that are marked as current.
+const RESOLVE_PREFER_CURRENT ResolutionPolicy = 1
+
+// Prefer records whose inception date is the date being queried.
+const RESOLVE_PREFER_STARTING_ON_DATE ResolutionPolicy = 2
+
+// Prefer records whose cessation date is the date being queried.
+const RESOLVE_PREFER_ENDING_ON_DATE ResolutionPolicy = 4
+
+// Return only the first (earliest) record matching a date.
+const RESOLVE_FIRST_MATCH ResolutionPolicy = 8
+
+var policy_labels = []struct {
+	policy ResolutionPolicy
+	label  string
+}{
+	{RESOLVE_PREFER_CURRENT, "prefer-current"},
+	{RESOLVE_PREFER_STARTING_ON_DATE, "prefer-starting-on-date"},
+	{RESOLVE_PREFER_ENDING_ON_DATE, "prefer-ending-on-date"},
+	{RESOLVE_FIRST_MATCH, "first-match"},
+}
+
+// Has reports whether all the flags in 'other' are set in 'p'.
+func (p ResolutionPolicy) Has(other ResolutionPolicy) bool {
+	return p&other == other
+}
+
+// String returns a comma-separated list of the labels for the flags set in 'p', or "strict" if none are set.
+func (p ResolutionPolicy) String() string {
+
+	labels := make([]string, 0)
+
+	for _, l := range policy_labels {
+
+		if p.Has(l.policy) {
+			labels = append(labels, l.label)
+		}
+	}
+
+	if len(labels) == 0 {
+		return "strict"
+	}
+
+	return strings.Join(labels, ",")
+}
+
+// ParseResolutionPolicy returns the `ResolutionPolicy` for 'str' which is a comma-separated list of labels as returned by
+// `ResolutionPolicy.String`.
+func ParseResolutionPolicy(str string) (ResolutionPolicy, error) {
+
+	p := RESOLVE_STRICT
+
+	for _, label := range strings.Split(str, ",") {
+
+		label = strings.TrimSpace(label)
+
+		if label == "strict" {
+			continue
+		}
+
+		found := false
+
+		for _, l := range policy_labels {
+
+			if l.label == label {
+				p = p | l.policy
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return p, fmt.Errorf("Invalid resolution policy '%s'", label)
+		}
+	}
+
+	return p, nil
+}
+
+// type ResolutionCandidate is a struct containing the properties of a record used by `ApplyResolutionPolicy`.
+type ResolutionCandidate struct {
+	// A Who's On First "existential" (`KnownUnknownFlag`) flag signaling the record's status
+	IsCurrent int64
+	// The (EDTF) inception date for the record.
+	Inception string
+	// The (EDTF) cessation date for the record.
+	Cessation string
+}
+
+// ResolutionCandidateFunc is a function that returns the `ResolutionCandidate` for a record.
+type ResolutionCandidateFunc[T any] func(T) *ResolutionCandidate
+
+// ApplyResolutionPolicy returns the subset of 'records', all of which are assumed to match 'date' and to be sorted in chronological order,
+// chosen by 'policy'.
+func ApplyResolutionPolicy[T any](policy ResolutionPolicy, date string, records []T, candidate ResolutionCandidateFunc[T]) []T {
+
+	if len(records) < 2 {
+		return records
+	}
+
+	filter := func(match func(*ResolutionCandidate) bool) []T {
+
+		filtered := make([]T, 0)
+
+		for _, r := range records {
+
+			if match(candidate(r)) {
+				filtered = append(filtered, r)
+			}
+		}
+
+		return filtered
+	}
+
+	preferences := []struct {
+		policy ResolutionPolicy
+		match  func(*ResolutionCandidate) bool
+	}{
+		{RESOLVE_PREFER_CURRENT, func(c *ResolutionCandidate) bool { return c.IsCurrent == 1 }},
+		{RESOLVE_PREFER_STARTING_ON_DATE, func(c *ResolutionCandidate) bool { return c.Inception == date }},
+		{RESOLVE_PREFER_ENDING_ON_DATE, func(c *ResolutionCandidate) bool { return c.Cessation == date }},
+	}
+
+	for _, pref := range preferences {
+
+		if !policy.Has(pref.policy) {
+			continue
+		}
+
+		filtered := filter(pref.match)
+
+		if len(filtered) > 0 {
+			records = filtered
+			break
+		}
+	}
+
+	if len(records) > 1 && policy.Has(RESOLVE_FIRST_MATCH) {
+		records = records[0:1]
+	}
+
+	return records
+}
@@ -0,0 +1,86 @@
+package architecture
+
+import (
+	"testing"
+)
+
+func TestApplyResolutionPolicy(t *testing.T) {
+
+	records := []*ResolutionCandidate{
+		&ResolutionCandidate{IsCurrent: 0, Inception: "2020", Cessation: "2021-05-25"},
+		&ResolutionCandidate{IsCurrent: 0, Inception: "2021-05-25", Cessation: "2022"},
+		&ResolutionCandidate{IsCurrent: 1, Inception: "2021", Cessation: ".."},
+	}
+
+	candidate := func(c *ResolutionCandidate) *ResolutionCandidate {
+		return c
+	}
+
+	date := "2021-05-25"
+
+	tests := map[ResolutionPolicy][]int{
+		RESOLVE_STRICT:                  []int{0, 1, 2},
+		RESOLVE_FIRST_MATCH:             []int{0},
+		RESOLVE_PREFER_CURRENT:          []int{2},
+		RESOLVE_PREFER_STARTING_ON_DATE: []int{1},
+		RESOLVE_PREFER_ENDING_ON_DATE:   []int{0},
+		RESOLVE_PREFER_STARTING_ON_DATE | RESOLVE_PREFER_ENDING_ON_DATE: []int{1},
+		RESOLVE_PREFER_CURRENT | RESOLVE_PREFER_STARTING_ON_DATE:        []int{2},
+	}
+
+	for policy, expected := range tests {
+
+		rsp := ApplyResolutionPolicy(policy, date, records, candidate)
+
+		if len(rsp) != len(expected) {
+			t.Fatalf("Unexpected results for policy '%s', expected %d but got %d", policy, len(expected), len(rsp))
+		}
+
+		for i, idx := range expected {
+
+			if rsp[i] != records[idx] {
+				t.Fatalf("Unexpected result at position %d for policy '%s'", i, policy)
+			}
+		}
+	}
+
+	// No preference matches so fall back to first match
+
+	rsp := ApplyResolutionPolicy(RESOLVE_PREFER_CURRENT|RESOLVE_FIRST_MATCH, date, records[0:2], candidate)
+
+	if len(rsp) != 1 || rsp[0] != records[0] {
+		t.Fatalf("Unexpected results for prefer current, first match policy")
+	}
+}
+
+func TestParseResolutionPolicy(t *testing.T) {
+
+	tests := map[string]ResolutionPolicy{
+		"strict":                                 RESOLVE_STRICT,
+		"first-match":                            RESOLVE_FIRST_MATCH,
+		"prefer-current,prefer-starting-on-date": RESOLVE_PREFER_CURRENT | RESOLVE_PREFER_STARTING_ON_DATE,
+	}
+
+	for str, expected := range tests {
+
+		p, err := ParseResolutionPolicy(str)
+
+		if err != nil {
+			t.Fatalf("Failed to parse '%s', %v", str, err)
+		}
+
+		if p != expected {
+			t.Fatalf("Unexpected policy for '%s', %d", str, p)
+		}
+
+		if p.String() != str {
+			t.Fatalf("Unexpected string for '%s', %s", str, p.String())
+		}
+	}
+
+	_, err := ParseResolutionPolicy("prefer-nothing")
+
+	if err == nil {
+		t.Fatalf("Expected invalid policy to fail")
+	}
+}
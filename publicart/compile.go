@@ -0,0 +1,144 @@
+package publicart
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+	"github.com/tidwall/gjson"
+	"github.com/whosonfirst/go-whosonfirst-feature/properties"
+	"github.com/whosonfirst/go-whosonfirst-iterate/v2/iterator"
+	"github.com/whosonfirst/go-whosonfirst-uri"
+)
+
+// CompilePublicArtData will generate a list of `PublicArt` struct to be used as the source data for an `SFOMuseumLookup` instance.
+// The list of gate are compiled by iterating over one or more source. `iterator_uri` is a valid `whosonfirst/go-whosonfirst-iterate` URI
+// and `iterator_sources` are one more (iterator) URIs to process.
+func CompilePublicArtData(ctx context.Context, iterator_uri string, iterator_sources ...string) ([]*PublicArt, error) {
+
+	lookup := make([]*PublicArt, 0)
+	mu := new(sync.RWMutex)
+
+	iter_cb := func(ctx context.Context, path string, fh io.ReadSeeker, args ...interface{}) error {
+
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+			// pass
+		}
+
+		if strings.HasSuffix(path, "~") {
+			return nil
+		}
+
+		_, uri_args, err := uri.ParseURI(path)
+
+		if err != nil {
+			return fmt.Errorf("Failed to parse %s, %w", path, err)
+		}
+
+		if uri_args.IsAlternate {
+			return nil
+		}
+
+		body, err := io.ReadAll(fh)
+
+		if err != nil {
+			return fmt.Errorf("Failed load feature from %s, %w", path, err)
+		}
+
+		pa, err := newPublicArtFromFeature(body)
+
+		if err != nil {
+			return fmt.Errorf("Failed to derive public art from %s, %w", path, err)
+		}
+
+		mu.Lock()
+		lookup = append(lookup, pa)
+		mu.Unlock()
+
+		return nil
+	}
+
+	iter, err := iterator.NewIterator(ctx, iterator_uri, iter_cb)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create iterator, %w", err)
+	}
+
+	err = iter.IterateURIs(ctx, iterator_sources...)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to iterate sources, %w", err)
+	}
+
+	return lookup, nil
+}
+
+// newPublicArtFromFeature returns a new `PublicArt` instance derived from the Who's On First GeoJSON Feature 'body'.
+func newPublicArtFromFeature(body []byte) (*PublicArt, error) {
+
+	wof_id, err := properties.Id(body)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive ID, %w", err)
+	}
+
+	wof_name, err := properties.Name(body)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive name, %w", err)
+	}
+
+	fl, err := properties.IsCurrent(body)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to determine is current, %w", err)
+	}
+
+	objectid_rsp := gjson.GetBytes(body, "properties.sfomuseum:object_id")
+
+	if !objectid_rsp.Exists() {
+		return nil, fmt.Errorf("Missing sfomuseum:object_id property")
+	}
+
+	mapid_rsp := gjson.GetBytes(body, "properties.sfomuseum:map_id")
+	inception_rsp := gjson.GetBytes(body, "properties.edtf:inception")
+	cessation_rsp := gjson.GetBytes(body, "properties.edtf:cessation")
+
+	parents := architecture.DeriveParents(body)
+
+	pa := &PublicArt{
+		WhosOnFirstId:  wof_id,
+		ObjectId:       objectid_rsp.Int(),
+		MapId:          mapid_rsp.String(),
+		Name:           wof_name,
+		Inception:      inception_rsp.String(),
+		Cessation:      cessation_rsp.String(),
+		IsCurrent:      fl.Flag(),
+		ParentId:       parents.ParentId,
+		BoardingAreaId: parents.BoardingAreaId,
+		TerminalId:     parents.TerminalId,
+		Supersedes:     properties.Supersedes(body),
+		SupersededBy:   properties.SupersededBy(body),
+		Deprecated:     properties.Deprecated(body),
+	}
+
+	return pa, nil
+}
+
+// newPublicArtRecord derives a `PublicArt` record from 'body' for use as an `architecture.FeatureRecordFunc`.
+func newPublicArtRecord(ctx context.Context, body []byte) (interface{}, error) {
+
+	r, err := newPublicArtFromFeature(body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
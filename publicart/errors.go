@@ -0,0 +1,95 @@
+package publicart
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+)
+
+// type NotFound is the error returned when no public art matching a query can be found. It matches `architecture.ErrNotFound` using `errors.Is`.
+type NotFound struct {
+	// The code that was queried.
+	Code string
+	// The (EDTF) date that was queried, if any.
+	Date string
+	// The reason no public art was found. One of the `architecture.REASON_*` constants.
+	Reason string
+}
+
+func (e NotFound) Error() string {
+
+	msg := fmt.Sprintf("Public art '%s' not found", e.Code)
+
+	if e.Date != "" {
+		msg = fmt.Sprintf("%s for date '%s'", msg, e.Date)
+	}
+
+	if e.Reason != "" {
+		msg = fmt.Sprintf("%s (%s)", msg, e.Reason)
+	}
+
+	return msg
+}
+
+func (e NotFound) String() string {
+	return e.Error()
+}
+
+func (e NotFound) Is(target error) bool {
+	return target == architecture.ErrNotFound
+}
+
+// type MultipleCandidates is the error returned when more than one public art matches a query that expects a single result. It matches
+// `architecture.ErrMultipleCandidates` using `errors.Is`.
+type MultipleCandidates struct {
+	// The code that was queried.
+	Code string
+	// The (EDTF) date that was queried, if any.
+	Date string
+	// The reason a single public art could not be chosen. One of the `architecture.REASON_*` constants.
+	Reason string
+	// The public art matching the query.
+	Candidates []*PublicArt
+}
+
+func (e MultipleCandidates) Error() string {
+
+	msg := fmt.Sprintf("Multiple candidates for public art '%s'", e.Code)
+
+	if e.Date != "" {
+		msg = fmt.Sprintf("%s for date '%s'", msg, e.Date)
+	}
+
+	if e.Reason != "" {
+		msg = fmt.Sprintf("%s (%s)", msg, e.Reason)
+	}
+
+	return msg
+}
+
+func (e MultipleCandidates) String() string {
+	return e.Error()
+}
+
+func (e MultipleCandidates) Is(target error) bool {
+	return target == architecture.ErrMultipleCandidates
+}
+
+// IsNotFound reports whether 'e', or any error it wraps, is a `NotFound` error.
+func IsNotFound(e error) bool {
+
+	var nf NotFound
+	var nf_ptr *NotFound
+
+	return errors.As(e, &nf) || errors.As(e, &nf_ptr)
+}
+
+// IsMultipleCandidates reports whether 'e', or any error it wraps, is a `MultipleCandidates` error.
+func IsMultipleCandidates(e error) bool {
+
+	var mc MultipleCandidates
+	var mc_ptr *MultipleCandidates
+
+	return errors.As(e, &mc) || errors.As(e, &mc_ptr)
+}
@@ -0,0 +1,83 @@
+package publicart
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+)
+
+// LocationHistory returns all the `PublicArt` records, in date order, for the (collection) object with ID 'object_id'. This includes the records
+// sharing that object ID as well as any records they supersede, or are superseded by, directly or indirectly. Each record describes where (map ID,
+// parent, boarding area and terminal) the object was installed between its inception and cessation dates.
+func (l *PublicArtLookup) LocationHistory(ctx context.Context, object_id int64) ([]*PublicArt, error) {
+
+	code := strconv.FormatInt(object_id, 10)
+
+	candidates, err := l.Find(ctx, code)
+
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int64]bool)
+	history := make([]*PublicArt, 0)
+
+	add := func(pa *PublicArt) {
+
+		if !seen[pa.WhosOnFirstId] {
+			history = append(history, pa)
+			seen[pa.WhosOnFirstId] = true
+		}
+	}
+
+	for _, pa := range candidates {
+
+		// Object IDs and Who's On First IDs share the same codes in the lookup table
+
+		if pa.ObjectId != object_id {
+			continue
+		}
+
+		if seen[pa.WhosOnFirstId] {
+			continue
+		}
+
+		lineage, err := l.Lineage(ctx, pa.WhosOnFirstId)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to derive lineage for public art %d, %w", pa.WhosOnFirstId, err)
+		}
+
+		for _, p := range lineage.Predecessors {
+			add(p)
+		}
+
+		add(pa)
+
+		for _, s := range lineage.Successors {
+			add(s)
+		}
+	}
+
+	if len(history) == 0 {
+		return nil, NotFound{Code: code, Reason: architecture.REASON_UNKNOWN_CODE}
+	}
+
+	sort.Sort(PublicArtList(history))
+	return history, nil
+}
+
+// LocationHistory returns all the `PublicArt` records, in date order, for the (collection) object with ID 'object_id'. See `PublicArtLookup.LocationHistory` for details.
+func LocationHistory(ctx context.Context, object_id int64) ([]*PublicArt, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.LocationHistory(ctx, object_id)
+}
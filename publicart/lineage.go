@@ -0,0 +1,57 @@
+package publicart
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+)
+
+func lineageNode(pa *PublicArt) *architecture.LineageNode {
+
+	n := &architecture.LineageNode{
+		Id:           pa.WhosOnFirstId,
+		Supersedes:   pa.Supersedes,
+		SupersededBy: pa.SupersededBy,
+		Inception:    pa.Inception,
+		Cessation:    pa.Cessation,
+	}
+
+	return n
+}
+
+// Lineage returns the supersession chain, in date order, for the public art with Who's On First ID 'id'. See `architecture.TableLookup.Lineage` for details.
+func Lineage(ctx context.Context, id int64) (*architecture.Lineage[*PublicArt], error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Lineage(ctx, id)
+}
+
+// Predecessors returns all the public art, in date order, that the public art with Who's On First ID 'id' supersedes directly or indirectly.
+func Predecessors(ctx context.Context, id int64) ([]*PublicArt, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Predecessors(ctx, id)
+}
+
+// Successors returns all the public art, in date order, that supersede the public art with Who's On First ID 'id' directly or indirectly.
+func Successors(ctx context.Context, id int64) ([]*PublicArt, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Successors(ctx, id)
+}
@@ -0,0 +1,67 @@
+package publicart
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture/internal/testutil"
+)
+
+func TestSQLitePublicArtLookupObjectId(t *testing.T) {
+
+	ctx := context.Background()
+
+	features := map[string]string{
+		"1000000001.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000001, "wof:name": "Sculpture", "sfomuseum:object_id": 2000000001, "sfomuseum:map_id": "F-01", "sfomuseum:placetype": "publicart", "mz:is_current": 0, "edtf:inception": "2000", "edtf:cessation": "2010"}),
+		"1000000002.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000002, "wof:name": "Sculpture", "sfomuseum:object_id": 2000000001, "sfomuseum:map_id": "G-07", "sfomuseum:placetype": "publicart", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+		// Public art without a map ID is coded by its object ID
+		"1000000003.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000003, "wof:name": "Mural", "sfomuseum:object_id": 2000000002, "sfomuseum:placetype": "publicart", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+	}
+
+	dsn := testutil.NewSQLiteDatabase(t, features)
+
+	lu, err := NewSQLitePublicArtLookup(ctx, "publicart://sqlite?dsn="+url.QueryEscape(dsn))
+
+	if err != nil {
+		t.Fatalf("Failed to create SQLite lookup, %v", err)
+	}
+
+	defer lu.Close()
+
+	tests := map[string][]int64{
+		"2000000001": []int64{1000000001, 1000000002},
+		"G-07":       []int64{1000000002},
+		"2000000002": []int64{1000000003},
+	}
+
+	for code, expected := range tests {
+
+		rsp, err := lu.Find(ctx, code)
+
+		if err != nil {
+			t.Fatalf("Failed to find %s, %v", code, err)
+		}
+
+		ids := make([]int64, len(rsp))
+
+		for i, pa := range rsp {
+			ids[i] = pa.WhosOnFirstId
+		}
+
+		if !slices.Equal(ids, expected) {
+			t.Fatalf("Unexpected results for %s, %v", code, ids)
+		}
+	}
+
+	codes, err := lu.Codes(ctx, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to list codes, %v", err)
+	}
+
+	if !slices.Equal(codes, []string{"2000000002", "F-01", "G-07"}) {
+		t.Fatalf("Unexpected codes, %v", codes)
+	}
+}
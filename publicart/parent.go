@@ -0,0 +1,20 @@
+package publicart
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+	"github.com/sfomuseum/go-sfomuseum-architecture/terminals"
+)
+
+// Terminal returns the terminal that the public art belonged to, for the period the public art existed, using 'lookup'.
+// See `terminals.FindTerminalForParentWithLookup` for details.
+func (pa *PublicArt) Terminal(ctx context.Context, lookup architecture.TypedLookup[*terminals.Terminal]) (*terminals.Terminal, error) {
+
+	if pa.TerminalId <= 0 {
+		return nil, fmt.Errorf("PublicArt %d does not have a terminal ID", pa.WhosOnFirstId)
+	}
+
+	return terminals.FindTerminalForParentWithLookup(ctx, lookup, pa.TerminalId, pa.Inception, pa.Cessation)
+}
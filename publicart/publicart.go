@@ -0,0 +1,376 @@
+// package publicart provides methods for working with public art works at SFO.
+package publicart
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"log/slog"
+	"strconv"
+
+	"github.com/paulmach/orb/geojson"
+	"github.com/sfomuseum/go-edtf/cmp"
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+)
+
+// The default `architecture.ResolutionPolicy` used by the date-based finders which is to prefer public art records that are marked as current or, failing that,
+// records whose inception date matches the date being queried.
+//
+// When a work is moved, or relabeled, the cessation date of the record for its old location is typically the same as the inception date of the
+// record for its new location. Both records match a query for that date so the one considered to be "current" is preferred. If neither is current
+// then precedence is given to the record whose inception date matches the date being queried against. See the `galleries` package for a worked example.
+var DEFAULT_RESOLUTION_POLICY architecture.ResolutionPolicy = architecture.RESOLVE_PREFER_CURRENT | architecture.RESOLVE_PREFER_STARTING_ON_DATE
+
+// type PublicArtList is a list of `PublicArt` records that can be sorted in chronological order. See `architecture.CompareDates` for details.
+// Records whose dates can not be distinguished are sorted by their Who's On First IDs.
+type PublicArtList []*PublicArt
+
+func (c PublicArtList) Len() int {
+	return len(c)
+}
+
+func (c PublicArtList) Less(i, j int) bool {
+
+	cmp := architecture.CompareDates(c[i].Inception, c[i].Cessation, c[j].Inception, c[j].Cessation)
+
+	if cmp != 0 {
+		return cmp < 0
+	}
+
+	return c[i].WhosOnFirstId < c[j].WhosOnFirstId
+}
+
+func (c PublicArtList) Swap(i, j int) {
+	c[i], c[j] = c[j], c[i]
+}
+
+// type PublicArt is a struct representing a public art work installed at SFO.
+type PublicArt struct {
+	// The Who's On First ID associated with this public art.
+	WhosOnFirstId int64 `json:"wof:id"`
+	// The SFO Museum (collection) object ID of the public art. An object keeps the same ID as it is moved, and superseded, over time.
+	ObjectId int64 `json:"sfomuseum:object_id"`
+	// The map label (ID) associated with the location of this public art.
+	MapId string `json:"map_id"`
+	// The name of this public art.
+	Name string `json:"wof:name"`
+	// The (EDTF) inception date for the public art
+	Inception string `json:"edtf:inception"`
+	// The (EDTF) cessation date for the public art
+	Cessation string `json:"edtf:cessation"`
+	// A Who's On First "existential" (`KnownUnknownFlag`) flag signaling the public art's status
+	IsCurrent int64 `json:"mz:is_current"`
+	// The Who's On First ID of the public art's parent (typically a boarding area).
+	ParentId int64 `json:"wof:parent_id,omitempty"`
+	// The Who's On First ID of the boarding area the public art belongs to.
+	BoardingAreaId int64 `json:"boardingarea_id,omitempty"`
+	// The Who's On First ID of the terminal the public art belongs to.
+	TerminalId int64 `json:"terminal_id,omitempty"`
+	// The list of Who's On First IDs that this public art supersedes.
+	Supersedes []int64 `json:"wof:supersedes,omitempty"`
+	// The list of Who's On First IDs that this public art is superseded by.
+	SupersededBy []int64 `json:"wof:superseded_by,omitempty"`
+	// The (EDTF) deprecated date for the public art, if it has been deprecated.
+	Deprecated string `json:"edtf:deprecated,omitempty"`
+	// The Who's On First GeoJSON Feature for the public art, including its geometry and all of its properties. It is only populated by
+	// lookups that load records on demand, for example `ReaderPublicArtLookup`, and is never encoded.
+	Feature *geojson.Feature `json:"-"`
+}
+
+// String() will return the name of the public art.
+func (pa *PublicArt) String() string {
+	return fmt.Sprintf("%d#%d %s %s-%s (%d)", pa.WhosOnFirstId, pa.ObjectId, pa.Name, pa.Inception, pa.Cessation, pa.IsCurrent)
+}
+
+// Code returns the primary code for the public art which is its map ID or, if empty, its object ID.
+func (pa *PublicArt) Code() string {
+
+	if pa.MapId != "" {
+		return pa.MapId
+	}
+
+	return strconv.FormatInt(pa.ObjectId, 10)
+}
+
+// Dates returns the (EDTF) inception and cessation dates for the public art.
+func (pa *PublicArt) Dates() (string, string) {
+	return pa.Inception, pa.Cessation
+}
+
+// Id returns the Who's On First ID for the public art.
+func (pa *PublicArt) Id() int64 {
+	return pa.WhosOnFirstId
+}
+
+// Placetype returns the SFO Museum placetype for the public art which is always `PLACETYPE`.
+func (pa *PublicArt) Placetype() string {
+	return PLACETYPE
+}
+
+// Status returns whether the public art is current, superseded or deprecated.
+func (pa *PublicArt) Status() *architecture.Status {
+	return architecture.NewStatus(pa.IsCurrent, pa.SupersededBy, pa.Deprecated)
+}
+
+// Return the PublicArt matching 'code' that was active for 'date'. Multiple matches throw an error.
+func FindPublicArtForDate(ctx context.Context, code string, date string) (*PublicArt, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return FindPublicArtForDateWithTypedLookup(ctx, lookup, code, date)
+}
+
+// Return all the PublicArtList matching 'code' that were active for 'date'.
+func FindAllPublicArtForDate(ctx context.Context, code string, date string) ([]*PublicArt, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return FindAllPublicArtForDateWithTypedLookup(ctx, lookup, code, date)
+}
+
+// Return all the PublicArtList matching 'code' that existed at any point between 'start' and 'end'. See `PublicArtLookup.FindAllForRange` for details.
+func FindAllPublicArtForRange(ctx context.Context, code string, start string, end string) ([]*PublicArt, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.FindAllForRange(ctx, code, start, end)
+}
+
+// SnapshotForDate returns a snapshot of all the PublicArtList that were active on 'date' keyed by their codes. See `PublicArtLookup.SnapshotForDate` for details.
+func SnapshotForDate(ctx context.Context, date string) (*architecture.Snapshot[*PublicArt], error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.SnapshotForDate(ctx, date)
+}
+
+// Suggest returns a ranked list of public art codes that are similar to 'code'. See `PublicArtLookup.Suggest` for details.
+func Suggest(ctx context.Context, code string) ([]*architecture.Suggestion, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Suggest(ctx, code)
+}
+
+// Codes returns the sorted list of (primary) codes for all the PublicArtList matching 'filter'. See `PublicArtLookup.Codes` for details.
+func Codes(ctx context.Context, filter *architecture.ListFilter) ([]string, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Codes(ctx, filter)
+}
+
+// All returns an iterator over all the PublicArtList matching 'filter' in chronological order. See `PublicArtLookup.All` for details.
+func All(ctx context.Context, filter *architecture.ListFilter) (iter.Seq[*PublicArt], error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.All(ctx, filter)
+}
+
+// Len returns the total number of PublicArtList.
+func Len(ctx context.Context) (int, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return 0, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Len(ctx)
+}
+
+// Return the current PublicArt matching 'code'. Multiple matches throw an error.
+func FindCurrentPublicArt(ctx context.Context, code string) (*PublicArt, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return FindCurrentPublicArtWithTypedLookup(ctx, lookup, code)
+}
+
+// Return the current PublicArt matching 'code' with a custom architecture.Lookup instance. Multiple matches throw an error.
+func FindCurrentPublicArtWithLookup(ctx context.Context, lookup architecture.Lookup, code string) (*PublicArt, error) {
+	return FindCurrentPublicArtWithTypedLookup(ctx, architecture.NewTypedLookup[*PublicArt](lookup), code)
+}
+
+// FindCurrentPublicArtWithTypedLookup is the same as `FindCurrentPublicArtWithLookup` but with a custom architecture.TypedLookup[*PublicArt] instance.
+func FindCurrentPublicArtWithTypedLookup(ctx context.Context, lookup architecture.TypedLookup[*PublicArt], code string) (*PublicArt, error) {
+
+	current, err := FindPublicArtCurrentWithTypedLookup(ctx, lookup, code)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(current) {
+	case 0:
+		return nil, NotFound{Code: code, Reason: architecture.REASON_NO_CURRENT}
+	case 1:
+		return current[0], nil
+	default:
+		return nil, MultipleCandidates{Code: code, Reason: architecture.REASON_MULTIPLE_CURRENT, Candidates: current}
+	}
+
+}
+
+// Returns all PublicArt instances matching 'code' that are marked as current.
+func FindPublicArtCurrent(ctx context.Context, code string) ([]*PublicArt, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return FindPublicArtCurrentWithTypedLookup(ctx, lookup, code)
+}
+
+// Returns all PublicArt instances matching 'code' that are marked as current with a custom architecture.Lookup instance.
+func FindPublicArtCurrentWithLookup(ctx context.Context, lookup architecture.Lookup, code string) ([]*PublicArt, error) {
+	return FindPublicArtCurrentWithTypedLookup(ctx, architecture.NewTypedLookup[*PublicArt](lookup), code)
+}
+
+// FindPublicArtCurrentWithTypedLookup is the same as `FindPublicArtCurrentWithLookup` but with a custom architecture.TypedLookup[*PublicArt] instance.
+func FindPublicArtCurrentWithTypedLookup(ctx context.Context, lookup architecture.TypedLookup[*PublicArt], code string) ([]*PublicArt, error) {
+
+	rsp, err := lookup.Find(ctx, code)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find %s, %w", code, err)
+	}
+
+	current := make([]*PublicArt, 0)
+
+	for _, pa := range rsp {
+
+		// if pa.IsCurrent == 0 {
+		if pa.IsCurrent != 1 {
+			continue
+		}
+
+		current = append(current, pa)
+	}
+
+	return current, nil
+}
+
+// Return the PublicArt matching 'code' that was active for 'date' using the architecture.Lookup instance 'lookup' and `DEFAULT_RESOLUTION_POLICY`. Multiple matches throw an error.
+func FindPublicArtForDateWithLookup(ctx context.Context, lookup architecture.Lookup, code string, date string) (*PublicArt, error) {
+	return FindPublicArtForDateWithTypedLookup(ctx, architecture.NewTypedLookup[*PublicArt](lookup), code, date)
+}
+
+// FindPublicArtForDateWithTypedLookup is the same as `FindPublicArtForDateWithLookup` but with a custom architecture.TypedLookup[*PublicArt] instance.
+func FindPublicArtForDateWithTypedLookup(ctx context.Context, lookup architecture.TypedLookup[*PublicArt], code string, date string) (*PublicArt, error) {
+	return FindPublicArtForDateWithPolicy(ctx, lookup, code, date, DEFAULT_RESOLUTION_POLICY)
+}
+
+// Return the PublicArt matching 'code' that was active for 'date' using 'lookup' and 'policy'. Multiple matches throw an error.
+func FindPublicArtForDateWithPolicy(ctx context.Context, lookup architecture.TypedLookup[*PublicArt], code string, date string, policy architecture.ResolutionPolicy) (*PublicArt, error) {
+
+	publicart, err := FindAllPublicArtForDateWithPolicy(ctx, lookup, code, date, policy)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(publicart) {
+	case 0:
+		return nil, NotFound{Code: code, Date: date, Reason: architecture.REASON_NO_DATE_MATCH}
+	case 1:
+		return publicart[0], nil
+	default:
+		return nil, MultipleCandidates{Code: code, Date: date, Reason: architecture.REASON_MULTIPLE_DATE_MATCH, Candidates: publicart}
+	}
+}
+
+// Return all the PublicArtList matching 'code' that were active for 'date' using the architecture.Lookup instance 'lookup' and `DEFAULT_RESOLUTION_POLICY`.
+func FindAllPublicArtForDateWithLookup(ctx context.Context, lookup architecture.Lookup, code string, date string) ([]*PublicArt, error) {
+	return FindAllPublicArtForDateWithTypedLookup(ctx, architecture.NewTypedLookup[*PublicArt](lookup), code, date)
+}
+
+// FindAllPublicArtForDateWithTypedLookup is the same as `FindAllPublicArtForDateWithLookup` but with a custom architecture.TypedLookup[*PublicArt] instance.
+func FindAllPublicArtForDateWithTypedLookup(ctx context.Context, lookup architecture.TypedLookup[*PublicArt], code string, date string) ([]*PublicArt, error) {
+	return FindAllPublicArtForDateWithPolicy(ctx, lookup, code, date, DEFAULT_RESOLUTION_POLICY)
+}
+
+// Return all the PublicArtList matching 'code' that were active for 'date' using 'lookup', choosing between multiple matches using 'policy'.
+// See `architecture.ResolutionPolicy` for details.
+func FindAllPublicArtForDateWithPolicy(ctx context.Context, lookup architecture.TypedLookup[*PublicArt], code string, date string, policy architecture.ResolutionPolicy) ([]*PublicArt, error) {
+
+	rsp, err := lookup.Find(ctx, code)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find public art for code, %w", err)
+	}
+
+	publicart := make([]*PublicArt, 0)
+
+	for _, pa := range rsp {
+
+		inception := pa.Inception
+		cessation := pa.Cessation
+
+		is_between, err := cmp.IsBetween(date, inception, cessation)
+
+		if err != nil {
+			slog.Debug("Failed to determine whether public art matches date conditions", "code", code, "date", date, "public art", pa.Name, "inception", inception, "cessation", cessation, "error", err)
+			continue
+		}
+
+		if !is_between {
+			slog.Debug("Public art does not match date conditions", "id", pa.WhosOnFirstId, "code", code, "date", date, "public art", pa.Name, "inception", inception, "cessation", cessation)
+			continue
+		}
+
+		slog.Debug("Public art DOES match date conditions", "id", pa.WhosOnFirstId, "code", code, "date", date, "public art", pa.Name, "inception", inception, "cessation", cessation)
+		publicart = append(publicart, pa)
+	}
+
+	publicart = architecture.ApplyResolutionPolicy(policy, date, publicart, resolutionCandidate)
+
+	slog.Debug("Return publicart", "code", code, "date", date, "policy", policy, "count", len(publicart))
+	return publicart, nil
+}
+
+func resolutionCandidate(pa *PublicArt) *architecture.ResolutionCandidate {
+
+	c := &architecture.ResolutionCandidate{
+		IsCurrent: pa.IsCurrent,
+		Inception: pa.Inception,
+		Cessation: pa.Cessation,
+	}
+
+	return c
+}
@@ -0,0 +1,225 @@
+package publicart
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture/internal/testutil"
+)
+
+func TestPublicArtLookupEmbedded(t *testing.T) {
+
+	ctx := context.Background()
+
+	lu, err := NewPublicArtLookup(ctx, "publicart://")
+
+	if err != nil {
+		t.Fatalf("Failed to create embedded lookup, %v", err)
+	}
+
+	count, err := lu.Len(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to count public art in embedded data, %v", err)
+	}
+
+	if count == 0 {
+		t.Fatalf("Embedded public art data is empty, it needs to be compiled with 'make compile-publicart'")
+	}
+
+	all, err := lu.All(ctx, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to list public art in embedded data, %v", err)
+	}
+
+	for pa := range all {
+
+		rsp, err := lu.Find(ctx, pa.Code())
+
+		if err != nil {
+			t.Fatalf("Failed to find public art %d by its code (%s), %v", pa.WhosOnFirstId, pa.Code(), err)
+		}
+
+		found := false
+
+		for _, r := range rsp {
+
+			if r.WhosOnFirstId == pa.WhosOnFirstId {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			t.Fatalf("Expected to find public art %d by its code (%s)", pa.WhosOnFirstId, pa.Code())
+		}
+	}
+}
+
+func TestFindPublicArtWithLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	publicart_list := []*PublicArt{
+		&PublicArt{WhosOnFirstId: 1000000001, ObjectId: 2000000001, MapId: "F-01", Name: "Sculpture", IsCurrent: 0, Inception: "2000", Cessation: "2010", SupersededBy: []int64{1000000002}},
+		&PublicArt{WhosOnFirstId: 1000000002, ObjectId: 2000000001, MapId: "G-07", Name: "Sculpture", IsCurrent: 0, Inception: "2010", Cessation: "2015", Supersedes: []int64{1000000001}, SupersededBy: []int64{1000000003}},
+		&PublicArt{WhosOnFirstId: 1000000003, ObjectId: 2000000001, MapId: "F-01", Name: "Sculpture", IsCurrent: 1, Inception: "2015", Cessation: "..", Supersedes: []int64{1000000002}},
+		&PublicArt{WhosOnFirstId: 1000000004, ObjectId: 2000000002, MapId: "G-07", Name: "Mural", IsCurrent: 1, Inception: "2015", Cessation: ".."},
+	}
+
+	lookup, err := NewPublicArtLookupWithLookupFunc(ctx, NewLookupFuncWithPublicArt(ctx, publicart_list))
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	for _, code := range []string{"F-01", "f01"} {
+
+		pa, err := FindCurrentPublicArtWithTypedLookup(ctx, lookup, code)
+
+		if err != nil {
+			t.Fatalf("Failed to find current public art for %s, %v", code, err)
+		}
+
+		if pa.WhosOnFirstId != 1000000003 {
+			t.Fatalf("Unexpected current public art for %s, %d", code, pa.WhosOnFirstId)
+		}
+	}
+
+	tests := map[string]int64{
+		"2005-06-01": 1000000001,
+		"2012-06-01": 1000000002,
+		"2020-06-01": 1000000004,
+	}
+
+	for date, expected := range tests {
+
+		pa, err := FindPublicArtForDateWithTypedLookup(ctx, lookup, "G-07", date)
+
+		if date == "2005-06-01" {
+
+			if !IsNotFound(err) {
+				t.Fatalf("Expected no public art at G-07 for %s, got %v", date, err)
+			}
+
+			pa, err = FindPublicArtForDateWithTypedLookup(ctx, lookup, "F-01", date)
+		}
+
+		if err != nil {
+			t.Fatalf("Failed to find public art for %s, %v", date, err)
+		}
+
+		if pa.WhosOnFirstId != expected {
+			t.Fatalf("Unexpected public art for %s, %d", date, pa.WhosOnFirstId)
+		}
+	}
+
+	rsp, err := lookup.Find(ctx, "2000000002")
+
+	if err != nil {
+		t.Fatalf("Failed to find public art by object ID, %v", err)
+	}
+
+	if len(rsp) != 1 || rsp[0].WhosOnFirstId != 1000000004 {
+		t.Fatalf("Unexpected results for object ID, %v", rsp)
+	}
+}
+
+func TestPublicArtLocationHistory(t *testing.T) {
+
+	ctx := context.Background()
+
+	publicart_list := []*PublicArt{
+		&PublicArt{WhosOnFirstId: 1000000003, ObjectId: 2000000001, MapId: "F-01", IsCurrent: 1, Inception: "2015", Cessation: "..", Supersedes: []int64{1000000002}},
+		&PublicArt{WhosOnFirstId: 1000000001, ObjectId: 2000000001, MapId: "F-01", IsCurrent: 0, Inception: "2000", Cessation: "2010", SupersededBy: []int64{1000000002}},
+		// A record, superseded as part of a move, that was not assigned an object ID
+		&PublicArt{WhosOnFirstId: 1000000002, MapId: "G-07", IsCurrent: 0, Inception: "2010", Cessation: "2015", Supersedes: []int64{1000000001}, SupersededBy: []int64{1000000003}},
+		&PublicArt{WhosOnFirstId: 1000000004, ObjectId: 2000000002, MapId: "G-07", IsCurrent: 1, Inception: "2015", Cessation: ".."},
+	}
+
+	lookup, err := NewPublicArtLookupWithLookupFunc(ctx, NewLookupFuncWithPublicArt(ctx, publicart_list))
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	history, err := lookup.LocationHistory(ctx, 2000000001)
+
+	if err != nil {
+		t.Fatalf("Failed to derive location history, %v", err)
+	}
+
+	expected := []int64{1000000001, 1000000002, 1000000003}
+
+	if len(history) != len(expected) {
+		t.Fatalf("Unexpected location history, %v", history)
+	}
+
+	for i, pa := range history {
+
+		if pa.WhosOnFirstId != expected[i] {
+			t.Fatalf("Unexpected record at position %d of location history, %d", i, pa.WhosOnFirstId)
+		}
+	}
+
+	// 1000000004 is a Who's On First ID, not an object ID
+
+	for _, id := range []int64{1000000004, 2000000099} {
+
+		_, err = lookup.LocationHistory(ctx, id)
+
+		if !IsNotFound(err) {
+			t.Fatalf("Expected no location history for %d, got %v", id, err)
+		}
+	}
+}
+
+func TestCompilePublicArtData(t *testing.T) {
+
+	ctx := context.Background()
+
+	features := map[string]string{
+		"1000000001.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000001, "wof:name": "Sculpture", "sfomuseum:object_id": 2000000001, "sfomuseum:map_id": "F-01", "sfomuseum:placetype": "publicart", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+		"1000000002.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000002, "wof:name": "Mural", "sfomuseum:object_id": 2000000002, "sfomuseum:placetype": "publicart", "mz:is_current": 0, "edtf:inception": "2000", "edtf:cessation": "2010"}),
+	}
+
+	root := testutil.NewDirectoryData(t, features)
+
+	q := url.Values{}
+	q.Set("uri", "directory://")
+	q.Set("source", root)
+
+	lu, err := NewPublicArtLookup(ctx, "publicart://iterator?"+q.Encode())
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup from iterator, %v", err)
+	}
+
+	rsp, err := lu.Find(ctx, "F-01")
+
+	if err != nil {
+		t.Fatalf("Failed to find F-01, %v", err)
+	}
+
+	if len(rsp) != 1 || rsp[0].WhosOnFirstId != 1000000001 || rsp[0].ObjectId != 2000000001 {
+		t.Fatalf("Unexpected results for F-01, %v", rsp)
+	}
+
+	pa, err := FindCurrentPublicArtWithTypedLookup(ctx, lu, "2000000002")
+
+	if !IsNotFound(err) {
+		t.Fatalf("Expected no current public art for object ID 2000000002, got %v", pa)
+	}
+
+	count, err := lu.Len(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to count public art, %v", err)
+	}
+
+	if count != 2 {
+		t.Fatalf("Unexpected number of public art records, %d", count)
+	}
+}
@@ -0,0 +1,64 @@
+package publicart
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+	"github.com/whosonfirst/go-reader"
+)
+
+// ReaderPublicArtLookup implements the `architecture.TypedLookup[*PublicArt]` interface for public art whose records are loaded, on demand, from a
+// `whosonfirst/go-reader` instance. Only an index of codes and Who's On First IDs is kept in memory. Records are read the first time
+// they are found and cached after that. Records returned by this lookup have their `Feature` property populated. See `architecture.ReaderLookup`
+// for details.
+type ReaderPublicArtLookup struct {
+	*architecture.ReaderLookup[*PublicArt]
+}
+
+// NewReaderPublicArtLookup will return a `ReaderPublicArtLookup` instance derived from 'uri' which is expected to take the form:
+//
+//	`publicart://reader?reader={READER_URI}&index={INDEX_URI}`
+//
+// Where `{READER_URI}` is a valid `whosonfirst/go-reader` URI and `{INDEX_URI}` is an optional public art lookup URI, in any of the forms
+// that produce an in-memory lookup table (see `NewLookup`), whose records are used to derive the index of codes. If `{INDEX_URI}` is
+// empty the precompiled (embedded) data is used.
+func NewReaderPublicArtLookup(ctx context.Context, uri string) (*ReaderPublicArtLookup, error) {
+
+	r, index_uri, err := architecture.NewReaderWithURI(ctx, uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	lookup_func, err := NewLookupFuncWithURI(ctx, index_uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive index lookup function, %w", err)
+	}
+
+	return NewReaderPublicArtLookupWithLookupFunc(ctx, r, lookup_func)
+}
+
+// NewReaderPublicArtLookupWithLookupFunc will return a `ReaderPublicArtLookup` instance which reads records from 'r' and whose index of codes
+// is derived from the records compiled using `lookup_func`. Those records are discarded once the index has been built.
+func NewReaderPublicArtLookupWithLookupFunc(ctx context.Context, r reader.Reader, lookup_func PublicArtLookupFunc) (*ReaderPublicArtLookup, error) {
+
+	publicart_list, err := lookup_func(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive index, %w", err)
+	}
+
+	reader_lookup, err := architecture.NewReaderLookup(ctx, record_kind, r, publicart_list)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := &ReaderPublicArtLookup{
+		ReaderLookup: reader_lookup,
+	}
+
+	return l, nil
+}
@@ -0,0 +1,74 @@
+package publicart
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+)
+
+// The indices, on the `geojson` table, used by `SQLitePublicArtLookup` in addition to the placetype index and those created by `campus.NewDatabaseWithIterator`.
+var sqlite_indices = map[string]string{
+	"geojson_by_sfomuseum_object_id": sqlite_object_id_expr,
+	"geojson_by_sfomuseum_map_id":    sqlite_map_id_expr,
+}
+
+// The queries used by `SQLitePublicArtLookup` to find public art by its Who's On First ID, object ID or map ID.
+var sqlite_queries = []*architecture.SQLiteCodeQuery{
+	architecture.SQLITE_QUERY_BY_ID,
+	architecture.NewSQLiteCodeQuery("object ID", sqlite_object_id_expr, true),
+	architecture.NewSQLiteCodeQuery("map ID", sqlite_map_id_expr, false),
+}
+
+const sqlite_object_id_expr string = `JSON_EXTRACT(body, '$.properties."sfomuseum:object_id"')`
+
+const sqlite_map_id_expr string = `JSON_EXTRACT(body, '$.properties."sfomuseum:map_id"')`
+
+// The SQL expression that derives the same value as `PublicArt.Code`, the public art's map ID or else its object ID.
+const sqlite_code_expr string = `COALESCE(NULLIF(CAST(JSON_EXTRACT(body, '$.properties."sfomuseum:map_id"') AS TEXT), ''), CAST(JSON_EXTRACT(body, '$.properties."sfomuseum:object_id"') AS INTEGER))`
+
+// SQLitePublicArtLookup implements the `architecture.TypedLookup[*PublicArt]` interface for public art stored in a Who's On First SQLite database,
+// as produced by `campus.NewDatabaseWithIterator`. Records are queried from the database, by their Who's On First ID, object ID or map ID, each time
+// `Find` is called rather than being loaded in to memory ahead of time. The current and date-based finders (for example `FindCurrentPublicArtWithLookup`)
+// filter the records returned by `Find` for a code so they only ever evaluate the handful of records sharing that code. See `architecture.SQLiteLookup`
+// for details.
+type SQLitePublicArtLookup struct {
+	*architecture.SQLiteLookup[*PublicArt]
+}
+
+// NewSQLitePublicArtLookup will return a `SQLitePublicArtLookup` instance derived from 'uri' which is expected to take the form:
+//
+//	`publicart://sqlite?dsn={DSN}`
+//
+// Where `{DSN}` is the data source name of a Who's On First SQLite database. See `architecture.NewSQLiteDatabaseWithURL` for details
+// on the other parameters.
+func NewSQLitePublicArtLookup(ctx context.Context, uri string) (*SQLitePublicArtLookup, error) {
+
+	sqlite_lookup, err := architecture.NewSQLiteLookup(ctx, record_kind, uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := &SQLitePublicArtLookup{
+		SQLiteLookup: sqlite_lookup,
+	}
+
+	return l, nil
+}
+
+// NewSQLitePublicArtLookupWithDatabase will return a `SQLitePublicArtLookup` instance for 'db'. No indices are created.
+func NewSQLitePublicArtLookupWithDatabase(ctx context.Context, db *sql.DB) (*SQLitePublicArtLookup, error) {
+
+	sqlite_lookup, err := architecture.NewSQLiteLookupWithDatabase(ctx, record_kind, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := &SQLitePublicArtLookup{
+		SQLiteLookup: sqlite_lookup,
+	}
+
+	return l, nil
+}
@@ -0,0 +1,226 @@
+package architecture
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"net/url"
+	"slices"
+	"sync"
+
+	"github.com/paulmach/orb/geojson"
+	"github.com/whosonfirst/go-reader"
+	wof_reader "github.com/whosonfirst/go-whosonfirst-reader"
+)
+
+// type ReaderLookup is a lookup for records, of the kind described by a `RecordKind`, which are loaded on demand from a `whosonfirst/go-reader`
+// instance. Only an index of codes and Who's On First IDs is kept in memory. Records are read the first time they are found and cached after
+// that. Records returned by this lookup are assigned their Who's On First GeoJSON Feature using the kind's `WithFeature` function. It is meant
+// to be embedded in the reader-backed lookups for each kind of record, for example `gates.ReaderGatesLookup`.
+type ReaderLookup[T Record] struct {
+	kind   *RecordKind[T]
+	reader reader.Reader
+	index  *CodeIndex
+	cache  *sync.Map
+}
+
+// NewReaderWithURI returns a new `whosonfirst/go-reader` instance derived from 'uri' which is expected to take the form:
+//
+//	`{SCHEME}://reader?reader={READER_URI}&index={INDEX_URI}`
+//
+// Where `{READER_URI}` is a valid `whosonfirst/go-reader` URI. The value of `{INDEX_URI}`, which may be empty, is returned as is for
+// deriving the records used to build the index of codes for a `ReaderLookup` instance.
+func NewReaderWithURI(ctx context.Context, uri string) (reader.Reader, string, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, "", fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	if u.Host != "reader" {
+		return nil, "", fmt.Errorf("Invalid host '%s', expected 'reader'", u.Host)
+	}
+
+	q := u.Query()
+
+	reader_uri := q.Get("reader")
+
+	if reader_uri == "" {
+		return nil, "", fmt.Errorf("Missing ?reader= parameter")
+	}
+
+	r, err := reader.NewReader(ctx, reader_uri)
+
+	if err != nil {
+		return nil, "", fmt.Errorf("Failed to create reader, %w", err)
+	}
+
+	return r, q.Get("index"), nil
+}
+
+// NewReaderLookup returns a new `ReaderLookup` instance which reads records of the kind described by 'kind' from 'r' and whose index of codes
+// is derived from 'records'. 'records' are not retained once the index has been built.
+func NewReaderLookup[T Record](ctx context.Context, kind *RecordKind[T], r reader.Reader, records []T) (*ReaderLookup[T], error) {
+
+	index := NewCodeIndex()
+
+	for _, data := range records {
+		index.Add(data.Id(), kind.Codes(data)...)
+	}
+
+	l := &ReaderLookup[T]{
+		kind:   kind,
+		reader: r,
+		index:  index,
+		cache:  new(sync.Map),
+	}
+
+	return l, nil
+}
+
+// Find returns the list of records matching 'code' in chronological order, reading any records that have not been read already. If there
+// are no records matching 'code' exactly then records whose codes match the normalized value of 'code' are returned. See `NormalizeCode`
+// for details.
+func (l *ReaderLookup[T]) Find(ctx context.Context, code string) ([]T, error) {
+
+	ids, ok := l.index.Find(code)
+
+	if !ok {
+		return nil, l.kind.NotFound(code, REASON_UNKNOWN_CODE)
+	}
+
+	records := make([]T, len(ids))
+
+	for idx, id := range ids {
+
+		r, err := l.load(ctx, id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		records[idx] = r
+	}
+
+	SortRecords(records)
+	return records, nil
+}
+
+// Append adds the codes for 'data' to the index and 'data' itself to the cache so that it is never read.
+func (l *ReaderLookup[T]) Append(ctx context.Context, data T) error {
+
+	l.cache.Store(data.Id(), data)
+	l.index.Add(data.Id(), l.kind.Codes(data)...)
+
+	return nil
+}
+
+// Codes returns the sorted list of (primary) codes, as returned by the `Code` method, for the records matching 'filter'. If 'filter' is
+// nil the codes for all the records are returned. Any records that have not been read already are read to derive the list.
+func (l *ReaderLookup[T]) Codes(ctx context.Context, filter *ListFilter) ([]string, error) {
+
+	records, err := l.list(ctx, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return RecordCodes(records), nil
+}
+
+// All returns an iterator over the records matching 'filter' in chronological order. If 'filter' is nil all the records are included.
+// Any records that have not been read already are read, and filtered, before `All` returns.
+func (l *ReaderLookup[T]) All(ctx context.Context, filter *ListFilter) (iter.Seq[T], error) {
+
+	records, err := l.list(ctx, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.Values(records), nil
+}
+
+// Len returns the total number of records in the index. No records are read.
+func (l *ReaderLookup[T]) Len(ctx context.Context) (int, error) {
+	return len(l.index.Ids()), nil
+}
+
+// WriteTo writes all the records in the index to 'wr' in the same (uncompressed) format as the precompiled data, reading any records
+// that have not been read already. See `TableLookup.WriteTo` for details.
+func (l *ReaderLookup[T]) WriteTo(ctx context.Context, wr io.Writer) error {
+
+	records, err := l.list(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	return WriteRecords(wr, l.kind, records)
+}
+
+func (l *ReaderLookup[T]) list(ctx context.Context, filter *ListFilter) ([]T, error) {
+
+	err := filter.Validate()
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid filter, %w", err)
+	}
+
+	ids := l.index.Ids()
+	records := make([]T, len(ids))
+
+	for idx, id := range ids {
+
+		r, err := l.load(ctx, id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		records[idx] = r
+	}
+
+	return FilterRecords(l.kind, records, filter), nil
+}
+
+func (l *ReaderLookup[T]) load(ctx context.Context, id int64) (T, error) {
+
+	var zero T
+
+	v, ok := l.cache.Load(id)
+
+	if ok {
+		return v.(T), nil
+	}
+
+	body, err := wof_reader.LoadBytes(ctx, l.reader, id)
+
+	if err != nil {
+		return zero, fmt.Errorf("Failed to read %s %d, %w", l.kind.Label, id, err)
+	}
+
+	r, err := l.kind.FromFeature(body)
+
+	if err != nil {
+		return zero, fmt.Errorf("Failed to derive %s %d, %w", l.kind.Label, id, err)
+	}
+
+	if l.kind.WithFeature != nil {
+
+		f, err := geojson.UnmarshalFeature(body)
+
+		if err != nil {
+			return zero, fmt.Errorf("Failed to unmarshal feature for %s %d, %w", l.kind.Label, id, err)
+		}
+
+		l.kind.WithFeature(r, f)
+	}
+
+	// Another caller may have read the same record in the meantime, in which case use theirs so that callers always share one instance
+
+	v, _ = l.cache.LoadOrStore(id, r)
+	return v.(T), nil
+}
@@ -0,0 +1,99 @@
+package architecture
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture/internal/testutil"
+)
+
+func TestReaderLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	features := map[int64]string{
+		1000000001: testutil.Feature(t, map[string]any{"wof:id": 1000000001, "wof:name": "Z1", "sfomuseum:placetype": "gate", "mz:is_current": 0, "edtf:inception": "2000", "edtf:cessation": "2010"}),
+		1000000002: testutil.Feature(t, map[string]any{"wof:id": 1000000002, "wof:name": "Z1", "sfomuseum:placetype": "gate", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+	}
+
+	q := url.Values{}
+	q.Set("reader", testutil.NewReaderData(t, features))
+	q.Set("index", "gates://file?path=gates.json")
+
+	r, index_uri, err := NewReaderWithURI(ctx, "gates://reader?"+q.Encode())
+
+	if err != nil {
+		t.Fatalf("Failed to create reader, %v", err)
+	}
+
+	if index_uri != "gates://file?path=gates.json" {
+		t.Fatalf("Unexpected index URI, %s", index_uri)
+	}
+
+	records := []*tableTestRecord{
+		&tableTestRecord{WhosOnFirstId: 1000000001, Name: "Z1"},
+		&tableTestRecord{WhosOnFirstId: 1000000002, Name: "Z1"},
+		&tableTestRecord{WhosOnFirstId: 1000000003, Name: "Z2"},
+	}
+
+	lu, err := NewReaderLookup(ctx, table_test_kind, r, records)
+
+	if err != nil {
+		t.Fatalf("Failed to create reader lookup, %v", err)
+	}
+
+	rsp, err := lu.Find(ctx, "z-01")
+
+	if err != nil {
+		t.Fatalf("Failed to find Z1, %v", err)
+	}
+
+	if ids := tableTestRecordIds(rsp); !slices.Equal(ids, []int64{1000000001, 1000000002}) {
+		t.Fatalf("Unexpected records for Z1, %v", ids)
+	}
+
+	// Records are read from the reader rather than being the records the index was derived from
+
+	if rsp[1].IsCurrent != 1 || rsp[1].Inception != "2010" {
+		t.Fatalf("Expected record to be read from reader, %v", rsp[1])
+	}
+
+	again, err := lu.Find(ctx, "Z1")
+
+	if err != nil || again[0] != rsp[0] {
+		t.Fatalf("Expected cached record to be returned, %v", err)
+	}
+
+	_, err = lu.Find(ctx, "Z2")
+
+	if err == nil {
+		t.Fatalf("Expected record missing from reader to fail")
+	}
+
+	_, err = lu.Find(ctx, "Z9")
+
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected not found error, got %v", err)
+	}
+
+	err = lu.Append(ctx, &tableTestRecord{WhosOnFirstId: 1000000004, Name: "Z4", IsCurrent: 1})
+
+	if err != nil {
+		t.Fatalf("Failed to append record, %v", err)
+	}
+
+	rsp, err = lu.Find(ctx, "Z4")
+
+	if err != nil || len(rsp) != 1 {
+		t.Fatalf("Failed to find appended record, %v", err)
+	}
+
+	count, err := lu.Len(ctx)
+
+	if err != nil || count != 4 {
+		t.Fatalf("Unexpected count, %d %v", count, err)
+	}
+}
@@ -0,0 +1,141 @@
+package architecture
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+
+	"github.com/paulmach/orb/geojson"
+)
+
+// Record is implemented by the records (for example `*gates.Gate`) stored in the generic `TableLookup`, `ReaderLookup` and `SQLiteLookup` types.
+type Record interface {
+	comparable
+	DatedRecord
+	// Id returns the Who's On First ID for the record.
+	Id() int64
+	// Code returns the primary code for the record.
+	Code() string
+}
+
+// type RecordKind describes a kind of record (for example gates) to the generic `TableLookup`, `ReaderLookup` and `SQLiteLookup` types
+// so that each kind only needs to define the things that make it different from the others.
+type RecordKind[T Record] struct {
+	// The SFO Museum placetype for records of this kind.
+	Placetype string
+	// The (singular) label for records of this kind used in error messages, for example "gate".
+	Label string
+	// Codes returns the list of codes that a record can be found by. Empty codes are ignored.
+	Codes func(T) []string
+	// Candidate returns the properties of a record used to filter and choose between records.
+	Candidate ResolutionCandidateFunc[T]
+	// NotFound returns the error for a record that can not be found. 'reason' is one of the `REASON_*` constants.
+	NotFound func(code string, reason string) error
+	// FromFeature returns a new record derived from a Who's On First GeoJSON Feature.
+	FromFeature func([]byte) (T, error)
+	// WithFeature assigns a Who's On First GeoJSON Feature to a record. It is used by `ReaderLookup` and may be nil.
+	WithFeature func(T, *geojson.Feature)
+	// LineageNode returns the `LineageNode` for a record.
+	LineageNode LineageNodeFunc[T]
+	// The maximum edit distance for codes returned by `TableLookup.Suggest`.
+	SuggestMaxDistance int
+	// The indices, on the `geojson` table, used by `SQLiteLookup` in addition to the placetype index and those created by `campus.NewDatabaseWithIterator`.
+	SQLiteIndices map[string]string
+	// The queries used by `SQLiteLookup` to find records matching a code, in order.
+	SQLiteQueries []*SQLiteCodeQuery
+	// The SQL expression, for the `geojson` table, that derives the same value as a record's `Code` method. It is used by `SQLiteLookup`
+	// to select distinct codes without reading every record and may be empty, in which case every record is read.
+	SQLiteCodeExpr string
+}
+
+// SortRecords sorts 'records' in chronological order. See `CompareDates` for details. Records whose dates can not be distinguished are
+// sorted by their Who's On First IDs.
+func SortRecords[T Record](records []T) {
+
+	sort.SliceStable(records, func(i, j int) bool {
+
+		inception_i, cessation_i := records[i].Dates()
+		inception_j, cessation_j := records[j].Dates()
+
+		cmp := CompareDates(inception_i, cessation_i, inception_j, cessation_j)
+
+		if cmp != 0 {
+			return cmp < 0
+		}
+
+		return records[i].Id() < records[j].Id()
+	})
+}
+
+// FilterRecords returns the list of records in 'records' matching 'filter', sorted in chronological order.
+func FilterRecords[T Record](kind *RecordKind[T], records []T, filter *ListFilter) []T {
+
+	filtered := make([]T, 0)
+
+	for _, r := range records {
+
+		c := kind.Candidate(r)
+
+		if filter.Include(c.IsCurrent, c.Inception, c.Cessation) {
+			filtered = append(filtered, r)
+		}
+	}
+
+	SortRecords(filtered)
+	return filtered
+}
+
+// RecordCodes returns the sorted list of distinct, non-empty, (primary) codes for 'records'.
+func RecordCodes[T Record](records []T) []string {
+
+	codes := make([]string, 0)
+
+	for _, r := range records {
+
+		code := r.Code()
+
+		if code != "" && !slices.Contains(codes, code) {
+			codes = append(codes, code)
+		}
+	}
+
+	sort.Strings(codes)
+	return codes
+}
+
+// WriteRecords writes 'records' to 'wr' in the versioned `DataEnvelope` format, using `EncodeData`, sorted by their Who's On First IDs so the
+// same records always produce the same output.
+func WriteRecords[T Record](wr io.Writer, kind *RecordKind[T], records []T) error {
+
+	sorted := slices.Clone(records)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Id() < sorted[j].Id()
+	})
+
+	err := EncodeData(wr, kind.Placetype, sorted)
+
+	if err != nil {
+		return fmt.Errorf("Failed to encode %s records, %w", kind.Label, err)
+	}
+
+	return nil
+}
+
+// recordIds returns the Who's On First IDs for 'records', ignoring zero (nil) values.
+func recordIds[T Record](records ...T) []int64 {
+
+	var zero T
+
+	ids := make([]int64, 0, len(records))
+
+	for _, r := range records {
+
+		if r != zero {
+			ids = append(ids, r.Id())
+		}
+	}
+
+	return ids
+}
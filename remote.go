@@ -0,0 +1,414 @@
+package architecture
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The default base URL for remote data, used by the `github` lookup URI host.
+const DEFAULT_REMOTE_BASE_URL string = "https://raw.githubusercontent.com/sfomuseum/go-sfomuseum-architecture/main/data"
+
+// The name of the (`sha256sum` formatted) checksum manifest published alongside remote data.
+const CHECKSUM_MANIFEST string = "SHA256SUMS"
+
+// The default timeout for remote data requests.
+const DEFAULT_REMOTE_TIMEOUT time.Duration = 30 * time.Second
+
+// type RemoteSource retrieves precompiled data files from a remote (HTTP) location. Responses are validated against a published
+// checksum manifest and cached locally using ETag and Last-Modified headers. If the remote location can not be reached a previously
+// cached copy, or an optional fallback `fs.FS` instance, is used instead.
+type RemoteSource struct {
+	// The base URL that data files (and the checksum manifest) are resolved against.
+	BaseURL string
+	// The HTTP client used to retrieve data files.
+	Client *http.Client
+	// An optional local directory where data files are cached.
+	CacheDir string
+	// Boolean flag signaling that data files should be verified against the checksum manifest.
+	Verify bool
+	// An optional `fs.FS` instance to read data files from if they can not be retrieved remotely.
+	Fallback fs.FS
+}
+
+type remoteCacheMetadata struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// NewRemoteSource returns a new `RemoteSource` instance for 'base_url' which verifies checksums, does not cache data and has no fallback.
+func NewRemoteSource(base_url string) *RemoteSource {
+
+	s := &RemoteSource{
+		BaseURL: strings.TrimRight(base_url, "/"),
+		Client: &http.Client{
+			Timeout: DEFAULT_REMOTE_TIMEOUT,
+		},
+		Verify: true,
+	}
+
+	return s
+}
+
+// NewRemoteSourceWithURL returns a new `RemoteSource` instance derived from the query parameters in 'u' which is expected to be
+// a lookup URI with a `github` or `remote` host. Valid parameters are:
+//
+// * `url` The base URL to retrieve data from. Required for the `remote` host; defaults to `DEFAULT_REMOTE_BASE_URL` for the `github` host.
+// * `cache` A local directory to cache data in.
+// * `verify` A boolean value signaling whether to verify data against the checksum manifest. Default is true.
+// * `fallback` A boolean value signaling whether to use 'fallback' if data can not be retrieved remotely. Default is false.
+// * `timeout` A duration string (for example "10s") for remote requests. Default is `DEFAULT_REMOTE_TIMEOUT`.
+func NewRemoteSourceWithURL(u *url.URL, fallback fs.FS) (*RemoteSource, error) {
+
+	q := u.Query()
+
+	base_url := q.Get("url")
+
+	if base_url == "" {
+
+		if u.Host != "github" {
+			return nil, fmt.Errorf("Missing ?url= parameter")
+		}
+
+		base_url = DEFAULT_REMOTE_BASE_URL
+	}
+
+	_, err := url.Parse(base_url)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse base URL, %w", err)
+	}
+
+	s := NewRemoteSource(base_url)
+	s.CacheDir = q.Get("cache")
+
+	if q.Has("verify") {
+
+		v, err := strconv.ParseBool(q.Get("verify"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?verify= parameter, %w", err)
+		}
+
+		s.Verify = v
+	}
+
+	if q.Has("fallback") {
+
+		v, err := strconv.ParseBool(q.Get("fallback"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?fallback= parameter, %w", err)
+		}
+
+		if v {
+			s.Fallback = fallback
+		}
+	}
+
+	if q.Has("timeout") {
+
+		d, err := time.ParseDuration(q.Get("timeout"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?timeout= parameter, %w", err)
+		}
+
+		s.Client.Timeout = d
+	}
+
+	return s, nil
+}
+
+// Open returns an `io.ReadCloser` instance for the data file 'filename'.
+func (s *RemoteSource) Open(ctx context.Context, filename string) (io.ReadCloser, error) {
+
+	body, err := s.fetch(ctx, filename)
+
+	if err == nil {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	if s.CacheDir != "" {
+
+		cached, cache_err := os.ReadFile(s.cachePath(filename))
+
+		if cache_err == nil {
+			slog.Warn("Failed to retrieve remote data, using cached copy", "filename", filename, "error", err)
+			return io.NopCloser(bytes.NewReader(cached)), nil
+		}
+	}
+
+	if s.Fallback != nil {
+		slog.Warn("Failed to retrieve remote data, using fallback", "filename", filename, "error", err)
+		return s.Fallback.Open(filename)
+	}
+
+	return nil, err
+}
+
+func (s *RemoteSource) fetch(ctx context.Context, filename string) ([]byte, error) {
+
+	data_url := fmt.Sprintf("%s/%s", s.BaseURL, filename)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, data_url, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create request for %s, %w", data_url, err)
+	}
+
+	var cached []byte
+
+	if s.CacheDir != "" {
+
+		meta, err := s.readCacheMetadata(filename)
+
+		if err == nil {
+
+			cached, err = os.ReadFile(s.cachePath(filename))
+
+			if err == nil {
+
+				if meta.ETag != "" {
+					req.Header.Set("If-None-Match", meta.ETag)
+				}
+
+				if meta.LastModified != "" {
+					req.Header.Set("If-Modified-Since", meta.LastModified)
+				}
+			}
+		}
+	}
+
+	rsp, err := s.Client.Do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to retrieve %s, %w", data_url, err)
+	}
+
+	defer rsp.Body.Close()
+
+	var body []byte
+	modified := true
+
+	switch {
+	case rsp.StatusCode == http.StatusNotModified && cached != nil:
+		body = cached
+		modified = false
+	case rsp.StatusCode == http.StatusOK:
+
+		body, err = io.ReadAll(rsp.Body)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read %s, %w", data_url, err)
+		}
+
+	default:
+		return nil, fmt.Errorf("Unexpected status code (%d) for %s", rsp.StatusCode, data_url)
+	}
+
+	if s.Verify {
+
+		err := s.verify(ctx, filename, body)
+
+		if err != nil {
+
+			// Don't let a cached copy that no longer matches the manifest be used as an offline fallback
+
+			if !modified {
+				os.Remove(s.cachePath(filename))
+				os.Remove(s.cachePath(filename) + ".meta")
+			}
+
+			return nil, err
+		}
+	}
+
+	if s.CacheDir != "" && modified {
+
+		meta := &remoteCacheMetadata{
+			ETag:         rsp.Header.Get("ETag"),
+			LastModified: rsp.Header.Get("Last-Modified"),
+		}
+
+		err := s.writeCache(filename, body, meta)
+
+		if err != nil {
+			slog.Warn("Failed to cache remote data", "filename", filename, "error", err)
+		}
+	}
+
+	return body, nil
+}
+
+func (s *RemoteSource) verify(ctx context.Context, filename string, body []byte) error {
+
+	manifest_url := fmt.Sprintf("%s/%s", s.BaseURL, CHECKSUM_MANIFEST)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, manifest_url, nil)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create request for %s, %w", manifest_url, err)
+	}
+
+	rsp, err := s.Client.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("Failed to retrieve %s, %w", manifest_url, err)
+	}
+
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unexpected status code (%d) for %s", rsp.StatusCode, manifest_url)
+	}
+
+	checksums, err := ReadChecksumManifest(rsp.Body)
+
+	if err != nil {
+		return fmt.Errorf("Failed to read checksum manifest, %w", err)
+	}
+
+	expected, ok := checksums[filename]
+
+	if !ok {
+		return fmt.Errorf("Checksum manifest does not contain %s", filename)
+	}
+
+	h := sha256.Sum256(body)
+	actual := hex.EncodeToString(h[:])
+
+	if actual != expected {
+		return fmt.Errorf("Checksum mismatch for %s, expected %s but got %s", filename, expected, actual)
+	}
+
+	return nil
+}
+
+func (s *RemoteSource) cachePath(filename string) string {
+	return filepath.Join(s.CacheDir, filename)
+}
+
+func (s *RemoteSource) readCacheMetadata(filename string) (*remoteCacheMetadata, error) {
+
+	body, err := os.ReadFile(s.cachePath(filename) + ".meta")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var meta *remoteCacheMetadata
+
+	err = json.Unmarshal(body, &meta)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return meta, nil
+}
+
+func (s *RemoteSource) writeCache(filename string, body []byte, meta *remoteCacheMetadata) error {
+
+	err := os.MkdirAll(s.CacheDir, 0755)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create cache directory, %w", err)
+	}
+
+	enc_meta, err := json.Marshal(meta)
+
+	if err != nil {
+		return fmt.Errorf("Failed to marshal cache metadata, %w", err)
+	}
+
+	err = writeFileAtomic(s.cachePath(filename), body)
+
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.cachePath(filename)+".meta", enc_meta)
+}
+
+func writeFileAtomic(path string, body []byte) error {
+
+	wr, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+
+	if err != nil {
+		return fmt.Errorf("Failed to create temporary file for %s, %w", path, err)
+	}
+
+	_, err = wr.Write(body)
+
+	if err != nil {
+		wr.Close()
+		os.Remove(wr.Name())
+		return fmt.Errorf("Failed to write %s, %w", path, err)
+	}
+
+	err = wr.Close()
+
+	if err != nil {
+		os.Remove(wr.Name())
+		return fmt.Errorf("Failed to close %s, %w", path, err)
+	}
+
+	err = os.Rename(wr.Name(), path)
+
+	if err != nil {
+		os.Remove(wr.Name())
+		return fmt.Errorf("Failed to rename %s, %w", path, err)
+	}
+
+	return nil
+}
+
+// ReadChecksumManifest returns a dictionary of (hex-encoded) SHA-256 checksums keyed by filename read from 'r' which is expected
+// to be in the format produced by the `sha256sum` command.
+func ReadChecksumManifest(r io.Reader) (map[string]string, error) {
+
+	checksums := make(map[string]string)
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.Fields(line)
+
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Invalid line '%s'", line)
+		}
+
+		// sha256sum prefixes filenames with "*" in binary mode
+		checksums[strings.TrimPrefix(parts[1], "*")] = strings.ToLower(parts[0])
+	}
+
+	err := scanner.Err()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return checksums, nil
+}
@@ -0,0 +1,181 @@
+package architecture
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRemoteSource(t *testing.T) {
+
+	ctx := context.Background()
+
+	body := []byte(`[{"wof:id": 1000000001}]`)
+	h := sha256.Sum256(body)
+
+	manifest := fmt.Sprintf("%s  data.json\n", hex.EncodeToString(h[:]))
+	etag := `"v1"`
+
+	var not_modified int32
+	var broken atomic.Bool
+
+	handler := func(rsp http.ResponseWriter, req *http.Request) {
+
+		if broken.Load() {
+			http.Error(rsp, "Service unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		switch req.URL.Path {
+		case "/data/" + CHECKSUM_MANIFEST:
+			rsp.Write([]byte(manifest))
+		case "/data/data.json":
+
+			if req.Header.Get("If-None-Match") == etag {
+				atomic.AddInt32(&not_modified, 1)
+				rsp.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			rsp.Header().Set("ETag", etag)
+			rsp.Write(body)
+		default:
+			http.NotFound(rsp, req)
+		}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(handler))
+	defer srv.Close()
+
+	read := func(s *RemoteSource, filename string) (string, error) {
+
+		r, err := s.Open(ctx, filename)
+
+		if err != nil {
+			return "", err
+		}
+
+		defer r.Close()
+
+		b, err := io.ReadAll(r)
+		return string(b), err
+	}
+
+	cache_dir := t.TempDir()
+
+	u, _ := url.Parse(fmt.Sprintf("gates://remote?url=%s&cache=%s", url.QueryEscape(srv.URL+"/data"), url.QueryEscape(cache_dir)))
+
+	s, err := NewRemoteSourceWithURL(u, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to create remote source, %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+
+		str, err := read(s, "data.json")
+
+		if err != nil {
+			t.Fatalf("Failed to read data (%d), %v", i, err)
+		}
+
+		if str != string(body) {
+			t.Fatalf("Unexpected data (%d), %s", i, str)
+		}
+	}
+
+	if atomic.LoadInt32(&not_modified) != 1 {
+		t.Fatalf("Expected second request to be conditional")
+	}
+
+	_, err = read(s, "missing.json")
+
+	if err == nil {
+		t.Fatalf("Expected missing file to fail")
+	}
+
+	// Offline, with a cached copy
+
+	broken.Store(true)
+
+	str, err := read(s, "data.json")
+
+	if err != nil || str != string(body) {
+		t.Fatalf("Expected cached copy when offline, %v", err)
+	}
+
+	// Offline, without a cached copy
+
+	s.CacheDir = ""
+
+	_, err = read(s, "data.json")
+
+	if err == nil {
+		t.Fatalf("Expected offline request without cache or fallback to fail")
+	}
+
+	s.Fallback = fstest.MapFS{
+		"data.json": &fstest.MapFile{Data: []byte("fallback")},
+	}
+
+	str, err = read(s, "data.json")
+
+	if err != nil || str != "fallback" {
+		t.Fatalf("Expected fallback data when offline, %v", err)
+	}
+
+	// Checksum mismatch
+
+	broken.Store(false)
+	manifest = fmt.Sprintf("%s  data.json\n", hex.EncodeToString(make([]byte, 32)))
+
+	s.Fallback = nil
+
+	_, err = read(s, "data.json")
+
+	if err == nil {
+		t.Fatalf("Expected checksum mismatch to fail")
+	}
+
+	s.Verify = false
+
+	_, err = read(s, "data.json")
+
+	if err != nil {
+		t.Fatalf("Expected unverified request to succeed, %v", err)
+	}
+}
+
+func TestNewRemoteSourceWithURL(t *testing.T) {
+
+	u, _ := url.Parse("gates://github")
+
+	s, err := NewRemoteSourceWithURL(u, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to create github source, %v", err)
+	}
+
+	if s.BaseURL != DEFAULT_REMOTE_BASE_URL || !s.Verify || s.Fallback != nil {
+		t.Fatalf("Unexpected github source, %v", s)
+	}
+
+	for _, uri := range []string{"gates://remote", "gates://github?verify=bunk", "gates://github?timeout=bunk"} {
+
+		u, _ := url.Parse(uri)
+
+		_, err := NewRemoteSourceWithURL(u, nil)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", uri)
+		}
+	}
+}
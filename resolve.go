@@ -0,0 +1,299 @@
+package architecture
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/paulmach/orb/geojson"
+	"github.com/tidwall/gjson"
+	"github.com/whosonfirst/go-whosonfirst-feature/properties"
+)
+
+// type Status describes whether an architectural element is current, superseded or deprecated. Status derived from the precompiled data
+// embedded in each package is incomplete: the tools that compile that data (for example `cmd/compile-gates-data`) exclude deprecated
+// records so `IsDeprecated` is never true for them, and `IsSuperseded` is only reported if the data was compiled with the records'
+// `wof:superseded_by` properties. Use a Who's On First SQLite database (see `ResolveOptions`) when an accurate status is required.
+type Status struct {
+	// Boolean flag signaling that the element is current (its `mz:is_current` property is 1).
+	IsCurrent bool
+	// Boolean flag signaling that the element has been superseded by one or more other elements.
+	IsSuperseded bool
+	// Boolean flag signaling that the element has been deprecated (it has a non-empty `edtf:deprecated` property).
+	IsDeprecated bool
+}
+
+// NewStatus returns a new `Status` instance derived from a Who's On First "existential" (`KnownUnknownFlag`) flag, a list of
+// superseding Who's On First IDs and an (EDTF) deprecated date.
+func NewStatus(is_current int64, superseded_by []int64, deprecated string) *Status {
+
+	s := &Status{
+		IsCurrent:    is_current == 1,
+		IsSuperseded: len(superseded_by) > 0,
+		IsDeprecated: deprecated != "",
+	}
+
+	return s
+}
+
+// NewStatusWithFeature returns a new `Status` instance derived from the Who's On First GeoJSON Feature 'body'.
+func NewStatusWithFeature(body []byte) (*Status, error) {
+
+	fl, err := properties.IsCurrent(body)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to determine is current, %w", err)
+	}
+
+	return NewStatus(fl.Flag(), properties.SupersededBy(body), properties.Deprecated(body)), nil
+}
+
+// ElementRecord is implemented by records for architectural elements that can be resolved by `Resolve`.
+type ElementRecord interface {
+	// Id returns the Who's On First ID of the element.
+	Id() int64
+	// Placetype returns the SFO Museum placetype of the element.
+	Placetype() string
+	// Status returns whether the element is current, superseded or deprecated.
+	Status() *Status
+}
+
+// FeatureRecordFunc is a function that derives a record from a Who's On First GeoJSON Feature.
+type FeatureRecordFunc func(context.Context, []byte) (interface{}, error)
+
+type featureRecordFunc struct {
+	kind string
+	fn   FeatureRecordFunc
+}
+
+var feature_record_funcs = new(sync.Map)
+
+// RegisterFeatureRecordFunc registers 'fn' as the function used by `Resolve` to derive records from features whose SFO Museum placetype
+// is 'placetype'. 'kind' is the kind reported for those records and is expected to be the scheme, without "://", of the lookup for them.
+func RegisterFeatureRecordFunc(ctx context.Context, placetype string, kind string, fn FeatureRecordFunc) error {
+
+	f := &featureRecordFunc{
+		kind: kind,
+		fn:   fn,
+	}
+
+	_, exists := feature_record_funcs.LoadOrStore(placetype, f)
+
+	if exists {
+		return fmt.Errorf("A feature record function for placetype '%s' has already been registered", placetype)
+	}
+
+	return nil
+}
+
+// type Resolution describes the architectural element that a Who's On First ID resolves to.
+type Resolution struct {
+	// The Who's On First ID that was resolved.
+	Id int64
+	// The kind of element. This is the scheme, without "://", of the lookup for the element (for example "gates") or empty if
+	// there is no lookup for the element's placetype.
+	Kind string
+	// The SFO Museum placetype of the element (for example "gate" or "boardingarea").
+	Placetype string
+	// The record for the element. This is a typed record (for example a `*gates.Gate` instance) if there is a lookup, or a
+	// registered `FeatureRecordFunc`, for the element's placetype or a `*geojson.Feature` instance otherwise.
+	Record interface{}
+	// Whether the element is current, superseded or deprecated.
+	Status *Status
+}
+
+// type ResolveOptions is a struct containing configuration options for the `ResolveWithOptions` method.
+type ResolveOptions struct {
+	// An optional `CompositeLookup` instance used to resolve IDs. If nil a default instance, created using `NewCompositeLookup`, is used.
+	Lookup *CompositeLookup
+	// An optional Who's On First SQLite database, as produced by `campus.NewDatabaseWithIterator`. If present it is consulted before
+	// 'Lookup' so that elements without a lookup, like boarding areas, can be resolved and so that the most recent data is used. It is
+	// required to resolve deprecated elements, or to report their status, since they are not included in the precompiled data.
+	Database *sql.DB
+}
+
+var default_composite_lookup *CompositeLookup
+var default_composite_lookup_mu = new(sync.Mutex)
+
+// Resolve returns the `Resolution` for the Who's On First ID 'id' using the default lookups for each registered scheme. An error
+// matching `ErrNotFound` is returned if 'id' can not be resolved. The default lookups read the precompiled data so deprecated elements
+// can not be resolved, and superseded elements may be reported as not superseded, by this method. See `Status` for details.
+func Resolve(ctx context.Context, id int64) (*Resolution, error) {
+	return ResolveWithOptions(ctx, id, nil)
+}
+
+// ResolveWithOptions returns the `Resolution` for the Who's On First ID 'id' using 'opts'. An error matching `ErrNotFound` is returned
+// if 'id' can not be resolved.
+func ResolveWithOptions(ctx context.Context, id int64, opts *ResolveOptions) (*Resolution, error) {
+
+	if opts == nil {
+		opts = &ResolveOptions{}
+	}
+
+	if opts.Database != nil {
+
+		r, err := resolveWithDatabase(ctx, opts.Database, id)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to resolve %d with database, %w", id, err)
+		}
+
+		if r != nil {
+			return r, nil
+		}
+	}
+
+	lookup := opts.Lookup
+
+	if lookup == nil {
+
+		default_lookup, err := defaultCompositeLookup()
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create default lookup, %w", err)
+		}
+
+		lookup = default_lookup
+	}
+
+	r, err := resolveWithLookup(ctx, lookup, id)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to resolve %d with lookup, %w", id, err)
+	}
+
+	if r == nil {
+		return nil, fmt.Errorf("Failed to resolve %d, %w", id, ErrNotFound)
+	}
+
+	return r, nil
+}
+
+// defaultCompositeLookup returns the `CompositeLookup` instance shared by calls to `ResolveWithOptions` that do not specify a lookup.
+// It is created with a background context, rather than the context of the first caller which may be cancelled while it is still
+// being used, and a failure to create it is not retained so that it is attempted again by the next caller.
+func defaultCompositeLookup() (*CompositeLookup, error) {
+
+	default_composite_lookup_mu.Lock()
+	defer default_composite_lookup_mu.Unlock()
+
+	if default_composite_lookup != nil {
+		return default_composite_lookup, nil
+	}
+
+	lookup, err := NewCompositeLookup(context.Background())
+
+	if err != nil {
+		return nil, err
+	}
+
+	default_composite_lookup = lookup
+	return default_composite_lookup, nil
+}
+
+// resolveWithLookup returns the `Resolution` for 'id' using 'lookup', or nil if no lookup has a record for 'id'. All the lookups
+// index records by their Who's On First IDs, as well as their codes, so results are checked to make sure it was the ID that matched.
+func resolveWithLookup(ctx context.Context, lookup *CompositeLookup, id int64) (*Resolution, error) {
+
+	matches, err := lookup.Find(ctx, strconv.FormatInt(id, 10))
+
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range matches {
+
+		el, ok := m.Record.(ElementRecord)
+
+		if !ok || el.Id() != id {
+			continue
+		}
+
+		r := &Resolution{
+			Id:        id,
+			Kind:      m.Kind,
+			Placetype: el.Placetype(),
+			Record:    m.Record,
+			Status:    el.Status(),
+		}
+
+		return r, nil
+	}
+
+	return nil, nil
+}
+
+// resolveWithDatabase returns the `Resolution` for 'id' using 'db', or nil if 'db' does not contain 'id'.
+func resolveWithDatabase(ctx context.Context, db *sql.DB, id int64) (*Resolution, error) {
+
+	q := "SELECT body FROM geojson WHERE id = ?"
+
+	var body []byte
+
+	err := db.QueryRowContext(ctx, q, id).Scan(&body)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load feature, %w", err)
+	}
+
+	status, err := NewStatusWithFeature(body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	placetype := gjson.GetBytes(body, `properties.sfomuseum:placetype`).String()
+
+	if placetype == "" {
+
+		placetype, err = properties.Placetype(body)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to derive placetype, %w", err)
+		}
+	}
+
+	r := &Resolution{
+		Id:        id,
+		Placetype: placetype,
+		Status:    status,
+	}
+
+	v, ok := feature_record_funcs.Load(placetype)
+
+	if ok {
+
+		f := v.(*featureRecordFunc)
+
+		record, err := f.fn(ctx, body)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to derive %s record, %w", placetype, err)
+		}
+
+		r.Kind = f.kind
+		r.Record = record
+
+		return r, nil
+	}
+
+	feature, err := geojson.UnmarshalFeature(body)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal feature, %w", err)
+	}
+
+	r.Record = feature
+	return r, nil
+}
@@ -0,0 +1,144 @@
+package architecture_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/paulmach/orb/geojson"
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+	"github.com/sfomuseum/go-sfomuseum-architecture/gates"
+	"github.com/sfomuseum/go-sfomuseum-architecture/internal/testutil"
+	"github.com/sfomuseum/go-sfomuseum-architecture/terminals"
+)
+
+func TestResolve(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := map[int64]string{
+		1763588135: "gates",
+		1729792435: "terminals",
+	}
+
+	for id, kind := range tests {
+
+		r, err := architecture.Resolve(ctx, id)
+
+		if err != nil {
+			t.Fatalf("Failed to resolve %d, %v", id, err)
+		}
+
+		if r.Id != id || r.Kind != kind {
+			t.Fatalf("Unexpected resolution for %d: %d %s", id, r.Id, r.Kind)
+		}
+
+		switch rec := r.Record.(type) {
+		case *gates.Gate:
+
+			if r.Placetype != gates.PLACETYPE || rec.WhosOnFirstId != id || r.Status.IsCurrent != (rec.IsCurrent == 1) {
+				t.Fatalf("Unexpected gate resolution for %d: %s %v", id, r.Placetype, rec)
+			}
+
+		case *terminals.Terminal:
+
+			if r.Placetype != terminals.PLACETYPE || rec.WhosOnFirstId != id || r.Status.IsCurrent != (rec.IsCurrent == 1) {
+				t.Fatalf("Unexpected terminal resolution for %d: %s %v", id, r.Placetype, rec)
+			}
+
+		default:
+			t.Fatalf("Unexpected record type for %d: %T", id, r.Record)
+		}
+	}
+
+	_, err := architecture.Resolve(ctx, 1)
+
+	if !errors.Is(err, architecture.ErrNotFound) {
+		t.Fatalf("Expected not found error resolving unknown ID, got %v", err)
+	}
+}
+
+func TestResolveWithDatabase(t *testing.T) {
+
+	ctx := context.Background()
+
+	features := map[string]string{
+		"1.geojson": testutil.Feature(t, map[string]any{
+			"wof:id":              1,
+			"wof:name":            "Z99",
+			"sfomuseum:placetype": gates.PLACETYPE,
+			"mz:is_current":       0,
+			"wof:superseded_by":   []int64{2},
+			"edtf:deprecated":     "2024-01-01",
+			"edtf:inception":      "2020-01-01",
+			"edtf:cessation":      "2024-01-01",
+			"wof:hierarchy":       []any{},
+		}),
+		"3.geojson": testutil.Feature(t, map[string]any{
+			"wof:id":              3,
+			"wof:name":            "Boarding Area Z",
+			"sfomuseum:placetype": "boardingarea",
+			"mz:is_current":       1,
+			"wof:hierarchy":       []any{},
+		}),
+	}
+
+	dsn := testutil.NewSQLiteDatabase(t, features)
+
+	db, err := sql.Open(architecture.DEFAULT_SQLITE_DRIVER, dsn)
+
+	if err != nil {
+		t.Fatalf("Failed to open database, %v", err)
+	}
+
+	defer db.Close()
+
+	opts := &architecture.ResolveOptions{
+		Database: db,
+	}
+
+	r, err := architecture.ResolveWithOptions(ctx, 1, opts)
+
+	if err != nil {
+		t.Fatalf("Failed to resolve gate, %v", err)
+	}
+
+	g, ok := r.Record.(*gates.Gate)
+
+	if !ok || r.Kind != "gates" || r.Placetype != gates.PLACETYPE || g.Name != "Z99" {
+		t.Fatalf("Unexpected gate resolution: %s %s %T", r.Kind, r.Placetype, r.Record)
+	}
+
+	if r.Status.IsCurrent || !r.Status.IsSuperseded || !r.Status.IsDeprecated {
+		t.Fatalf("Unexpected gate status: %v", r.Status)
+	}
+
+	if *g.Status() != *r.Status {
+		t.Fatalf("Gate status %v does not match resolved status %v", g.Status(), r.Status)
+	}
+
+	r, err = architecture.ResolveWithOptions(ctx, 3, opts)
+
+	if err != nil {
+		t.Fatalf("Failed to resolve boarding area, %v", err)
+	}
+
+	_, ok = r.Record.(*geojson.Feature)
+
+	if !ok || r.Kind != "" || r.Placetype != "boardingarea" || !r.Status.IsCurrent {
+		t.Fatalf("Unexpected boarding area resolution: %s %s %T %v", r.Kind, r.Placetype, r.Record, r.Status)
+	}
+
+	// IDs that are not in the database fall back on the default lookups
+
+	r, err = architecture.ResolveWithOptions(ctx, 1763588135, opts)
+
+	if err != nil {
+		t.Fatalf("Failed to resolve embedded gate, %v", err)
+	}
+
+	if r.Kind != "gates" {
+		t.Fatalf("Unexpected kind for embedded gate: %s", r.Kind)
+	}
+}
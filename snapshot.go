@@ -0,0 +1,128 @@
+package architecture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// type Snapshot is a collection of records that were active on a given date keyed by their codes.
+type Snapshot[T any] struct {
+	// The (EDTF) date of the snapshot.
+	Date string `json:"date"`
+	// The records that were active on `Date` keyed by their codes.
+	Records map[string][]T `json:"records"`
+}
+
+// SnapshotLookup is implemented by lookups that can produce a `Snapshot` of all their records active on a given (EDTF) date.
+type SnapshotLookup interface {
+	SnapshotForDate(context.Context, string) (*Snapshot[interface{}], error)
+}
+
+// NewSnapshot returns a new, empty, `Snapshot` instance for 'date'.
+func NewSnapshot[T any](date string) *Snapshot[T] {
+
+	s := &Snapshot[T]{
+		Date:    date,
+		Records: make(map[string][]T),
+	}
+
+	return s
+}
+
+// Add adds 'r' to the snapshot for 'code'.
+func (s *Snapshot[T]) Add(code string, r T) {
+	s.Records[code] = append(s.Records[code], r)
+}
+
+// Codes returns the sorted list of codes in the snapshot.
+func (s *Snapshot[T]) Codes() []string {
+
+	codes := make([]string, 0)
+
+	for code := range s.Records {
+		codes = append(codes, code)
+	}
+
+	sort.Strings(codes)
+	return codes
+}
+
+// Multiple returns the sorted list of codes in the snapshot that resolve to more than one record.
+func (s *Snapshot[T]) Multiple() []string {
+
+	codes := make([]string, 0)
+
+	for code, records := range s.Records {
+
+		if len(records) > 1 {
+			codes = append(codes, code)
+		}
+	}
+
+	sort.Strings(codes)
+	return codes
+}
+
+// IsMultiple reports whether 'code' resolves to more than one record in the snapshot.
+func (s *Snapshot[T]) IsMultiple(code string) bool {
+	return len(s.Records[code]) > 1
+}
+
+// SnapshotForDate returns a `Snapshot` for 'date' for every registered lookup that supports them, keyed by the lookup's scheme.
+// Lookups are created using their default (scheme-only) URIs. Lookups that do not support snapshots are skipped.
+func SnapshotForDate(ctx context.Context, date string) (map[string]*Snapshot[interface{}], error) {
+
+	lookups := make(map[string]Lookup)
+
+	for _, scheme := range Schemes() {
+
+		lu, err := NewLookup(ctx, scheme)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create lookup for %s, %w", scheme, err)
+		}
+
+		lookups[strings.TrimSuffix(scheme, "://")] = lu
+	}
+
+	snapshots, err := SnapshotForDateWithLookups(ctx, date, lookups)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+// SnapshotForDateWithLookups returns a `Snapshot` for 'date' for each lookup in 'lookups', keyed by the same keys used in 'lookups'.
+// Lookups that do not support snapshots are skipped.
+func SnapshotForDateWithLookups(ctx context.Context, date string, lookups map[string]Lookup) (map[string]*Snapshot[interface{}], error) {
+
+	snapshots := make(map[string]*Snapshot[interface{}])
+
+	for k, lu := range lookups {
+
+		sl, ok := lu.(SnapshotLookup)
+
+		if !ok {
+			continue
+		}
+
+		s, err := sl.SnapshotForDate(ctx, date)
+
+		if errors.Is(err, errors.ErrUnsupported) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to derive snapshot for %s, %w", k, err)
+		}
+
+		snapshots[k] = s
+	}
+
+	return snapshots, nil
+}
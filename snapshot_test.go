@@ -0,0 +1,53 @@
+package architecture_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+	_ "github.com/sfomuseum/go-sfomuseum-architecture/galleries"
+	"github.com/sfomuseum/go-sfomuseum-architecture/gates"
+	_ "github.com/sfomuseum/go-sfomuseum-architecture/terminals"
+)
+
+func TestSnapshotForDate(t *testing.T) {
+
+	ctx := context.Background()
+
+	snapshots, err := architecture.SnapshotForDate(ctx, "2022-07-04")
+
+	if err != nil {
+		t.Fatalf("Failed to derive snapshots, %v", err)
+	}
+
+	for _, k := range []string{"gates", "galleries", "terminals"} {
+
+		s, ok := snapshots[k]
+
+		if !ok {
+			t.Fatalf("Missing snapshot for %s", k)
+		}
+
+		if len(s.Records) == 0 {
+			t.Fatalf("Empty snapshot for %s", k)
+		}
+	}
+
+	s := snapshots["gates"]
+
+	for code, records := range s.Records {
+
+		for _, r := range records {
+
+			g, ok := r.(*gates.Gate)
+
+			if !ok {
+				t.Fatalf("Unexpected record type for %s, %T", code, r)
+			}
+
+			if g.Code() != code {
+				t.Fatalf("Unexpected code for %d, expected %s but got %s", g.WhosOnFirstId, code, g.Code())
+			}
+		}
+	}
+}
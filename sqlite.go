@@ -0,0 +1,533 @@
+package architecture
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// The default `database/sql` driver used to open SQLite databases. This package does not import any drivers itself so applications
+// using SQLite-backed lookups need to import one, for example `_ "github.com/mattn/go-sqlite3"`.
+const DEFAULT_SQLITE_DRIVER string = "sqlite3"
+
+// The SQL expression, for the `geojson` table, used to select records by their SFO Museum placetype.
+const SQLITE_PLACETYPE_EXPR string = `JSON_EXTRACT(body, '$.properties."sfomuseum:placetype"')`
+
+// SQLiteQuerier is implemented by both `sql.DB` and `sql.Tx` so that `QuerySQLiteFeatures` can be used inside or outside of a transaction.
+type SQLiteQuerier interface {
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+}
+
+// SQLiteFeatureFunc is a function that is invoked for each GeoJSON Feature returned by `QuerySQLiteFeatures`.
+type SQLiteFeatureFunc func(context.Context, []byte) error
+
+// ErrStopSQLiteQuery can be returned by a `SQLiteFeatureFunc` to stop `QuerySQLiteFeatures` from reading any more rows without
+// it returning an error.
+var ErrStopSQLiteQuery = errors.New("Stop SQLite query")
+
+// NewSQLiteDatabaseWithURL returns a `sql.DB` instance for a Who's On First SQLite database, as produced by `campus.NewDatabaseWithIterator`,
+// derived from the query parameters in 'u' which is expected to be a lookup URI with a `sqlite` host. Valid parameters are:
+//
+// * `dsn` The data source name of the database to open. Required.
+// * `driver` The `database/sql` driver used to open the database. Default is `DEFAULT_SQLITE_DRIVER`.
+// * `index` A boolean value signaling whether to create any missing indices needed by the lookup. Default is false, since doing so
+// modifies the database, in which case lookups will still work but queries that can not use an index will be slower.
+//
+// 'indices' is a dictionary of index names and the SQL expressions, for the `geojson` table, to create them on.
+func NewSQLiteDatabaseWithURL(ctx context.Context, u *url.URL, indices map[string]string) (*sql.DB, error) {
+
+	q := u.Query()
+
+	dsn := q.Get("dsn")
+
+	if dsn == "" {
+		return nil, fmt.Errorf("Missing ?dsn= parameter")
+	}
+
+	driver := DEFAULT_SQLITE_DRIVER
+
+	if q.Has("driver") {
+		driver = q.Get("driver")
+	}
+
+	create_indices := false
+
+	if q.Has("index") {
+
+		v, err := strconv.ParseBool(q.Get("index"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?index= parameter, %w", err)
+		}
+
+		create_indices = v
+	}
+
+	db, err := sql.Open(driver, dsn)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open database, %w", err)
+	}
+
+	err = db.PingContext(ctx)
+
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Failed to connect to database, %w", err)
+	}
+
+	if create_indices {
+
+		for name, expr := range indices {
+
+			// Read-only databases can still be queried, just more slowly, so a missing index is not fatal
+
+			err := EnsureSQLiteIndex(ctx, db, name, expr)
+
+			if err != nil {
+				slog.Warn("Failed to create index, queries will not be able to use it", "index", name, "error", err)
+			}
+		}
+	}
+
+	return db, nil
+}
+
+// EnsureSQLiteIndex creates an index named 'name' on the SQL expression 'expr' for the `geojson` table in 'db' if it does not already exist.
+func EnsureSQLiteIndex(ctx context.Context, db *sql.DB, name string, expr string) error {
+
+	q := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON geojson (%s)", name, expr)
+
+	_, err := db.ExecContext(ctx, q)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create index %s, %w", name, err)
+	}
+
+	return nil
+}
+
+// QuerySQLiteFeatures executes the query 'q', which is expected to select a single column containing GeoJSON Feature bodies,
+// with 'args' against 'db' and invokes 'cb' for each row. Rows are read one at a time so the results are never held in memory at once.
+// If 'cb' returns `ErrStopSQLiteQuery` no more rows are read and nil is returned.
+func QuerySQLiteFeatures(ctx context.Context, db SQLiteQuerier, cb SQLiteFeatureFunc, q string, args ...any) error {
+
+	slog.Debug(q, "args", args)
+
+	rows, err := db.QueryContext(ctx, q, args...)
+
+	if err != nil {
+		return fmt.Errorf("Failed to execute query, %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var body []byte
+		err := rows.Scan(&body)
+
+		if err != nil {
+			return fmt.Errorf("Failed to scan row, %w", err)
+		}
+
+		err = cb(ctx, body)
+
+		if errors.Is(err, ErrStopSQLiteQuery) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return fmt.Errorf("Failed to iterate rows, %w", err)
+	}
+
+	return nil
+}
+
+// type SQLiteCodeQuery is a query used by `SQLiteLookup` to find the records matching a code. Queries select a single column containing
+// GeoJSON Feature bodies and are passed two arguments: the code being queried and the SFO Museum placetype of the records being queried.
+type SQLiteCodeQuery struct {
+	// The label for the query used in error messages, for example "name".
+	Label string
+	// The SQL query to execute.
+	Query string
+	// Numeric signals that the query is only executed for codes that are (64-bit) integers, which are passed to it as integers.
+	Numeric bool
+}
+
+// The `SQLiteCodeQuery` used to find records by their Who's On First ID.
+var SQLITE_QUERY_BY_ID = NewSQLiteCodeQuery("ID", "id", true)
+
+// The `SQLiteCodeQuery` used to find records by their Who's On First name, using the `spr` table.
+var SQLITE_QUERY_BY_NAME = &SQLiteCodeQuery{
+	Label: "name",
+	Query: fmt.Sprintf("SELECT g.body FROM spr s, geojson g WHERE s.name = ? AND s.id = g.id AND +%s = ?", SQLITE_PLACETYPE_EXPR),
+}
+
+// NewSQLiteCodeQuery returns a new `SQLiteCodeQuery` instance that finds records in the `geojson` table whose SQL expression 'expr' is
+// equal to the code being queried. 'label' and 'numeric' are assigned to the query's `Label` and `Numeric` properties.
+func NewSQLiteCodeQuery(label string, expr string, numeric bool) *SQLiteCodeQuery {
+
+	// The unary "+" operator prevents SQLite from choosing the placetype index over the (far more selective) index for 'expr'
+
+	q := &SQLiteCodeQuery{
+		Label:   label,
+		Query:   fmt.Sprintf("SELECT body FROM geojson WHERE %s = ? AND +%s = ?", expr, SQLITE_PLACETYPE_EXPR),
+		Numeric: numeric,
+	}
+
+	return q
+}
+
+// type SQLiteLookup is a lookup for records, of the kind described by a `RecordKind`, stored in a Who's On First SQLite database as produced
+// by `campus.NewDatabaseWithIterator`. Records are queried from the database, using the kind's `SQLiteQueries` and the database's indices,
+// each time `Find` is called rather than being loaded in to memory ahead of time. It is meant to be embedded in the SQLite-backed lookups
+// for each kind of record, for example `gates.SQLiteGatesLookup`.
+type SQLiteLookup[T Record] struct {
+	kind *RecordKind[T]
+	db   *sql.DB
+}
+
+// NewSQLiteLookup returns a new `SQLiteLookup` instance for records of the kind described by 'kind' derived from 'uri' which is expected
+// to take the form:
+//
+//	`{SCHEME}://sqlite?dsn={DSN}`
+//
+// Where `{DSN}` is the data source name of a Who's On First SQLite database. See `NewSQLiteDatabaseWithURL` for details on the other
+// parameters. The indices that may be created are an index on `SQLITE_PLACETYPE_EXPR` and the kind's `SQLiteIndices`.
+func NewSQLiteLookup[T Record](ctx context.Context, kind *RecordKind[T], uri string) (*SQLiteLookup[T], error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	if u.Host != "sqlite" {
+		return nil, fmt.Errorf("Invalid host '%s', expected 'sqlite'", u.Host)
+	}
+
+	indices := map[string]string{
+		"geojson_by_sfomuseum_placetype": SQLITE_PLACETYPE_EXPR,
+	}
+
+	for name, expr := range kind.SQLiteIndices {
+		indices[name] = expr
+	}
+
+	db, err := NewSQLiteDatabaseWithURL(ctx, u, indices)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create database, %w", err)
+	}
+
+	return NewSQLiteLookupWithDatabase(ctx, kind, db)
+}
+
+// NewSQLiteLookupWithDatabase returns a new `SQLiteLookup` instance for records of the kind described by 'kind' stored in 'db'. No indices are created.
+func NewSQLiteLookupWithDatabase[T Record](ctx context.Context, kind *RecordKind[T], db *sql.DB) (*SQLiteLookup[T], error) {
+
+	l := &SQLiteLookup[T]{
+		kind: kind,
+		db:   db,
+	}
+
+	return l, nil
+}
+
+// Find returns the list of records matching 'code' in chronological order. Records are selected using each of the kind's `SQLiteQueries`
+// and the database's indices. Unlike the in-memory lookups, normalized codes (see `NormalizeCode`) are not matched since they can not be
+// indexed. If there are no matches the kind's `NotFound` error is returned.
+func (l *SQLiteLookup[T]) Find(ctx context.Context, code string) ([]T, error) {
+
+	records, err := l.findWithIndices(ctx, code)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, l.kind.NotFound(code, REASON_UNKNOWN_CODE)
+	}
+
+	SortRecords(records)
+	return records, nil
+}
+
+// Append returns an error since `SQLiteLookup` instances are read-only.
+func (l *SQLiteLookup[T]) Append(ctx context.Context, data T) error {
+	return fmt.Errorf("SQLite lookups are read-only, %w", errors.ErrUnsupported)
+}
+
+// Close closes the underlying database.
+func (l *SQLiteLookup[T]) Close() error {
+	return l.db.Close()
+}
+
+// Codes returns the sorted list of (primary) codes, as returned by the `Code` method, for the records matching 'filter'. If 'filter' does
+// not exclude any records, and the kind has a `SQLiteCodeExpr`, the distinct codes are selected by the database without reading any records.
+// Otherwise records are read, and filtered, one at a time and only their codes are kept.
+func (l *SQLiteLookup[T]) Codes(ctx context.Context, filter *ListFilter) ([]string, error) {
+
+	err := filter.Validate()
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid filter, %w", err)
+	}
+
+	if filter.includesAll() && l.kind.SQLiteCodeExpr != "" {
+		return l.distinctCodes(ctx)
+	}
+
+	seen := make(map[string]bool)
+	codes := make([]string, 0)
+
+	cb := func(ctx context.Context, body []byte) error {
+
+		r, err := l.kind.FromFeature(body)
+
+		if err != nil {
+			return fmt.Errorf("Failed to derive %s, %w", l.kind.Label, err)
+		}
+
+		c := l.kind.Candidate(r)
+
+		if !filter.Include(c.IsCurrent, c.Inception, c.Cessation) {
+			return nil
+		}
+
+		code := r.Code()
+
+		if code != "" && !seen[code] {
+			codes = append(codes, code)
+			seen[code] = true
+		}
+
+		return nil
+	}
+
+	q := fmt.Sprintf("SELECT body FROM geojson WHERE %s = ?", SQLITE_PLACETYPE_EXPR)
+
+	err = QuerySQLiteFeatures(ctx, l.db, cb, q, l.kind.Placetype)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list %s codes, %w", l.kind.Label, err)
+	}
+
+	sort.Strings(codes)
+	return codes, nil
+}
+
+func (l *SQLiteLookup[T]) distinctCodes(ctx context.Context) ([]string, error) {
+
+	q := fmt.Sprintf("SELECT DISTINCT %s FROM geojson WHERE %s = ?", l.kind.SQLiteCodeExpr, SQLITE_PLACETYPE_EXPR)
+
+	slog.Debug(q, "placetype", l.kind.Placetype)
+
+	rows, err := l.db.QueryContext(ctx, q, l.kind.Placetype)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list %s codes, %w", l.kind.Label, err)
+	}
+
+	defer rows.Close()
+
+	codes := make([]string, 0)
+
+	for rows.Next() {
+
+		var code sql.NullString
+
+		err := rows.Scan(&code)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to scan row, %w", err)
+		}
+
+		if code.Valid && code.String != "" {
+			codes = append(codes, code.String)
+		}
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to iterate rows, %w", err)
+	}
+
+	sort.Strings(codes)
+	return codes, nil
+}
+
+// All returns an iterator over the records matching 'filter'. If 'filter' is nil all the records are included. Records are read from
+// the database, and filtered, one at a time as the iterator is consumed so they are returned in the order they are stored in the database
+// rather than in chronological order. Errors reading the database are logged and end the iteration.
+func (l *SQLiteLookup[T]) All(ctx context.Context, filter *ListFilter) (iter.Seq[T], error) {
+
+	err := filter.Validate()
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid filter, %w", err)
+	}
+
+	q := fmt.Sprintf("SELECT body FROM geojson WHERE %s = ?", SQLITE_PLACETYPE_EXPR)
+
+	seq := func(yield func(T) bool) {
+
+		cb := func(ctx context.Context, body []byte) error {
+
+			r, err := l.kind.FromFeature(body)
+
+			if err != nil {
+				return fmt.Errorf("Failed to derive %s, %w", l.kind.Label, err)
+			}
+
+			c := l.kind.Candidate(r)
+
+			if !filter.Include(c.IsCurrent, c.Inception, c.Cessation) {
+				return nil
+			}
+
+			if !yield(r) {
+				return ErrStopSQLiteQuery
+			}
+
+			return nil
+		}
+
+		err := QuerySQLiteFeatures(ctx, l.db, cb, q, l.kind.Placetype)
+
+		if err != nil {
+			slog.Error("Failed to list records", "placetype", l.kind.Placetype, "error", err)
+		}
+	}
+
+	return seq, nil
+}
+
+// Len returns the total number of records of the kind in the database.
+func (l *SQLiteLookup[T]) Len(ctx context.Context) (int, error) {
+
+	q := fmt.Sprintf("SELECT COUNT(id) FROM geojson WHERE %s = ?", SQLITE_PLACETYPE_EXPR)
+
+	var count int
+
+	err := l.db.QueryRowContext(ctx, q, l.kind.Placetype).Scan(&count)
+
+	if err != nil {
+		return 0, fmt.Errorf("Failed to count %s records, %w", l.kind.Label, err)
+	}
+
+	return count, nil
+}
+
+// WriteTo writes all the records of the kind in the database to 'wr' in the same (uncompressed) format as the precompiled data, sorted by their
+// Who's On First IDs. Records are read, and written, one at a time inside a single transaction so they are never held in memory at once and
+// the number of records written always matches the count recorded before them. See `DataEncoder` for details.
+func (l *SQLiteLookup[T]) WriteTo(ctx context.Context, wr io.Writer) error {
+
+	tx, err := l.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return fmt.Errorf("Failed to begin transaction, %w", err)
+	}
+
+	defer tx.Rollback()
+
+	var count int
+
+	q := fmt.Sprintf("SELECT COUNT(id) FROM geojson WHERE %s = ?", SQLITE_PLACETYPE_EXPR)
+
+	err = tx.QueryRowContext(ctx, q, l.kind.Placetype).Scan(&count)
+
+	if err != nil {
+		return fmt.Errorf("Failed to count %s records, %w", l.kind.Label, err)
+	}
+
+	enc, err := NewDataEncoder[T](wr, l.kind.Placetype, count)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create encoder, %w", err)
+	}
+
+	cb := func(ctx context.Context, body []byte) error {
+
+		r, err := l.kind.FromFeature(body)
+
+		if err != nil {
+			return fmt.Errorf("Failed to derive %s, %w", l.kind.Label, err)
+		}
+
+		return enc.Encode(r)
+	}
+
+	q = fmt.Sprintf("SELECT body FROM geojson WHERE %s = ? ORDER BY id ASC", SQLITE_PLACETYPE_EXPR)
+
+	err = QuerySQLiteFeatures(ctx, tx, cb, q, l.kind.Placetype)
+
+	if err != nil {
+		return fmt.Errorf("Failed to write %s records, %w", l.kind.Label, err)
+	}
+
+	return enc.Close()
+}
+
+func (l *SQLiteLookup[T]) findWithIndices(ctx context.Context, code string) ([]T, error) {
+
+	records := make([]T, 0)
+	seen := make(map[int64]bool)
+
+	cb := func(ctx context.Context, body []byte) error {
+
+		r, err := l.kind.FromFeature(body)
+
+		if err != nil {
+			return fmt.Errorf("Failed to derive %s, %w", l.kind.Label, err)
+		}
+
+		if !seen[r.Id()] {
+			records = append(records, r)
+			seen[r.Id()] = true
+		}
+
+		return nil
+	}
+
+	id, id_err := strconv.ParseInt(code, 10, 64)
+
+	for _, q := range l.kind.SQLiteQueries {
+
+		var arg any = code
+
+		if q.Numeric {
+
+			if id_err != nil {
+				continue
+			}
+
+			arg = id
+		}
+
+		err := QuerySQLiteFeatures(ctx, l.db, cb, q.Query, arg, l.kind.Placetype)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to find %s records by %s, %w", l.kind.Label, q.Label, err)
+		}
+	}
+
+	return records, nil
+}
@@ -0,0 +1,188 @@
+package architecture
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture/internal/testutil"
+)
+
+func TestSQLiteLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	features := map[string]string{
+		"1000000001.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000001, "wof:name": "Z1", "sfomuseum:placetype": "gate", "mz:is_current": 0, "edtf:inception": "2000", "edtf:cessation": "2010"}),
+		"1000000002.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000002, "wof:name": "Z1", "sfomuseum:placetype": "gate", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+		"1000000003.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000003, "wof:name": "Z1", "sfomuseum:placetype": "gallery", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+	}
+
+	dsn := testutil.NewSQLiteDatabase(t, features)
+
+	lu, err := NewSQLiteLookup(ctx, table_test_kind, "gates://sqlite?dsn="+url.QueryEscape(dsn))
+
+	if err != nil {
+		t.Fatalf("Failed to create SQLite lookup, %v", err)
+	}
+
+	defer lu.Close()
+
+	rsp, err := lu.Find(ctx, "Z1")
+
+	if err != nil {
+		t.Fatalf("Failed to find Z1, %v", err)
+	}
+
+	if ids := tableTestRecordIds(rsp); !slices.Equal(ids, []int64{1000000001, 1000000002}) {
+		t.Fatalf("Unexpected records for Z1, %v", ids)
+	}
+
+	rsp, err = lu.Find(ctx, "1000000002")
+
+	if err != nil {
+		t.Fatalf("Failed to find record by ID, %v", err)
+	}
+
+	if ids := tableTestRecordIds(rsp); !slices.Equal(ids, []int64{1000000002}) {
+		t.Fatalf("Unexpected records for ID, %v", ids)
+	}
+
+	_, err = lu.Find(ctx, "1000000003")
+
+	if err == nil {
+		t.Fatalf("Did not expect to find a record with a different placetype")
+	}
+}
+
+func TestSQLiteLookupWriteTo(t *testing.T) {
+
+	ctx := context.Background()
+
+	features := map[string]string{
+		"1000000002.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000002, "wof:name": "Z1", "sfomuseum:placetype": "gate", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+		"1000000001.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000001, "wof:name": "Z1", "sfomuseum:placetype": "gate", "mz:is_current": 0, "edtf:inception": "2000", "edtf:cessation": "2010"}),
+		"1000000003.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000003, "wof:name": "Z2", "sfomuseum:placetype": "gallery", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+	}
+
+	dsn := testutil.NewSQLiteDatabase(t, features)
+
+	lu, err := NewSQLiteLookup(ctx, table_test_kind, "gates://sqlite?dsn="+url.QueryEscape(dsn))
+
+	if err != nil {
+		t.Fatalf("Failed to create SQLite lookup, %v", err)
+	}
+
+	defer lu.Close()
+
+	var buf bytes.Buffer
+
+	err = lu.WriteTo(ctx, &buf)
+
+	if err != nil {
+		t.Fatalf("Failed to write records, %v", err)
+	}
+
+	// The output should be the same as writing all the records at once
+
+	all, err := lu.All(ctx, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to list records, %v", err)
+	}
+
+	var expected bytes.Buffer
+
+	err = WriteRecords(&expected, table_test_kind, slices.Collect(all))
+
+	if err != nil {
+		t.Fatalf("Failed to write expected records, %v", err)
+	}
+
+	if buf.String() != expected.String() {
+		t.Fatalf("Unexpected output, %s", buf.String())
+	}
+
+	// The test kind does not have a code expression so its codes are derived by reading the records
+
+	codes, err := lu.Codes(ctx, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to list codes, %v", err)
+	}
+
+	if !slices.Equal(codes, []string{"Z1"}) {
+		t.Fatalf("Unexpected codes, %v", codes)
+	}
+}
+
+func TestSQLiteLookupIndices(t *testing.T) {
+
+	ctx := context.Background()
+
+	features := map[string]string{
+		"1000000001.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000001, "wof:name": "Z1", "sfomuseum:placetype": "gate", "mz:is_current": 0, "edtf:inception": "2000", "edtf:cessation": "2010"}),
+		"1000000002.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000002, "wof:name": "Z2", "sfomuseum:placetype": "gate", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+	}
+
+	dsn := testutil.NewSQLiteDatabase(t, features)
+
+	has_index := func(db *sql.DB) bool {
+
+		var count int
+
+		err := db.QueryRowContext(ctx, "SELECT COUNT(name) FROM sqlite_master WHERE type = 'index' AND name = 'geojson_by_sfomuseum_placetype'").Scan(&count)
+
+		if err != nil {
+			t.Fatalf("Failed to query indices, %v", err)
+		}
+
+		return count > 0
+	}
+
+	lu, err := NewSQLiteLookup(ctx, table_test_kind, "gates://sqlite?dsn="+url.QueryEscape(dsn))
+
+	if err != nil {
+		t.Fatalf("Failed to create SQLite lookup, %v", err)
+	}
+
+	defer lu.Close()
+
+	if has_index(lu.db) {
+		t.Fatalf("Did not expect indices to be created by default")
+	}
+
+	// Stopping early should not read the remaining rows
+
+	all, err := lu.All(ctx, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to list records, %v", err)
+	}
+
+	count := 0
+
+	for range all {
+		count += 1
+		break
+	}
+
+	if count != 1 {
+		t.Fatalf("Unexpected number of records, %d", count)
+	}
+
+	indexed_lu, err := NewSQLiteLookup(ctx, table_test_kind, "gates://sqlite?index=true&dsn="+url.QueryEscape(dsn))
+
+	if err != nil {
+		t.Fatalf("Failed to create SQLite lookup with indices, %v", err)
+	}
+
+	defer indexed_lu.Close()
+
+	if !has_index(indexed_lu.db) {
+		t.Fatalf("Expected indices to be created")
+	}
+}
@@ -0,0 +1,613 @@
+package architecture
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/sfomuseum/go-edtf/cmp"
+)
+
+// type TableLookup is an in-memory lookup table of records, of the kind described by a `RecordKind`, keyed by their codes. It implements the
+// `TypedLookup`, `MutableLookup`, `ListableLookup` and `ExportableLookup` interfaces (the last three by way of `UntypedLookup`) and is
+// meant to be embedded in the lookups for each kind of record, for example `gates.GatesLookup`. Each instance has its own lookup table.
+type TableLookup[T Record] struct {
+	kind *RecordKind[T]
+	// Queries hold a read lock, and changes to the lookup table a write lock, for their duration so that queries always see a table whose
+	// codes are consistent with its records and see the records changed together (for example by `AppendBatch`) either all or not at all.
+	mu         *sync.RWMutex
+	records    map[int64]T
+	codes      map[string][]int64
+	normalized map[string][]int64
+	// The interval index is derived from records the first time it is needed, by a date or range query, so that lookups which are
+	// only ever queried by code never parse the records' (EDTF) dates. After that it is updated as records change. See withIntervals.
+	intervals *IntervalIndex[T]
+}
+
+// NewTableLookup returns a new `TableLookup` instance for records of the kind described by 'kind' containing 'records'.
+func NewTableLookup[T Record](ctx context.Context, kind *RecordKind[T], records []T) (*TableLookup[T], error) {
+
+	l := &TableLookup[T]{
+		kind: kind,
+		mu:   new(sync.RWMutex),
+	}
+
+	err := l.Replace(ctx, records)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Replace replaces the lookup table with a new table containing 'records'. The new table is built in full before it replaces the current
+// table so calls to `Find` will see either the old data or the new data but never a mix of both. If the new table can not be built the
+// current table is left in place and an error is returned.
+func (l *TableLookup[T]) Replace(ctx context.Context, records []T) error {
+
+	// Build the new table in a separate instance, without holding any locks, and then swap its contents in
+
+	table := &TableLookup[T]{
+		kind:       l.kind,
+		records:    make(map[int64]T, len(records)),
+		codes:      make(map[string][]int64),
+		normalized: make(map[string][]int64),
+	}
+
+	for _, r := range records {
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			// pass
+		}
+
+		err := l.validate(r)
+
+		if err != nil {
+			return err
+		}
+
+		table.appendRecord(ctx, r)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.records = table.records
+	l.codes = table.codes
+	l.normalized = table.normalized
+	l.intervals = nil
+
+	return nil
+}
+
+// Find returns the list of records matching 'code' in chronological order. If there are no records matching 'code' exactly then records
+// whose codes match the normalized value of 'code' are returned. See `NormalizeCode` for details.
+func (l *TableLookup[T]) Find(ctx context.Context, code string) ([]T, error) {
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.find(ctx, code)
+}
+
+// find returns the records matching 'code'. The caller is expected to hold a lock.
+func (l *TableLookup[T]) find(ctx context.Context, code string) ([]T, error) {
+
+	ids, ok := l.codes[code]
+
+	if !ok {
+		ids, ok = l.normalized[NormalizeCode(code)]
+	}
+
+	if !ok {
+		return nil, l.kind.NotFound(code, REASON_UNKNOWN_CODE)
+	}
+
+	records := make([]T, 0, len(ids))
+
+	for _, id := range ids {
+
+		r, ok := l.records[id]
+
+		if !ok {
+			return nil, fmt.Errorf("Invalid pointer '%d' for code '%s'", id, code)
+		}
+
+		records = append(records, r)
+	}
+
+	SortRecords(records)
+	return records, nil
+}
+
+// FindById returns the record with Who's On First ID 'id'. The second return value is false if there is no record with that ID.
+func (l *TableLookup[T]) FindById(ctx context.Context, id int64) (T, bool, error) {
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	r, ok := l.records[id]
+	return r, ok, nil
+}
+
+// Append adds 'data' to the lookup table, replacing any record with the same Who's On First ID. See `AppendBatch` for details.
+func (l *TableLookup[T]) Append(ctx context.Context, data T) error {
+	return l.AppendBatch(ctx, []T{data})
+}
+
+// AppendBatch adds each of 'records' to the lookup table, replacing any records with the same Who's On First IDs. The records are added
+// together so calls to `Find` see either none of them or all of them. If any of the records can not be added then none of them are. Only
+// the codes for the records being added, or replaced, are changed so the cost of adding a record does not depend on the size of the table.
+func (l *TableLookup[T]) AppendBatch(ctx context.Context, records []T) error {
+
+	for _, r := range records {
+
+		err := l.validate(r)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, r := range records {
+		l.appendRecord(ctx, r)
+	}
+
+	return nil
+}
+
+// Update replaces the record in the lookup table with the same Who's On First ID as 'data', updating the codes it can be found by.
+// The kind's `NotFound` error is returned if there is no record with that ID.
+func (l *TableLookup[T]) Update(ctx context.Context, data T) error {
+
+	err := l.validate(data)
+
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, exists := l.records[data.Id()]
+
+	if !exists {
+		return l.kind.NotFound(strconv.FormatInt(data.Id(), 10), REASON_UNKNOWN_ID)
+	}
+
+	l.appendRecord(ctx, data)
+	return nil
+}
+
+// Remove removes the record with Who's On First ID 'id', and all the codes it can be found by, from the lookup table. The kind's
+// `NotFound` error is returned if there is no record with that ID.
+func (l *TableLookup[T]) Remove(ctx context.Context, id int64) error {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.removeRecord(ctx, id) {
+		return l.kind.NotFound(strconv.FormatInt(id, 10), REASON_UNKNOWN_ID)
+	}
+
+	return nil
+}
+
+// validate returns an error if 'r' can not be added to the lookup table.
+func (l *TableLookup[T]) validate(r T) error {
+
+	var zero T
+
+	if r == zero {
+		return fmt.Errorf("Invalid %s (nil)", l.kind.Label)
+	}
+
+	return nil
+}
+
+// appendRecord adds 'r' to the lookup table, replacing any record with the same Who's On First ID, and updates the interval index if
+// it has already been derived. The caller is expected to hold a write lock and to have validated 'r'.
+func (l *TableLookup[T]) appendRecord(ctx context.Context, r T) {
+
+	id := r.Id()
+
+	l.removeRecord(ctx, id)
+	l.records[id] = r
+
+	for _, code := range l.kind.Codes(r) {
+
+		if code == "" {
+			continue
+		}
+
+		l.codes[code] = appendId(l.codes[code], id)
+
+		normalized_code := NormalizeCode(code)
+
+		if normalized_code != "" {
+			l.normalized[normalized_code] = appendId(l.normalized[normalized_code], id)
+		}
+	}
+
+	if l.intervals != nil {
+		appendSpan(ctx, l.intervals, r)
+	}
+}
+
+// removeRecord removes the record with Who's On First ID 'id', and every reference to it, from the lookup table and the interval index
+// if it has already been derived. It returns false if there is no record with that ID. The caller is expected to hold a write lock.
+func (l *TableLookup[T]) removeRecord(ctx context.Context, id int64) bool {
+
+	r, ok := l.records[id]
+
+	if !ok {
+		return false
+	}
+
+	delete(l.records, id)
+
+	for _, code := range l.kind.Codes(r) {
+
+		if code == "" {
+			continue
+		}
+
+		removeId(l.codes, code, id)
+
+		normalized_code := NormalizeCode(code)
+
+		if normalized_code != "" {
+			removeId(l.normalized, normalized_code, id)
+		}
+	}
+
+	if l.intervals != nil {
+		l.intervals.Remove(r)
+	}
+
+	return true
+}
+
+// withIntervals invokes 'fn' with the interval index for the lookup table, deriving it first if necessary, while holding a read lock.
+func (l *TableLookup[T]) withIntervals(ctx context.Context, fn func(*IntervalIndex[T])) {
+
+	for {
+
+		l.mu.RLock()
+
+		if l.intervals != nil {
+			defer l.mu.RUnlock()
+			fn(l.intervals)
+			return
+		}
+
+		l.mu.RUnlock()
+
+		// The table may be replaced, or the index derived by another caller, between releasing the read lock and acquiring the
+		// write lock so check again before deriving it
+
+		l.mu.Lock()
+
+		if l.intervals == nil {
+
+			intervals := NewIntervalIndex[T]()
+
+			for _, r := range l.records {
+				appendSpan(ctx, intervals, r)
+			}
+
+			l.intervals = intervals
+		}
+
+		l.mu.Unlock()
+	}
+}
+
+// Codes returns the sorted list of (primary) codes, as returned by the `Code` method, for the records matching 'filter'. If 'filter'
+// is nil the codes for all the records are returned.
+func (l *TableLookup[T]) Codes(ctx context.Context, filter *ListFilter) ([]string, error) {
+
+	records, err := l.list(ctx, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return RecordCodes(records), nil
+}
+
+// All returns an iterator over the records matching 'filter' in chronological order. If 'filter' is nil all the records are included.
+// The iterator yields the records in the lookup at the time `All` is called.
+func (l *TableLookup[T]) All(ctx context.Context, filter *ListFilter) (iter.Seq[T], error) {
+
+	records, err := l.list(ctx, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.Values(records), nil
+}
+
+// Len returns the total number of records in the lookup.
+func (l *TableLookup[T]) Len(ctx context.Context) (int, error) {
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return len(l.records), nil
+}
+
+// WriteTo writes all the records in the lookup to 'wr' in the same (uncompressed) format as the precompiled data. Records are sorted by
+// their Who's On First IDs so the same records always produce the same output. See `WriteRecords` for details.
+func (l *TableLookup[T]) WriteTo(ctx context.Context, wr io.Writer) error {
+
+	records, err := l.list(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	return WriteRecords(wr, l.kind, records)
+}
+
+func (l *TableLookup[T]) list(ctx context.Context, filter *ListFilter) ([]T, error) {
+
+	err := filter.Validate()
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid filter, %w", err)
+	}
+
+	l.mu.RLock()
+	records := slices.Collect(maps.Values(l.records))
+	l.mu.RUnlock()
+
+	return FilterRecords(l.kind, records, filter), nil
+}
+
+// FindAllForRange returns all the records matching 'code' that existed at any point between the EDTF dates 'start' and 'end'.
+// An open (`..`) or unknown (empty) value for 'start' or 'end' means that the range is unbounded on that side. Records with open or
+// unknown inception or cessation dates are treated as unbounded on that side. Records whose dates can not be parsed are excluded.
+func (l *TableLookup[T]) FindAllForRange(ctx context.Context, code string, start string, end string) ([]T, error) {
+
+	q, err := NewSpan(start, end)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid range, %w", err)
+	}
+
+	var rsp []T
+	records := make([]T, 0)
+
+	l.withIntervals(ctx, func(intervals *IntervalIndex[T]) {
+
+		rsp, err = l.find(ctx, code)
+
+		if err != nil {
+			return
+		}
+
+		for _, r := range rsp {
+
+			span, ok := intervals.Span(r)
+
+			if !ok || !span.Overlaps(q) {
+				continue
+			}
+
+			records = append(records, r)
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// FindAllOverlappingRange returns all the records, regardless of code, that existed at any point between the EDTF dates 'start' and 'end'
+// in chronological order. See `FindAllForRange` for details on how open and unknown dates are handled.
+func (l *TableLookup[T]) FindAllOverlappingRange(ctx context.Context, start string, end string) ([]T, error) {
+
+	q, err := NewSpan(start, end)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid range, %w", err)
+	}
+
+	var records []T
+
+	l.withIntervals(ctx, func(intervals *IntervalIndex[T]) {
+		records = intervals.Overlapping(q)
+	})
+
+	SortRecords(records)
+
+	return records, nil
+}
+
+// FindAllChangedForRange returns all the records, regardless of code, whose inception or cessation dates fall between the EDTF dates
+// 'start' and 'end' in chronological order. Open and unknown inception or cessation dates are never considered to be changes.
+func (l *TableLookup[T]) FindAllChangedForRange(ctx context.Context, start string, end string) ([]T, error) {
+
+	q, err := NewSpan(start, end)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid range, %w", err)
+	}
+
+	records := make([]T, 0)
+
+	l.withIntervals(ctx, func(intervals *IntervalIndex[T]) {
+
+		for _, r := range intervals.Overlapping(q) {
+
+			span, ok := intervals.Span(r)
+
+			if !ok || !span.ChangedDuring(q) {
+				continue
+			}
+
+			records = append(records, r)
+		}
+	})
+
+	SortRecords(records)
+	return records, nil
+}
+
+// SnapshotForDate returns a snapshot of all the records that were active for the EDTF date 'date', keyed by the value of their `Code`
+// method, using `RESOLVE_STRICT` so that every code which resolves to more than one record is reported by the snapshot's `Multiple` method.
+func (l *TableLookup[T]) SnapshotForDate(ctx context.Context, date string) (*Snapshot[T], error) {
+	return l.SnapshotForDateWithPolicy(ctx, date, RESOLVE_STRICT)
+}
+
+// SnapshotForDateWithPolicy returns a snapshot of all the records that were active for the EDTF date 'date', keyed by the value of their
+// `Code` method. Records are matched, and multiple records sharing a code are chosen between using 'policy', by the same rules as the
+// date-based finders for each kind of record so codes are reported by the snapshot's `Multiple` method only if those finders would
+// return a `MultipleCandidates` error for them.
+func (l *TableLookup[T]) SnapshotForDateWithPolicy(ctx context.Context, date string, policy ResolutionPolicy) (*Snapshot[T], error) {
+
+	q, err := NewSpanForDate(date)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid date, %w", err)
+	}
+
+	var overlapping []T
+
+	l.withIntervals(ctx, func(intervals *IntervalIndex[T]) {
+		overlapping = intervals.Overlapping(q)
+	})
+
+	candidates := make(map[string][]T)
+
+	// The interval index uses the widest possible interpretation of each record's dates so it is only used to narrow
+	// the list of records to compare with 'date'
+
+	for _, r := range overlapping {
+
+		inception, cessation := r.Dates()
+
+		is_between, err := cmp.IsBetween(date, inception, cessation)
+
+		if err != nil {
+			slog.Debug("Failed to determine whether record matches date conditions", "placetype", l.kind.Placetype, "id", r.Id(), "date", date, "inception", inception, "cessation", cessation, "error", err)
+			continue
+		}
+
+		if !is_between {
+			continue
+		}
+
+		code := r.Code()
+		candidates[code] = append(candidates[code], r)
+	}
+
+	s := NewSnapshot[T](date)
+
+	for code, records := range candidates {
+
+		SortRecords(records)
+
+		for _, r := range ApplyResolutionPolicy(policy, date, records, l.kind.Candidate) {
+			s.Add(code, r)
+		}
+	}
+
+	return s, nil
+}
+
+// Suggest returns a ranked list of codes that are similar to 'code'. It is meant to be used, on an opt-in basis, when there are no
+// records matching 'code' either exactly or by its normalized value. See `SuggestCodes` for details.
+func (l *TableLookup[T]) Suggest(ctx context.Context, code string) ([]*Suggestion, error) {
+
+	l.mu.RLock()
+	candidates := slices.Collect(maps.Keys(l.codes))
+	l.mu.RUnlock()
+
+	return SuggestCodes(code, candidates, l.kind.SuggestMaxDistance), nil
+}
+
+// Lineage returns the supersession chain, in date order, for the record with Who's On First ID 'id'. See `DeriveLineage` for details.
+func (l *TableLookup[T]) Lineage(ctx context.Context, id int64) (*Lineage[T], error) {
+	return DeriveLineage(ctx, id, l.FindById, l.kind.LineageNode)
+}
+
+// Predecessors returns all the records, in date order, that the record with Who's On First ID 'id' supersedes directly or indirectly.
+func (l *TableLookup[T]) Predecessors(ctx context.Context, id int64) ([]T, error) {
+
+	lineage, err := l.Lineage(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return lineage.Predecessors, nil
+}
+
+// Successors returns all the records, in date order, that supersede the record with Who's On First ID 'id' directly or indirectly.
+func (l *TableLookup[T]) Successors(ctx context.Context, id int64) ([]T, error) {
+
+	lineage, err := l.Lineage(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return lineage.Successors, nil
+}
+
+func appendSpan[T Record](ctx context.Context, intervals *IntervalIndex[T], r T) {
+
+	inception, cessation := r.Dates()
+
+	span, err := CachedSpan(inception, cessation)
+
+	if err != nil {
+		slog.Debug("Failed to derive span for record, excluding from interval index", "id", r.Id(), "inception", inception, "cessation", cessation, "error", err)
+		return
+	}
+
+	intervals.Add(span, r)
+}
+
+// removeId removes 'id' from the list of IDs for 'key' in 'index', removing 'key' entirely if there are no IDs left.
+func removeId(index map[string][]int64, key string, id int64) {
+
+	ids, ok := index[key]
+
+	if !ok {
+		return
+	}
+
+	others := make([]int64, 0, len(ids))
+
+	for _, other := range ids {
+
+		if other != id {
+			others = append(others, other)
+		}
+	}
+
+	if len(others) == 0 {
+		delete(index, key)
+	} else {
+		index[key] = others
+	}
+}
@@ -0,0 +1,313 @@
+package architecture
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+type tableTestRecord struct {
+	WhosOnFirstId int64   `json:"wof:id"`
+	Name          string  `json:"wof:name"`
+	IsCurrent     int64   `json:"mz:is_current"`
+	Inception     string  `json:"edtf:inception"`
+	Cessation     string  `json:"edtf:cessation"`
+	Supersedes    []int64 `json:"wof:supersedes,omitempty"`
+	SupersededBy  []int64 `json:"wof:superseded_by,omitempty"`
+}
+
+func (r *tableTestRecord) Id() int64 {
+	return r.WhosOnFirstId
+}
+
+func (r *tableTestRecord) Code() string {
+	return r.Name
+}
+
+func (r *tableTestRecord) Dates() (string, string) {
+	return r.Inception, r.Cessation
+}
+
+var table_test_kind = &RecordKind[*tableTestRecord]{
+	Placetype: "gate",
+	Label:     "test record",
+	Codes: func(r *tableTestRecord) []string {
+		return []string{r.Name, fmt.Sprintf("%d", r.WhosOnFirstId)}
+	},
+	Candidate: func(r *tableTestRecord) *ResolutionCandidate {
+		return &ResolutionCandidate{IsCurrent: r.IsCurrent, Inception: r.Inception, Cessation: r.Cessation}
+	},
+	NotFound: func(code string, reason string) error {
+		return fmt.Errorf("Record '%s' not found (%s), %w", code, reason, ErrNotFound)
+	},
+	FromFeature: func(body []byte) (*tableTestRecord, error) {
+
+		var f struct {
+			Properties *tableTestRecord `json:"properties"`
+		}
+
+		err := json.Unmarshal(body, &f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return f.Properties, nil
+	},
+	LineageNode: func(r *tableTestRecord) *LineageNode {
+		return &LineageNode{Id: r.WhosOnFirstId, Supersedes: r.Supersedes, SupersededBy: r.SupersededBy, Inception: r.Inception, Cessation: r.Cessation}
+	},
+	SuggestMaxDistance: 2,
+	SQLiteQueries: []*SQLiteCodeQuery{
+		SQLITE_QUERY_BY_ID,
+		SQLITE_QUERY_BY_NAME,
+	},
+}
+
+func tableTestRecordIds(records []*tableTestRecord) []int64 {
+
+	ids := make([]int64, len(records))
+
+	for i, r := range records {
+		ids[i] = r.WhosOnFirstId
+	}
+
+	return ids
+}
+
+func TestTableLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	records := []*tableTestRecord{
+		&tableTestRecord{WhosOnFirstId: 3, Name: "Z1", IsCurrent: 1, Inception: "2010", Cessation: ".."},
+		&tableTestRecord{WhosOnFirstId: 1, Name: "Z1", IsCurrent: 0, Inception: "2000", Cessation: "2010", SupersededBy: []int64{3}},
+		&tableTestRecord{WhosOnFirstId: 2, Name: "Z2", IsCurrent: 1, Inception: "2005", Cessation: ".."},
+	}
+
+	lu, err := NewTableLookup(ctx, table_test_kind, records)
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	rsp, err := lu.Find(ctx, "z-01")
+
+	if err != nil {
+		t.Fatalf("Failed to find Z1 by its normalized code, %v", err)
+	}
+
+	if ids := tableTestRecordIds(rsp); !slices.Equal(ids, []int64{1, 3}) {
+		t.Fatalf("Unexpected records for Z1, %v", ids)
+	}
+
+	_, err = lu.Find(ctx, "Z9")
+
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected not found error, got %v", err)
+	}
+
+	codes, err := lu.Codes(ctx, &ListFilter{Current: LIST_CURRENT})
+
+	if err != nil {
+		t.Fatalf("Failed to list codes, %v", err)
+	}
+
+	if !slices.Equal(codes, []string{"Z1", "Z2"}) {
+		t.Fatalf("Unexpected codes, %v", codes)
+	}
+
+	all, err := lu.All(ctx, &ListFilter{Date: "2007"})
+
+	if err != nil {
+		t.Fatalf("Failed to list records, %v", err)
+	}
+
+	if ids := tableTestRecordIds(slices.Collect(all)); !slices.Equal(ids, []int64{1, 2}) {
+		t.Fatalf("Unexpected records for 2007, %v", ids)
+	}
+
+	count, err := lu.Len(ctx)
+
+	if err != nil || count != 3 {
+		t.Fatalf("Unexpected count, %d %v", count, err)
+	}
+
+	successors, err := lu.Successors(ctx, 1)
+
+	if err != nil {
+		t.Fatalf("Failed to derive successors, %v", err)
+	}
+
+	if ids := tableTestRecordIds(successors); !slices.Equal(ids, []int64{3}) {
+		t.Fatalf("Unexpected successors, %v", ids)
+	}
+
+	var buf bytes.Buffer
+
+	err = lu.WriteTo(ctx, &buf)
+
+	if err != nil {
+		t.Fatalf("Failed to write records, %v", err)
+	}
+
+	decoded, _, err := DecodeData[*tableTestRecord](&buf, "gate")
+
+	if err != nil {
+		t.Fatalf("Failed to decode records, %v", err)
+	}
+
+	if ids := tableTestRecordIds(decoded); !slices.Equal(ids, []int64{1, 2, 3}) {
+		t.Fatalf("Unexpected written records, %v", ids)
+	}
+
+	err = lu.Append(ctx, nil)
+
+	if err == nil {
+		t.Fatalf("Expected nil record to fail")
+	}
+
+	err = lu.Update(ctx, &tableTestRecord{WhosOnFirstId: 9, Name: "Z9"})
+
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected update of unknown record to fail, got %v", err)
+	}
+
+	err = lu.Remove(ctx, 2)
+
+	if err != nil {
+		t.Fatalf("Failed to remove record, %v", err)
+	}
+
+	_, err = lu.Find(ctx, "Z2")
+
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected removed record not to be found, got %v", err)
+	}
+}
+
+func TestTableLookupIntervalIndex(t *testing.T) {
+
+	ctx := context.Background()
+
+	records := []*tableTestRecord{
+		&tableTestRecord{WhosOnFirstId: 1, Name: "Z1", Inception: "2000", Cessation: "2010"},
+		&tableTestRecord{WhosOnFirstId: 2, Name: "Z2", Inception: "2005", Cessation: ".."},
+	}
+
+	lu, err := NewTableLookup(ctx, table_test_kind, records)
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	_, err = lu.Find(ctx, "Z1")
+
+	if err != nil {
+		t.Fatalf("Failed to find Z1, %v", err)
+	}
+
+	if lu.intervals != nil {
+		t.Fatalf("Did not expect interval index to be derived by Find")
+	}
+
+	rsp, err := lu.FindAllOverlappingRange(ctx, "2012", "..")
+
+	if err != nil {
+		t.Fatalf("Failed to find overlapping records, %v", err)
+	}
+
+	if len(rsp) == 0 || lu.intervals == nil {
+		t.Fatalf("Expected interval index to be derived by FindAllOverlappingRange")
+	}
+}
+
+func TestTableLookupMutateIntervalIndex(t *testing.T) {
+
+	ctx := context.Background()
+
+	records := []*tableTestRecord{
+		&tableTestRecord{WhosOnFirstId: 1, Name: "Z1", Inception: "2000", Cessation: "2010"},
+		&tableTestRecord{WhosOnFirstId: 2, Name: "Z2", Inception: "2005", Cessation: "2015"},
+	}
+
+	lu, err := NewTableLookup(ctx, table_test_kind, records)
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	rsp, err := lu.FindAllOverlappingRange(ctx, "2012", "2013")
+
+	if err != nil {
+		t.Fatalf("Failed to find overlapping records, %v", err)
+	}
+
+	if ids := tableTestRecordIds(rsp); !slices.Equal(ids, []int64{2}) {
+		t.Fatalf("Unexpected records for 2012-2013, %v", ids)
+	}
+
+	before := lu.intervals
+
+	err = lu.Append(ctx, &tableTestRecord{WhosOnFirstId: 3, Name: "Z3", Inception: "2012", Cessation: ".."})
+
+	if err != nil {
+		t.Fatalf("Failed to append record, %v", err)
+	}
+
+	err = lu.Update(ctx, &tableTestRecord{WhosOnFirstId: 1, Name: "Z1", Inception: "2000", Cessation: "2013"})
+
+	if err != nil {
+		t.Fatalf("Failed to update record, %v", err)
+	}
+
+	err = lu.Remove(ctx, 2)
+
+	if err != nil {
+		t.Fatalf("Failed to remove record, %v", err)
+	}
+
+	// The interval index should have been updated in place rather than being derived again
+
+	if lu.intervals != before {
+		t.Fatalf("Expected interval index to be updated by mutations")
+	}
+
+	rsp, err = lu.FindAllOverlappingRange(ctx, "2012", "2013")
+
+	if err != nil {
+		t.Fatalf("Failed to find overlapping records, %v", err)
+	}
+
+	if ids := tableTestRecordIds(rsp); !slices.Equal(ids, []int64{1, 3}) {
+		t.Fatalf("Unexpected records for 2012-2013 after mutations, %v", ids)
+	}
+}
+
+func BenchmarkTableLookupAppend(b *testing.B) {
+
+	ctx := context.Background()
+
+	lu, err := NewTableLookup(ctx, table_test_kind, []*tableTestRecord{})
+
+	if err != nil {
+		b.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+
+		r := &tableTestRecord{WhosOnFirstId: int64(i + 1), Name: fmt.Sprintf("Z%d", i), Inception: "2000", Cessation: ".."}
+
+		err := lu.Append(ctx, r)
+
+		if err != nil {
+			b.Fatalf("Failed to append record, %v", err)
+		}
+	}
+}
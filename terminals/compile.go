@@ -7,6 +7,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/sfomuseum/go-sfomuseum-architecture"
 	"github.com/tidwall/gjson"
 	"github.com/whosonfirst/go-whosonfirst-feature/properties"
 	"github.com/whosonfirst/go-whosonfirst-iterate/v2/iterator"
@@ -50,83 +51,122 @@ func CompileTerminalsData(ctx context.Context, iterator_uri string, iterator_sou
 			return fmt.Errorf("Failed to read %s, %w", path, err)
 		}
 
-		wof_id, err := properties.Id(body)
+		g, err := newTerminalFromFeature(body)
 
 		if err != nil {
-			return fmt.Errorf("Failed to derive ID for %s, %w", path, err)
+			return fmt.Errorf("Failed to derive terminal from %s, %w", path, err)
 		}
 
-		wof_name, err := properties.Name(body)
+		mu.Lock()
+		lookup = append(lookup, g)
+		mu.Unlock()
 
-		if err != nil {
-			return fmt.Errorf("Failed to derive name for %s, %w", path, err)
-		}
+		return nil
+	}
 
-		fl, err := properties.IsCurrent(body)
+	iter, err := iterator.NewIterator(ctx, iterator_uri, iter_cb)
 
-		if err != nil {
-			return fmt.Errorf("Failed to determine is current for %s, %v", path, err)
-		}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create iterator, %w", err)
+	}
 
-		preferred_names := make([]string, 0)
-		variant_names := make([]string, 0)
+	err = iter.IterateURIs(ctx, iterator_sources...)
 
-		names := properties.Names(body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to iterate sources, %w", err)
+	}
+
+	return lookup, nil
+}
+
+// newTerminalFromFeature returns a new `Terminal` instance derived from the Who's On First GeoJSON Feature 'body'.
+func newTerminalFromFeature(body []byte) (*Terminal, error) {
 
-		for k, k_names := range names {
+	wof_id, err := properties.Id(body)
 
-			if strings.HasSuffix(k, "_preferred") {
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive ID, %w", err)
+	}
+
+	wof_name, err := properties.Name(body)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive name, %w", err)
+	}
 
-				for _, n := range k_names {
-					preferred_names = append(preferred_names, n)
-				}
-			} else if strings.HasSuffix(k, "_variant") {
+	fl, err := properties.IsCurrent(body)
 
-				for _, n := range k_names {
-					variant_names = append(variant_names, n)
-				}
-			} else {
+	if err != nil {
+		return nil, fmt.Errorf("Failed to determine is current, %w", err)
+	}
+
+	preferred_names := make([]string, 0)
+	variant_names := make([]string, 0)
+
+	lang_names := make(map[string][]string)
+
+	names := properties.Names(body)
+
+	for k, k_names := range names {
+
+		if strings.HasSuffix(k, "_preferred") {
+
+			for _, n := range k_names {
+				preferred_names = append(preferred_names, n)
 			}
 
-		}
+			lang_names[k] = k_names
 
-		inception := properties.Inception(body)
-		cessation := properties.Cessation(body)
-
-		g := &Terminal{
-			WhosOnFirstId:  wof_id,
-			Name:           wof_name,
-			IsCurrent:      fl.Flag(),
-			PreferredNames: preferred_names,
-			VariantNames:   variant_names,
-			Inception:      inception,
-			Cessation:      cessation,
-		}
+		} else if strings.HasSuffix(k, "_variant") {
 
-		sfom_rsp := gjson.GetBytes(body, "properties.sfomuseum:terminal_id")
+			for _, n := range k_names {
+				variant_names = append(variant_names, n)
+			}
+
+			lang_names[k] = k_names
 
-		if sfom_rsp.Exists() {
-			g.SFOMuseumId = sfom_rsp.String()
+		} else {
 		}
 
-		mu.Lock()
-		lookup = append(lookup, g)
-		mu.Unlock()
+	}
 
-		return nil
+	inception := properties.Inception(body)
+	cessation := properties.Cessation(body)
+
+	parents := architecture.DeriveParents(body)
+
+	g := &Terminal{
+		WhosOnFirstId:  wof_id,
+		Name:           wof_name,
+		IsCurrent:      fl.Flag(),
+		PreferredNames: preferred_names,
+		VariantNames:   variant_names,
+		Names:          lang_names,
+		Inception:      inception,
+		Cessation:      cessation,
+		ParentId:       parents.ParentId,
+		Supersedes:     properties.Supersedes(body),
+		SupersededBy:   properties.SupersededBy(body),
+		Deprecated:     properties.Deprecated(body),
 	}
 
-	iter, err := iterator.NewIterator(ctx, iterator_uri, iter_cb)
+	sfom_rsp := gjson.GetBytes(body, "properties.sfomuseum:terminal_id")
 
-	if err != nil {
-		return nil, fmt.Errorf("Failed to create iterator, %w", err)
+	if sfom_rsp.Exists() {
+		g.SFOMuseumId = sfom_rsp.String()
 	}
 
-	err = iter.IterateURIs(ctx, iterator_sources...)
+	return g, nil
+}
+
+// newTerminalRecord derives a `Terminal` record from 'body' for use as an `architecture.FeatureRecordFunc`.
+func newTerminalRecord(ctx context.Context, body []byte) (interface{}, error) {
+
+	r, err := newTerminalFromFeature(body)
 
 	if err != nil {
-		return nil, fmt.Errorf("Failed to iterate sources, %w", err)
+		return nil, err
 	}
 
-	return lookup, nil
+	return r, nil
 }
@@ -1,45 +1,95 @@
 package terminals
 
 import (
+	"errors"
 	"fmt"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
 )
 
-type NotFound struct{ code string }
+// type NotFound is the error returned when no terminal matching a query can be found. It matches `architecture.ErrNotFound` using `errors.Is`.
+type NotFound struct {
+	// The code that was queried.
+	Code string
+	// The (EDTF) date that was queried, if any.
+	Date string
+	// The reason no terminal was found. One of the `architecture.REASON_*` constants.
+	Reason string
+}
 
 func (e NotFound) Error() string {
-	return fmt.Sprintf("Terminal '%s' not found", e.code)
+
+	msg := fmt.Sprintf("Terminal '%s' not found", e.Code)
+
+	if e.Date != "" {
+		msg = fmt.Sprintf("%s for date '%s'", msg, e.Date)
+	}
+
+	if e.Reason != "" {
+		msg = fmt.Sprintf("%s (%s)", msg, e.Reason)
+	}
+
+	return msg
 }
 
 func (e NotFound) String() string {
 	return e.Error()
 }
 
-type MultipleCandidates struct{ code string }
+func (e NotFound) Is(target error) bool {
+	return target == architecture.ErrNotFound
+}
+
+// type MultipleCandidates is the error returned when more than one terminal matches a query that expects a single result. It matches
+// `architecture.ErrMultipleCandidates` using `errors.Is`.
+type MultipleCandidates struct {
+	// The code that was queried.
+	Code string
+	// The (EDTF) date that was queried, if any.
+	Date string
+	// The reason a single terminal could not be chosen. One of the `architecture.REASON_*` constants.
+	Reason string
+	// The terminals matching the query.
+	Candidates []*Terminal
+}
 
 func (e MultipleCandidates) Error() string {
-	return fmt.Sprintf("Multiple candidates for terminal '%s'", e.code)
+
+	msg := fmt.Sprintf("Multiple candidates for terminal '%s'", e.Code)
+
+	if e.Date != "" {
+		msg = fmt.Sprintf("%s for date '%s'", msg, e.Date)
+	}
+
+	if e.Reason != "" {
+		msg = fmt.Sprintf("%s (%s)", msg, e.Reason)
+	}
+
+	return msg
 }
 
 func (e MultipleCandidates) String() string {
 	return e.Error()
 }
 
+func (e MultipleCandidates) Is(target error) bool {
+	return target == architecture.ErrMultipleCandidates
+}
+
+// IsNotFound reports whether 'e', or any error it wraps, is a `NotFound` error.
 func IsNotFound(e error) bool {
 
-	switch e.(type) {
-	case NotFound, *NotFound:
-		return true
-	default:
-		return false
-	}
+	var nf NotFound
+	var nf_ptr *NotFound
+
+	return errors.As(e, &nf) || errors.As(e, &nf_ptr)
 }
 
+// IsMultipleCandidates reports whether 'e', or any error it wraps, is a `MultipleCandidates` error.
 func IsMultipleCandidates(e error) bool {
 
-	switch e.(type) {
-	case MultipleCandidates, *MultipleCandidates:
-		return true
-	default:
-		return false
-	}
+	var mc MultipleCandidates
+	var mc_ptr *MultipleCandidates
+
+	return errors.As(e, &mc) || errors.As(e, &mc_ptr)
 }
@@ -1,13 +1,16 @@
 package terminals
 
 import (
-	_ "fmt"
+	"errors"
+	"fmt"
 	"testing"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
 )
 
 func TestNotFound(t *testing.T) {
 
-	e := NotFound{"T2"}
+	e := NotFound{Code: "T2"}
 
 	if !IsNotFound(e) {
 		t.Fatalf("Expected NotFound error")
@@ -20,7 +23,7 @@ func TestNotFound(t *testing.T) {
 
 func TestMultipleCandidates(t *testing.T) {
 
-	e := MultipleCandidates{"T2"}
+	e := MultipleCandidates{Code: "T2"}
 
 	if !IsMultipleCandidates(e) {
 		t.Fatalf("Expected MultipleCandidates error")
@@ -30,3 +33,42 @@ func TestMultipleCandidates(t *testing.T) {
 		t.Fatalf("Invalid stringification")
 	}
 }
+
+func TestWrappedErrors(t *testing.T) {
+
+	candidates := []*Terminal{
+		&Terminal{WhosOnFirstId: 1000000001},
+		&Terminal{WhosOnFirstId: 1000000002},
+	}
+
+	var err error = MultipleCandidates{Code: "T2", Date: "2020", Reason: architecture.REASON_MULTIPLE_DATE_MATCH, Candidates: candidates}
+	err = fmt.Errorf("Failed to find T2, %w", err)
+
+	if !IsMultipleCandidates(err) {
+		t.Fatalf("Expected wrapped MultipleCandidates error")
+	}
+
+	if !errors.Is(err, architecture.ErrMultipleCandidates) {
+		t.Fatalf("Expected wrapped error to match architecture.ErrMultipleCandidates")
+	}
+
+	var mc MultipleCandidates
+
+	if !errors.As(err, &mc) {
+		t.Fatalf("Expected wrapped error to be a MultipleCandidates error")
+	}
+
+	if len(mc.Candidates) != 2 || mc.Date != "2020" {
+		t.Fatalf("Unexpected candidates or date, %v", mc)
+	}
+
+	err = fmt.Errorf("Failed to find T2, %w", &NotFound{Code: "T2"})
+
+	if !IsNotFound(err) || !errors.Is(err, architecture.ErrNotFound) {
+		t.Fatalf("Expected wrapped NotFound error")
+	}
+
+	if errors.Is(err, architecture.ErrMultipleCandidates) {
+		t.Fatalf("NotFound error should not match architecture.ErrMultipleCandidates")
+	}
+}
@@ -2,53 +2,93 @@ package terminals
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
-	_ "log"
-	"net/http"
+	"io/fs"
 	"net/url"
-	"sort"
+	"os"
+	"path/filepath"
 	"strconv"
-	"strings"
 	"sync"
-	"sync/atomic"
 
+	"github.com/paulmach/orb/geojson"
 	"github.com/sfomuseum/go-sfomuseum-architecture"
 	"github.com/sfomuseum/go-sfomuseum-architecture/data"
 )
 
-const DATA_JSON string = "terminals.json"
+// The SFO Museum placetype for terminals.
+const PLACETYPE string = "terminal"
 
-var lookup_table *sync.Map
-var lookup_idx int64
+// The name of the compiled data file, in the `architecture.DataEnvelope` format.
+const DATA_JSON string = "terminals.json"
 
-var lookup_init sync.Once
-var lookup_init_err error
+// The name of the gzip-compressed copy of `DATA_JSON`. This is the only copy that is embedded, and published alongside the `SHA256SUMS`
+// checksum manifest, and the one read by default.
+const DATA_JSON_GZIP string = DATA_JSON + ".gz"
+
+// The maximum edit distance for codes returned by `Suggest`.
+const SUGGEST_MAX_DISTANCE int = 2
+
+var default_lookup *TerminalsLookup
+var default_lookup_mu = new(sync.Mutex)
+
+// record_kind describes terminals to the generic lookups in the architecture package.
+var record_kind = &architecture.RecordKind[*Terminal]{
+	Placetype:          PLACETYPE,
+	Label:              "terminal",
+	Codes:              possibleCodes,
+	Candidate:          resolutionCandidate,
+	NotFound:           notFound,
+	FromFeature:        newTerminalFromFeature,
+	WithFeature:        withFeature,
+	LineageNode:        lineageNode,
+	SuggestMaxDistance: SUGGEST_MAX_DISTANCE,
+	SQLiteIndices:      sqlite_indices,
+	SQLiteQueries:      sqlite_queries,
+	SQLiteCodeExpr:     sqlite_code_expr,
+}
 
-type TerminalsLookupFunc func(context.Context)
+// TerminalsLookupFunc is a function that, when invoked, returns the list of `Terminal` records to be used by a `TerminalsLookup` instance.
+type TerminalsLookupFunc func(context.Context) ([]*Terminal, error)
 
+// TerminalsLookup implements the `architecture.TypedLookup[*Terminal]` interface for terminals. Each instance has its own lookup table. See
+// `architecture.TableLookup` for details of the methods used to query, list, change and export the records in the lookup table.
 type TerminalsLookup struct {
-	architecture.Lookup
+	*architecture.TableLookup[*Terminal]
 }
 
 func init() {
 	ctx := context.Background()
 	architecture.RegisterLookup(ctx, "terminals", NewLookup)
-
-	lookup_idx = int64(0)
+	architecture.RegisterFeatureRecordFunc(ctx, PLACETYPE, "terminals", newTerminalRecord)
 }
 
-// NewLookup will return an `architecture.Lookup` instance. By default the lookup table is derived from precompiled (embedded) data in `data/terminals.json`
+// NewLookup will return an `architecture.Lookup` instance. By default the lookup table is derived from precompiled (embedded) gzip-compressed data in `data/terminals.json.gz`
 // by passing in `sfomuseum://` as the URI. It is also possible to create a new lookup table with the following URI options:
 //
 //	`sfomuseum://github`
 //
-// This will cause the lookup table to be derived from the data stored at https://raw.githubusercontent.com/sfomuseum/go-sfomuseum-architecture/main/data/terminals.json. This might be desirable if there have been updates to the underlying data that are not reflected in the locally installed package's pre-compiled data.
+// This will cause the lookup table to be derived from the data stored at https://raw.githubusercontent.com/sfomuseum/go-sfomuseum-architecture/main/data/terminals.json.gz. This might be desirable if there have been updates to the underlying data that are not reflected in the locally installed package's pre-compiled data.
+//
+//	`sfomuseum://remote?url={URL}`
+//
+// This will cause the lookup table to be derived from the data stored at `{URL}/terminals.json.gz`. Both the `github` and `remote` options verify the data against the `SHA256SUMS` checksum manifest published alongside it and accept optional `cache`, `verify`, `fallback` and `timeout` parameters. See `architecture.NewRemoteSourceWithURL` for details.
 //
 //	`sfomuseum://iterator?uri={URI}&source={SOURCE}`
 //
 // This will cause the lookup table to be derived, at runtime, from data emitted by a `whosonfirst/go-whosonfirst-iterate` instance. `{URI}` should be a valid `whosonfirst/go-whosonfirst-iterate/iterator` URI and `{SOURCE}` is one or more URIs for the iterator to process.
+//
+//	`sfomuseum://file?path={PATH}`
+//
+// This will cause the lookup table to be derived from the data stored in the local file `{PATH}`. It is assumed that the data in `{PATH}` will be formatted in the same way as the precompiled (embedded) data. This might be desirable if you want to pin a specific release of the data.
+//
+//	`sfomuseum://sqlite?dsn={DSN}`
+//
+// This will cause terminals to be queried, as needed, from the Who's On First SQLite database `{DSN}` rather than being loaded in to memory. See `NewSQLiteTerminalsLookup` for details.
+//
+//	`sfomuseum://reader?reader={READER_URI}`
+//
+// This will cause terminals to be read, as needed, from the `whosonfirst/go-reader` URI `{READER_URI}` and cached, keeping only an index of their codes in memory. See `NewReaderTerminalsLookup` for details.
 func NewLookup(ctx context.Context, uri string) (architecture.Lookup, error) {
 
 	u, err := url.Parse(uri)
@@ -57,6 +97,70 @@ func NewLookup(ctx context.Context, uri string) (architecture.Lookup, error) {
 		return nil, fmt.Errorf("Failed to parse URI, %w", err)
 	}
 
+	switch u.Host {
+	case "sqlite":
+
+		l, err := NewSQLiteTerminalsLookup(ctx, uri)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return architecture.NewUntypedLookup[*Terminal](l), nil
+
+	case "reader":
+
+		l, err := NewReaderTerminalsLookup(ctx, uri)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return architecture.NewUntypedLookup[*Terminal](l), nil
+	}
+
+	l, err := NewTerminalsLookup(ctx, uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return architecture.NewUntypedLookup[*Terminal](l), nil
+}
+
+// NewTerminalsLookup will return a `TerminalsLookup` instance derived from 'uri'. See `NewLookup` for details on the URI options.
+func NewTerminalsLookup(ctx context.Context, uri string) (*TerminalsLookup, error) {
+
+	lookup_func, err := NewLookupFuncWithURI(ctx, uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTerminalsLookupWithLookupFunc(ctx, lookup_func)
+}
+
+// NewTerminalsLookupWithFS will return a `TerminalsLookup` instance derived from the data stored in 'filename' in 'fsys'. See `NewLookupFuncWithFS` for details.
+func NewTerminalsLookupWithFS(ctx context.Context, fsys fs.FS, filename string) (*TerminalsLookup, error) {
+
+	lookup_func, err := NewLookupFuncWithFS(ctx, fsys, filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTerminalsLookupWithLookupFunc(ctx, lookup_func)
+}
+
+// NewLookupFuncWithURI will return a `TerminalsLookupFunc` function instance derived from 'uri'. See `NewLookup` for details on the URI options.
+func NewLookupFuncWithURI(ctx context.Context, uri string) (TerminalsLookupFunc, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
 	// Reminder: u.Scheme is used by the architecture.Lookup constructor
 
 	switch u.Host {
@@ -67,50 +171,87 @@ func NewLookup(ctx context.Context, uri string) (architecture.Lookup, error) {
 		iterator_uri := q.Get("uri")
 		iterator_sources := q["source"]
 
-		return NewLookupFromIterator(ctx, iterator_uri, iterator_sources...)
+		terminals_list, err := CompileTerminalsData(ctx, iterator_uri, iterator_sources...)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to compile terminals data, %w", err)
+		}
 
-	case "github":
+		return NewLookupFuncWithTerminals(ctx, terminals_list), nil
 
-		data_url := fmt.Sprintf("https://raw.githubusercontent.com/sfomuseum/go-sfomuseum-architecture/main/data/%s", DATA_JSON)
-		rsp, err := http.Get(data_url)
+	case "github", "remote":
+
+		src, err := architecture.NewRemoteSourceWithURL(u, data.FS)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create remote source, %w", err)
+		}
+
+		fh, err := src.Open(ctx, DATA_JSON_GZIP)
 
 		if err != nil {
-			return nil, fmt.Errorf("Failed to load remote data from Github, %w", err)
+			return nil, fmt.Errorf("Failed to load remote data, %w", err)
+		}
+
+		return NewLookupFuncWithReader(ctx, fh), nil
+
+	case "file":
+
+		path := u.Query().Get("path")
+
+		if path == "" {
+			return nil, fmt.Errorf("Missing ?path= parameter")
 		}
 
-		lookup_func := NewLookupFuncWithReader(ctx, rsp.Body)
-		return NewLookupWithLookupFunc(ctx, lookup_func)
+		return NewLookupFuncWithFS(ctx, os.DirFS(filepath.Dir(path)), filepath.Base(path))
+
+	case "sqlite":
+
+		return nil, fmt.Errorf("The sqlite host can not be used to derive a lookup table, use NewSQLiteTerminalsLookup instead")
+
+	case "reader":
+
+		return nil, fmt.Errorf("The reader host can not be used to derive a lookup table, use NewReaderTerminalsLookup instead")
 
 	default:
 
-		fs := data.FS
-		fh, err := fs.Open(DATA_JSON)
+		lookup_func, err := NewLookupFuncWithFS(ctx, data.FS, DATA_JSON_GZIP)
 
 		if err != nil {
 			return nil, fmt.Errorf("Failed to load local precompiled data, %w", err)
 		}
 
-		lookup_func := NewLookupFuncWithReader(ctx, fh)
-		return NewLookupWithLookupFunc(ctx, lookup_func)
+		return lookup_func, nil
+	}
+}
+
+// NewLookupFuncWithFS will return a `TerminalsLookupFunc` function instance that, when invoked, will populate an `architecture.Lookup` instance with data stored in
+// 'filename' in 'fsys'. It is assumed that the data in 'filename' will be formatted in the same way as the precompiled (embedded) data stored in `data/terminals.json.gz`, with or without gzip compression.
+func NewLookupFuncWithFS(ctx context.Context, fsys fs.FS, filename string) (TerminalsLookupFunc, error) {
+
+	fh, err := fsys.Open(filename)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open %s, %w", filename, err)
 	}
+
+	return NewLookupFuncWithReader(ctx, fh), nil
 }
 
 // NewLookupWithReader will return an `TerminalsLookupFunc` function instance that, when invoked, will populate an `architecture.Lookup` instance with data stored in `r`.
 // `r` will be closed when the `TerminalsLookupFunc` function instance is invoked.
-// It is assumed that the data in `r` will be formatted in the same way as the procompiled (embedded) data stored in `data/sfomuseum.json`.
+// It is assumed that the data in `r` will be formatted in the same way as the procompiled (embedded) data stored in `data/terminals.json.gz`, with or without gzip compression. Both the versioned
+// `architecture.DataEnvelope` format and the legacy bare JSON array format are supported. See `architecture.DecodeData` for details.
 func NewLookupFuncWithReader(ctx context.Context, r io.ReadCloser) TerminalsLookupFunc {
 
 	defer r.Close()
 
-	var terminals_list []*Terminal
-
-	dec := json.NewDecoder(r)
-	err := dec.Decode(&terminals_list)
+	terminals_list, _, err := architecture.DecodeData[*Terminal](r, PLACETYPE)
 
 	if err != nil {
 
-		lookup_func := func(ctx context.Context) {
-			lookup_init_err = err
+		lookup_func := func(ctx context.Context) ([]*Terminal, error) {
+			return nil, fmt.Errorf("Failed to decode data, %w", err)
 		}
 
 		return lookup_func
@@ -122,115 +263,142 @@ func NewLookupFuncWithReader(ctx context.Context, r io.ReadCloser) TerminalsLook
 // NewLookupFuncWithTerminals will return an `TerminalsLookupFunc` function instance that, when invoked, will populate an `architecture.Lookup` instance with data stored in `terminals_list`.
 func NewLookupFuncWithTerminals(ctx context.Context, terminals_list []*Terminal) TerminalsLookupFunc {
 
-	lookup_func := func(ctx context.Context) {
-
-		table := new(sync.Map)
-
-		for _, data := range terminals_list {
-
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				// pass
-			}
-
-			appendData(ctx, table, data)
-		}
-
-		lookup_table = table
+	lookup_func := func(ctx context.Context) ([]*Terminal, error) {
+		return terminals_list, nil
 	}
 
 	return lookup_func
 }
 
 // NewLookupWithLookupFunc will return an `architecture.Lookup` instance derived by data compiled using `lookup_func`.
+// Each call to `lookup_func` produces a new lookup table so the instances returned by this method do not share any data.
 func NewLookupWithLookupFunc(ctx context.Context, lookup_func TerminalsLookupFunc) (architecture.Lookup, error) {
 
-	fn := func() {
-		lookup_func(ctx)
-	}
-
-	lookup_init.Do(fn)
+	l, err := NewTerminalsLookupWithLookupFunc(ctx, lookup_func)
 
-	if lookup_init_err != nil {
-		return nil, lookup_init_err
+	if err != nil {
+		return nil, err
 	}
 
-	l := TerminalsLookup{}
-	return &l, nil
+	return architecture.NewUntypedLookup[*Terminal](l), nil
 }
 
-func NewLookupFromIterator(ctx context.Context, iterator_uri string, iterator_sources ...string) (architecture.Lookup, error) {
+// NewTerminalsLookupWithLookupFunc will return a `TerminalsLookup` instance derived by data compiled using `lookup_func`.
+func NewTerminalsLookupWithLookupFunc(ctx context.Context, lookup_func TerminalsLookupFunc) (*TerminalsLookup, error) {
 
-	terminals_list, err := CompileTerminalsData(ctx, iterator_uri, iterator_sources...)
+	terminals_list, err := lookup_func(ctx)
 
 	if err != nil {
-		return nil, fmt.Errorf("Failed to compile terminals data, %w", err)
+		return nil, err
 	}
 
-	lookup_func := NewLookupFuncWithTerminals(ctx, terminals_list)
-	return NewLookupWithLookupFunc(ctx, lookup_func)
+	table, err := architecture.NewTableLookup(ctx, record_kind, terminals_list)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := &TerminalsLookup{
+		TableLookup: table,
+	}
+
+	return l, nil
 }
 
-func (l *TerminalsLookup) Find(ctx context.Context, code string) ([]interface{}, error) {
+// defaultLookup returns a package-level `TerminalsLookup` instance derived from the precompiled (embedded) data. It is created lazily the first
+// time it is needed with a background context, rather than the context of the first caller which may be cancelled while it is still
+// being used, and a failure to create it is not retained so that it is attempted again by the next caller.
+func defaultLookup() (*TerminalsLookup, error) {
 
-	pointers, ok := lookup_table.Load(code)
+	default_lookup_mu.Lock()
+	defer default_lookup_mu.Unlock()
 
-	if !ok {
-		return nil, fmt.Errorf("Code '%s' not found", code)
+	if default_lookup != nil {
+		return default_lookup, nil
 	}
 
-	terminals := make([]*Terminal, 0)
+	lookup, err := NewTerminalsLookup(context.Background(), "")
 
-	for _, p := range pointers.([]string) {
+	if err != nil {
+		return nil, err
+	}
 
-		if !strings.HasPrefix(p, "pointer:") {
-			return nil, fmt.Errorf("Invalid pointer '%s'", p)
-		}
+	default_lookup = lookup
+	return default_lookup, nil
+}
 
-		row, ok := lookup_table.Load(p)
+// Reload replaces the data used by the package-level `Find*` methods with data derived from 'uri'. See `NewLookup` for details on the URI options.
+func Reload(ctx context.Context, uri string) error {
 
-		if !ok {
-			return nil, fmt.Errorf("Invalid pointer '%s'", p)
-		}
+	lookup, err := defaultLookup()
 
-		terminals = append(terminals, row.(*Terminal))
+	if err != nil {
+		return err
 	}
 
-	sort.Slice(terminals, func(i, j int) bool {
+	return lookup.Reload(ctx, uri)
+}
 
-		inception_i := terminals[i].Inception
-		cessation_i := terminals[i].Cessation
+// NewLookupFromIterator will return an `architecture.Lookup` instance derived from data compiled by `CompileTerminalsData`.
+func NewLookupFromIterator(ctx context.Context, iterator_uri string, iterator_sources ...string) (architecture.Lookup, error) {
 
-		inception_j := terminals[j].Inception
-		cessation_j := terminals[j].Cessation
+	l, err := NewTerminalsLookupFromIterator(ctx, iterator_uri, iterator_sources...)
 
-		date_i := fmt.Sprintf("%s - %s", inception_i, cessation_i)
-		date_j := fmt.Sprintf("%s - %s", inception_j, cessation_j)
+	if err != nil {
+		return nil, err
+	}
 
-		return date_i < date_j
-	})
+	return architecture.NewUntypedLookup[*Terminal](l), nil
+}
+
+// NewTerminalsLookupFromIterator will return a `TerminalsLookup` instance derived from data compiled by `CompileTerminalsData`.
+func NewTerminalsLookupFromIterator(ctx context.Context, iterator_uri string, iterator_sources ...string) (*TerminalsLookup, error) {
 
-	terminals_list := make([]interface{}, len(terminals))
+	terminals_list, err := CompileTerminalsData(ctx, iterator_uri, iterator_sources...)
 
-	for idx, t := range terminals {
-		terminals_list[idx] = t
+	if err != nil {
+		return nil, fmt.Errorf("Failed to compile terminals data, %w", err)
 	}
 
-	return terminals_list, nil
+	lookup_func := NewLookupFuncWithTerminals(ctx, terminals_list)
+	return NewTerminalsLookupWithLookupFunc(ctx, lookup_func)
 }
 
-func (l *TerminalsLookup) Append(ctx context.Context, data interface{}) error {
-	return appendData(ctx, lookup_table, data.(*Terminal))
+// Reload replaces the lookup table with a new table derived from 'uri'. See `NewLookup` for details on the URI options.
+// The new table is built in full before it replaces the current table so calls to `Find` will see either the old data or the
+// new data but never a mix of both. If the new table can not be built the current table is left in place and an error is returned.
+// Any changes made to the table (for example with `Append`) while the new table is being built are lost.
+func (l *TerminalsLookup) Reload(ctx context.Context, uri string) error {
+
+	lookup_func, err := NewLookupFuncWithURI(ctx, uri)
+
+	if err != nil {
+		return fmt.Errorf("Failed to derive lookup function, %w", err)
+	}
+
+	return l.ReloadWithLookupFunc(ctx, lookup_func)
 }
 
-func appendData(ctx context.Context, table *sync.Map, data *Terminal) error {
+// ReloadWithLookupFunc replaces the lookup table with a new table derived from data compiled using `lookup_func`.
+func (l *TerminalsLookup) ReloadWithLookupFunc(ctx context.Context, lookup_func TerminalsLookupFunc) error {
+
+	terminals_list, err := lookup_func(ctx)
 
-	idx := atomic.AddInt64(&lookup_idx, 1)
+	if err != nil {
+		return fmt.Errorf("Failed to reload lookup table, %w", err)
+	}
 
-	pointer := fmt.Sprintf("pointer:%d", idx)
-	table.Store(pointer, data)
+	err = l.Replace(ctx, terminals_list)
+
+	if err != nil {
+		return fmt.Errorf("Failed to reload lookup table, %w", err)
+	}
+
+	return nil
+}
+
+// possibleCodes returns the list of codes that 'data' can be found by.
+func possibleCodes(data *Terminal) []string {
 
 	str_wofid := strconv.FormatInt(data.WhosOnFirstId, 10)
 
@@ -247,41 +415,24 @@ func appendData(ctx context.Context, table *sync.Map, data *Terminal) error {
 		possible_codes = append(possible_codes, n)
 	}
 
-	if data.SFOMuseumId != "" {
-		possible_codes = append(possible_codes, data.SFOMuseumId)
-	}
-
-	for _, code := range possible_codes {
+	for _, k_names := range data.Names {
 
-		if code == "" {
-			continue
+		for _, n := range k_names {
+			possible_codes = append(possible_codes, n)
 		}
+	}
 
-		pointers := make([]string, 0)
-		has_pointer := false
-
-		others, ok := table.Load(code)
-
-		if ok {
-
-			pointers = others.([]string)
-		}
-
-		for _, dupe := range pointers {
-
-			if dupe == pointer {
-				has_pointer = true
-				break
-			}
-		}
+	if data.SFOMuseumId != "" {
+		possible_codes = append(possible_codes, data.SFOMuseumId)
+	}
 
-		if has_pointer {
-			continue
-		}
+	return possible_codes
+}
 
-		pointers = append(pointers, pointer)
-		table.Store(code, pointers)
-	}
+func notFound(code string, reason string) error {
+	return NotFound{Code: code, Reason: reason}
+}
 
-	return nil
+func withFeature(r *Terminal, f *geojson.Feature) {
+	r.Feature = f
 }
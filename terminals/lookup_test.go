@@ -1,10 +1,20 @@
 package terminals
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/sfomuseum/go-sfomuseum-architecture"
+	"github.com/sfomuseum/go-sfomuseum-architecture/internal/testutil"
 )
 
 func TestTerminalsLookup(t *testing.T) {
@@ -38,3 +48,854 @@ func TestTerminalsLookup(t *testing.T) {
 		}
 	}
 }
+
+func TestTerminalsLookupInstances(t *testing.T) {
+
+	ctx := context.Background()
+
+	tm := &Terminal{
+		WhosOnFirstId: 1000000001,
+		Name:          "T9",
+		IsCurrent:     1,
+		Inception:     "2024",
+		Cessation:     "..",
+	}
+
+	lookup_func := NewLookupFuncWithTerminals(ctx, []*Terminal{tm})
+
+	lu_a, err := NewLookupWithLookupFunc(ctx, lookup_func)
+
+	if err != nil {
+		t.Fatalf("Failed to create first lookup, %v", err)
+	}
+
+	lu_b, err := NewLookupWithLookupFunc(ctx, lookup_func)
+
+	if err != nil {
+		t.Fatalf("Failed to create second lookup, %v", err)
+	}
+
+	tm2 := &Terminal{
+		WhosOnFirstId: 1000000002,
+		Name:          "T9",
+		IsCurrent:     0,
+		Inception:     "2020",
+		Cessation:     "2024",
+	}
+
+	err = lu_a.Append(ctx, tm2)
+
+	if err != nil {
+		t.Fatalf("Failed to append terminal, %v", err)
+	}
+
+	results_a, err := lu_a.Find(ctx, "1000000002")
+
+	if err != nil {
+		t.Fatalf("Expected to find appended terminal in first lookup, %v", err)
+	}
+
+	if len(results_a) != 1 {
+		t.Fatalf("Unexpected results for first lookup, %d", len(results_a))
+	}
+
+	_, err = lu_b.Find(ctx, "1000000002")
+
+	if err == nil {
+		t.Fatalf("Did not expect to find appended terminal in second lookup")
+	}
+
+	_, err = lu_b.Find(ctx, "1000000001")
+
+	if err != nil {
+		t.Fatalf("Failed to find terminal in second lookup, %v", err)
+	}
+
+	embedded_lu, err := NewLookup(ctx, "terminals://")
+
+	if err != nil {
+		t.Fatalf("Failed to create embedded lookup, %v", err)
+	}
+
+	_, err = embedded_lu.Find(ctx, "1000000001")
+
+	if err == nil {
+		t.Fatalf("Did not expect to find custom terminal in embedded lookup")
+	}
+}
+
+func TestTerminalsLookupReload(t *testing.T) {
+
+	ctx := context.Background()
+
+	tm := &Terminal{
+		WhosOnFirstId: 1000000001,
+		Name:          "Z1",
+		IsCurrent:     1,
+		Inception:     "2024",
+		Cessation:     "..",
+	}
+
+	lookup_func := NewLookupFuncWithTerminals(ctx, []*Terminal{tm})
+
+	lu, err := NewTerminalsLookupWithLookupFunc(ctx, lookup_func)
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	_, err = lu.Find(ctx, "T1")
+
+	if err == nil {
+		t.Fatalf("Did not expect to find T1 before reloading")
+	}
+
+	err = lu.Reload(ctx, "terminals://")
+
+	if err != nil {
+		t.Fatalf("Failed to reload lookup, %v", err)
+	}
+
+	_, err = lu.Find(ctx, "T1")
+
+	if err != nil {
+		t.Fatalf("Failed to find T1 after reloading, %v", err)
+	}
+
+	_, err = lu.Find(ctx, "1000000001")
+
+	if err == nil {
+		t.Fatalf("Did not expect to find custom terminal after reloading")
+	}
+
+	r := io.NopCloser(strings.NewReader("{"))
+
+	err = lu.ReloadWithLookupFunc(ctx, NewLookupFuncWithReader(ctx, r))
+
+	if err == nil {
+		t.Fatalf("Expected reload with invalid data to fail")
+	}
+
+	_, err = lu.Find(ctx, "T1")
+
+	if err != nil {
+		t.Fatalf("Failed to find T1 after failed reload, %v", err)
+	}
+}
+
+func TestTerminalsLookupMutate(t *testing.T) {
+
+	ctx := context.Background()
+
+	lookup_func := NewLookupFuncWithTerminals(ctx, []*Terminal{
+		&Terminal{WhosOnFirstId: 1000000001, Name: "Z1"},
+		&Terminal{WhosOnFirstId: 1000000002, Name: "Z2"},
+	})
+
+	lu, err := NewTerminalsLookupWithLookupFunc(ctx, lookup_func)
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	err = lu.Append(ctx, &Terminal{WhosOnFirstId: 1000000001, Name: "Z1"})
+
+	if err != nil {
+		t.Fatalf("Failed to append terminal, %v", err)
+	}
+
+	rsp, err := lu.Find(ctx, "Z1")
+
+	if err != nil {
+		t.Fatalf("Failed to find Z1, %v", err)
+	}
+
+	if len(rsp) != 1 {
+		t.Fatalf("Expected re-appended terminal to replace the original, got %d results", len(rsp))
+	}
+
+	err = lu.Update(ctx, &Terminal{WhosOnFirstId: 1000000001, Name: "Z9"})
+
+	if err != nil {
+		t.Fatalf("Failed to update terminal, %v", err)
+	}
+
+	_, err = lu.Find(ctx, "Z1")
+
+	if !IsNotFound(err) {
+		t.Fatalf("Expected old code to be removed by update, got %v", err)
+	}
+
+	for _, code := range []string{"Z9", "1000000001"} {
+
+		rsp, err := lu.Find(ctx, code)
+
+		if err != nil {
+			t.Fatalf("Failed to find updated terminal by %s, %v", code, err)
+		}
+
+		if len(rsp) != 1 || rsp[0].Name != "Z9" {
+			t.Fatalf("Unexpected results for %s, %v", code, rsp)
+		}
+	}
+
+	err = lu.Update(ctx, &Terminal{WhosOnFirstId: 1000000003, Name: "Z3"})
+
+	if !errors.Is(err, architecture.ErrNotFound) {
+		t.Fatalf("Expected update of unknown terminal to fail, got %v", err)
+	}
+
+	err = lu.Remove(ctx, 1000000002)
+
+	if err != nil {
+		t.Fatalf("Failed to remove terminal, %v", err)
+	}
+
+	for _, code := range []string{"Z2", "1000000002"} {
+
+		_, err := lu.Find(ctx, code)
+
+		if !IsNotFound(err) {
+			t.Fatalf("Expected removed terminal to not be found by %s, got %v", code, err)
+		}
+	}
+
+	err = lu.Remove(ctx, 1000000002)
+
+	if !errors.Is(err, architecture.ErrNotFound) {
+		t.Fatalf("Expected removal of unknown terminal to fail, got %v", err)
+	}
+
+	err = lu.AppendBatch(ctx, []*Terminal{&Terminal{WhosOnFirstId: 1000000004, Name: "Z4"}, nil})
+
+	if err == nil {
+		t.Fatalf("Expected batch with invalid terminal to fail")
+	}
+
+	_, err = lu.Find(ctx, "Z4")
+
+	if !IsNotFound(err) {
+		t.Fatalf("Expected failed batch to not be applied, got %v", err)
+	}
+
+	wg := new(sync.WaitGroup)
+
+	for i := 0; i < 50; i++ {
+
+		wg.Add(1)
+
+		go func(id int64) {
+
+			defer wg.Done()
+
+			err := lu.AppendBatch(ctx, []*Terminal{&Terminal{WhosOnFirstId: id, Name: "Z5"}, &Terminal{WhosOnFirstId: id, Name: "Z5"}})
+
+			if err != nil {
+				t.Errorf("Failed to append terminal %d, %v", id, err)
+			}
+
+		}(int64(1000000100 + i))
+	}
+
+	wg.Wait()
+
+	rsp, err = lu.Find(ctx, "Z5")
+
+	if err != nil {
+		t.Fatalf("Failed to find Z5, %v", err)
+	}
+
+	if len(rsp) != 50 {
+		t.Fatalf("Expected 50 concurrently appended terminals, got %d", len(rsp))
+	}
+
+	count, err := lu.Len(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to count terminals, %v", err)
+	}
+
+	if count != 51 {
+		t.Fatalf("Unexpected number of terminals, %d", count)
+	}
+}
+
+func TestTerminalsLookupFindAllForRange(t *testing.T) {
+
+	ctx := context.Background()
+
+	lu, err := NewTerminalsLookup(ctx, "terminals://")
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	tests := map[[3]string][]int64{
+		[3]string{"T1", "2007", "2008"}: []int64{1159396139},
+		[3]string{"T1", "2025", ".."}:   []int64{1947304259},
+	}
+
+	for q, expected := range tests {
+
+		rsp, err := lu.FindAllForRange(ctx, q[0], q[1], q[2])
+
+		if err != nil {
+			t.Fatalf("Failed to find %s for %s - %s, %v", q[0], q[1], q[2], err)
+		}
+
+		if len(rsp) != len(expected) {
+			t.Fatalf("Unexpected count for %s for %s - %s, expected %d but got %d", q[0], q[1], q[2], len(expected), len(rsp))
+		}
+
+		ids := make(map[int64]bool)
+
+		for _, r := range rsp {
+			ids[r.WhosOnFirstId] = true
+		}
+
+		for _, id := range expected {
+
+			if !ids[id] {
+				t.Fatalf("Expected %d in results for %s for %s - %s", id, q[0], q[1], q[2])
+			}
+		}
+	}
+
+	_, err = lu.FindAllForRange(ctx, "T1", "bogus", "..")
+
+	if err == nil {
+		t.Fatalf("Expected invalid range to fail")
+	}
+}
+
+func TestTerminalsLookupFindMatches(t *testing.T) {
+
+	ctx := context.Background()
+
+	lu, err := NewTerminalsLookup(ctx, "terminals://")
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	tests := map[string]string{
+		"ITB":                             MATCH_TERMINAL_ID,
+		"International Terminal":          MATCH_NAME,
+		"International Terminal Building": MATCH_PREFERRED,
+		"ITM":                             MATCH_PREFERRED,
+		"1763588123":                      MATCH_ID,
+	}
+
+	for code, source := range tests {
+
+		rsp, err := lu.FindMatches(ctx, code)
+
+		if err != nil {
+			t.Fatalf("Failed to find matches for %s, %v", code, err)
+		}
+
+		if len(rsp) == 0 {
+			t.Fatalf("Expected matches for %s", code)
+		}
+
+		for _, m := range rsp {
+
+			if m.Source != source {
+				t.Fatalf("Unexpected source for %s (%d), expected %s but got %s", code, m.Terminal.WhosOnFirstId, source, m.Source)
+			}
+		}
+	}
+
+	tm := &Terminal{
+		WhosOnFirstId: 1000000001,
+		Name:          "Test Terminal",
+		SFOMuseumId:   "TT",
+		Inception:     "2020",
+		Cessation:     "..",
+		Names: map[string][]string{
+			"eng_x_preferred":  []string{"Test Terminal"},
+			"fra_x_variant":    []string{"Terminal d'essai"},
+			"deu_x_colloquial": []string{"Testterminal"},
+		},
+	}
+
+	err = lu.Append(ctx, tm)
+
+	if err != nil {
+		t.Fatalf("Failed to append terminal, %v", err)
+	}
+
+	rsp, err := lu.FindMatches(ctx, "terminal d'essai")
+
+	if err != nil {
+		t.Fatalf("Failed to find matches for variant name, %v", err)
+	}
+
+	if len(rsp) != 1 {
+		t.Fatalf("Expected 1 match for variant name, got %d", len(rsp))
+	}
+
+	m := rsp[0]
+
+	if !m.IsVariant() || m.Language != "fra" || !m.Normalized {
+		t.Fatalf("Unexpected match for variant name: %v", m)
+	}
+
+	rsp, err = lu.FindMatches(ctx, "Testterminal")
+
+	if err != nil {
+		t.Fatalf("Failed to find matches for colloquial name, %v", err)
+	}
+
+	if len(rsp) != 1 || !rsp[0].IsVariant() || rsp[0].Language != "deu" {
+		t.Fatalf("Expected colloquial name to be matched as a variant name, %v", rsp)
+	}
+}
+
+func TestTerminalsLookupFindMatchesLanguage(t *testing.T) {
+
+	ctx := context.Background()
+
+	lu, err := NewTerminalsLookup(ctx, "terminals://")
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	current, err := lu.All(ctx, &architecture.ListFilter{Current: architecture.LIST_CURRENT})
+
+	if err != nil {
+		t.Fatalf("Failed to list current terminals, %v", err)
+	}
+
+	tested := 0
+
+	for tm := range current {
+
+		for label, names := range tm.Names {
+
+			lang, _, _ := strings.Cut(label, "_x_")
+
+			for _, n := range names {
+
+				// Names that are also codes are matched on those codes
+
+				if n == tm.Name || n == tm.SFOMuseumId {
+					continue
+				}
+
+				rsp, err := lu.FindMatches(ctx, n)
+
+				if err != nil {
+					t.Fatalf("Failed to find matches for %s, %v", n, err)
+				}
+
+				ok := false
+
+				for _, m := range rsp {
+
+					if m.Terminal.WhosOnFirstId == tm.WhosOnFirstId && m.Language == lang {
+						ok = true
+						break
+					}
+				}
+
+				if !ok {
+					t.Fatalf("Expected %s to match terminal %d in %s", n, tm.WhosOnFirstId, lang)
+				}
+
+				tested += 1
+			}
+		}
+	}
+
+	if tested == 0 {
+		t.Fatalf("Embedded terminals data does not contain localized names, it needs to be recompiled with 'make compile-terminals'")
+	}
+}
+
+func TestTerminalsLookupWriteTo(t *testing.T) {
+
+	ctx := context.Background()
+
+	lu, err := NewTerminalsLookup(ctx, "")
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	err = lu.Append(ctx, &Terminal{WhosOnFirstId: 1000000001, Name: "Z1", IsCurrent: 1, Inception: "2024", Cessation: ".."})
+
+	if err != nil {
+		t.Fatalf("Failed to append terminal, %v", err)
+	}
+
+	var buf_a bytes.Buffer
+
+	err = lu.WriteTo(ctx, &buf_a)
+
+	if err != nil {
+		t.Fatalf("Failed to write lookup, %v", err)
+	}
+
+	lookup_func := NewLookupFuncWithReader(ctx, io.NopCloser(bytes.NewReader(buf_a.Bytes())))
+
+	lu_b, err := NewTerminalsLookupWithLookupFunc(ctx, lookup_func)
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup from exported data, %v", err)
+	}
+
+	_, err = lu_b.Find(ctx, "Z1")
+
+	if err != nil {
+		t.Fatalf("Failed to find appended terminal in exported data, %v", err)
+	}
+
+	count_a, _ := lu.Len(ctx)
+	count_b, _ := lu_b.Len(ctx)
+
+	if count_a != count_b {
+		t.Fatalf("Unexpected number of terminals in exported data, expected %d but got %d", count_a, count_b)
+	}
+
+	var buf_b bytes.Buffer
+
+	err = lu_b.WriteTo(ctx, &buf_b)
+
+	if err != nil {
+		t.Fatalf("Failed to write exported lookup, %v", err)
+	}
+
+	if !bytes.Equal(buf_a.Bytes(), buf_b.Bytes()) {
+		t.Fatalf("Expected exported data to round-trip exactly")
+	}
+}
+
+func TestTerminalsList(t *testing.T) {
+
+	ctx := context.Background()
+
+	count, err := Len(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to count terminals, %v", err)
+	}
+
+	all, err := All(ctx, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to list terminals, %v", err)
+	}
+
+	listed := 0
+
+	for range all {
+		listed += 1
+	}
+
+	if count == 0 || listed != count {
+		t.Fatalf("Unexpected number of terminals, listed %d but expected %d", listed, count)
+	}
+
+	current, err := All(ctx, &architecture.ListFilter{Current: architecture.LIST_CURRENT})
+
+	if err != nil {
+		t.Fatalf("Failed to list current terminals, %v", err)
+	}
+
+	for tm := range current {
+
+		if tm.IsCurrent != 1 {
+			t.Fatalf("Terminal %d is not current", tm.WhosOnFirstId)
+		}
+	}
+
+	codes, err := Codes(ctx, &architecture.ListFilter{Date: "2024-07-25"})
+
+	if err != nil {
+		t.Fatalf("Failed to list codes, %v", err)
+	}
+
+	if !slices.Contains(codes, "T2") || !slices.IsSorted(codes) {
+		t.Fatalf("Unexpected codes for date, %v", codes)
+	}
+
+	_, err = Codes(ctx, &architecture.ListFilter{Date: "bogus"})
+
+	if err == nil {
+		t.Fatalf("Expected invalid date to fail")
+	}
+}
+
+func TestSQLiteTerminalsLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	features := map[string]string{
+		"1000000001.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000001, "wof:name": "Terminal 9", "sfomuseum:placetype": "terminal", "sfomuseum:terminal_id": "T9", "name:eng_x_variant": []string{"Zed Terminal"}, "mz:is_current": 0, "edtf:inception": "2000", "edtf:cessation": "2010"}),
+		"1000000002.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000002, "wof:name": "Terminal 9", "sfomuseum:placetype": "terminal", "sfomuseum:terminal_id": "T9", "name:eng_x_variant": []string{"Zed Terminal"}, "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+		"1000000003.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000003, "wof:name": "Terminal 9", "sfomuseum:placetype": "gate", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+	}
+
+	dsn := testutil.NewSQLiteDatabase(t, features)
+
+	lu, err := architecture.NewLookup(ctx, "terminals://sqlite?dsn="+url.QueryEscape(dsn))
+
+	if err != nil {
+		t.Fatalf("Failed to create SQLite lookup, %v", err)
+	}
+
+	typed := architecture.NewTypedLookup[*Terminal](lu)
+
+	defer typed.(*SQLiteTerminalsLookup).Close()
+
+	tests := map[string][]int64{
+		"Terminal 9": []int64{1000000001, 1000000002},
+		"T9":         []int64{1000000001, 1000000002},
+		"1000000002": []int64{1000000002},
+	}
+
+	for code, expected := range tests {
+
+		rsp, err := typed.Find(ctx, code)
+
+		if err != nil {
+			t.Fatalf("Failed to find %s, %v", code, err)
+		}
+
+		if len(rsp) != len(expected) {
+			t.Fatalf("Unexpected number of results for %s, expected %d but got %d", code, len(expected), len(rsp))
+		}
+
+		for idx, tm := range rsp {
+
+			if tm.WhosOnFirstId != expected[idx] {
+				t.Fatalf("Unexpected result %d for %s, expected %d but got %d", idx, code, expected[idx], tm.WhosOnFirstId)
+			}
+		}
+	}
+
+	_, err = typed.Find(ctx, "1000000003")
+
+	if !IsNotFound(err) {
+		t.Fatalf("Expected gate ID to not be found, got %v", err)
+	}
+
+	// Variant names and normalized codes can not be indexed so they are not matched by SQLite lookups
+
+	for _, code := range []string{"Zed Terminal", "terminal-09"} {
+
+		_, err = typed.Find(ctx, code)
+
+		if !IsNotFound(err) {
+			t.Fatalf("Expected %s to not be found, got %v", code, err)
+		}
+	}
+
+	current, err := FindCurrentTerminalWithTypedLookup(ctx, typed, "T9")
+
+	if err != nil {
+		t.Fatalf("Failed to find current terminal, %v", err)
+	}
+
+	if current.WhosOnFirstId != 1000000002 {
+		t.Fatalf("Unexpected current terminal %d", current.WhosOnFirstId)
+	}
+
+	tm, err := FindTerminalForDateWithTypedLookup(ctx, typed, "T9", "2005-06-01")
+
+	if err != nil {
+		t.Fatalf("Failed to find terminal for date, %v", err)
+	}
+
+	if tm.WhosOnFirstId != 1000000001 {
+		t.Fatalf("Unexpected terminal for date %d", tm.WhosOnFirstId)
+	}
+
+	count, err := lu.(architecture.ListableLookup).Len(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to count terminals, %v", err)
+	}
+
+	if count != 2 {
+		t.Fatalf("Unexpected number of terminals, %d", count)
+	}
+
+	all, err := lu.(architecture.ListableLookup).All(ctx, &architecture.ListFilter{Date: "2005-06-01"})
+
+	if err != nil {
+		t.Fatalf("Failed to list terminals, %v", err)
+	}
+
+	ids := make([]int64, 0)
+
+	for r := range all {
+		ids = append(ids, r.(*Terminal).WhosOnFirstId)
+	}
+
+	if len(ids) != 1 || ids[0] != 1000000001 {
+		t.Fatalf("Unexpected terminals for date, %v", ids)
+	}
+
+	err = typed.Append(ctx, tm)
+
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("Expected append to be unsupported, got %v", err)
+	}
+
+	_, err = architecture.NewLookup(ctx, "terminals://sqlite")
+
+	if err == nil {
+		t.Fatalf("Expected sqlite URI without a DSN to fail")
+	}
+}
+
+func TestSQLiteTerminalsLookupCodes(t *testing.T) {
+
+	ctx := context.Background()
+
+	features := map[string]string{
+		"1000000001.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000001, "wof:name": "Terminal Z", "sfomuseum:placetype": "terminal", "sfomuseum:terminal_id": "TZ", "mz:is_current": 0, "edtf:inception": "2000", "edtf:cessation": "2010"}),
+		"1000000002.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000002, "wof:name": "Terminal Z", "sfomuseum:placetype": "terminal", "sfomuseum:terminal_id": "TZ", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+		"1000000003.geojson": testutil.Feature(t, map[string]any{"wof:id": 1000000003, "wof:name": "Terminal Y", "sfomuseum:placetype": "terminal", "mz:is_current": 0, "edtf:inception": "2000", "edtf:cessation": "2010"}),
+	}
+
+	dsn := testutil.NewSQLiteDatabase(t, features)
+
+	lu, err := NewSQLiteTerminalsLookup(ctx, "terminals://sqlite?dsn="+url.QueryEscape(dsn))
+
+	if err != nil {
+		t.Fatalf("Failed to create SQLite lookup, %v", err)
+	}
+
+	defer lu.Close()
+
+	codes, err := lu.Codes(ctx, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to list codes, %v", err)
+	}
+
+	if !slices.Equal(codes, []string{"TZ", "Terminal Y"}) {
+		t.Fatalf("Unexpected codes, %v", codes)
+	}
+
+	// The codes selected by the database should be the same as the codes derived from the records themselves
+
+	all, err := lu.All(ctx, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to list terminals, %v", err)
+	}
+
+	if record_codes := architecture.RecordCodes(slices.Collect(all)); !slices.Equal(codes, record_codes) {
+		t.Fatalf("Expected codes to match the records' codes, %v", record_codes)
+	}
+
+	codes, err = lu.Codes(ctx, &architecture.ListFilter{Current: architecture.LIST_CURRENT})
+
+	if err != nil {
+		t.Fatalf("Failed to list current codes, %v", err)
+	}
+
+	if !slices.Equal(codes, []string{"TZ"}) {
+		t.Fatalf("Unexpected current codes, %v", codes)
+	}
+}
+
+func TestReaderTerminalsLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	features := map[int64]string{
+		1000000001: testutil.Feature(t, map[string]any{"wof:id": 1000000001, "wof:name": "Terminal 9", "sfomuseum:placetype": "terminal", "sfomuseum:terminal_id": "T9", "mz:is_current": 0, "edtf:inception": "2000", "edtf:cessation": "2010"}),
+		1000000002: testutil.Feature(t, map[string]any{"wof:id": 1000000002, "wof:name": "Terminal 9", "sfomuseum:placetype": "terminal", "sfomuseum:terminal_id": "T9", "mz:is_current": 1, "edtf:inception": "2010", "edtf:cessation": ".."}),
+	}
+
+	reader_uri := testutil.NewReaderData(t, features)
+
+	index := []byte(`[{"wof:id": 1000000001, "wof:name": "Terminal 9", "sfomuseum:terminal_id": "T9"}, {"wof:id": 1000000002, "wof:name": "Terminal 9", "sfomuseum:terminal_id": "T9"}, {"wof:id": 1000000003, "wof:name": "Terminal 8"}]`)
+
+	index_path := filepath.Join(t.TempDir(), "terminals.json")
+
+	err := os.WriteFile(index_path, index, 0644)
+
+	if err != nil {
+		t.Fatalf("Failed to write index, %v", err)
+	}
+
+	q := url.Values{}
+	q.Set("reader", reader_uri)
+	q.Set("index", "terminals://file?path="+url.QueryEscape(index_path))
+
+	lu, err := architecture.NewLookup(ctx, "terminals://reader?"+q.Encode())
+
+	if err != nil {
+		t.Fatalf("Failed to create reader lookup, %v", err)
+	}
+
+	typed := architecture.NewTypedLookup[*Terminal](lu)
+
+	rsp, err := typed.Find(ctx, "terminal-09")
+
+	if err != nil {
+		t.Fatalf("Failed to find T9, %v", err)
+	}
+
+	if len(rsp) != 2 || rsp[0].WhosOnFirstId != 1000000001 || rsp[1].WhosOnFirstId != 1000000002 {
+		t.Fatalf("Unexpected results for T9, %v", rsp)
+	}
+
+	for _, tm := range rsp {
+
+		if tm.Feature == nil || tm.Feature.Geometry.GeoJSONType() != "Point" {
+			t.Fatalf("Expected terminal %d to have a point geometry", tm.WhosOnFirstId)
+		}
+
+		if tm.Feature.Properties.MustString("sfomuseum:placetype") != "terminal" {
+			t.Fatalf("Expected terminal %d to have all its properties", tm.WhosOnFirstId)
+		}
+	}
+
+	current, err := FindCurrentTerminalWithTypedLookup(ctx, typed, "T9")
+
+	if err != nil {
+		t.Fatalf("Failed to find current terminal, %v", err)
+	}
+
+	if current != rsp[1] {
+		t.Fatalf("Expected current terminal to be read from the cache")
+	}
+
+	_, err = typed.Find(ctx, "Terminal 8")
+
+	if err == nil {
+		t.Fatalf("Expected terminal missing from reader to fail")
+	}
+
+	_, err = typed.Find(ctx, "Terminal 7")
+
+	if !IsNotFound(err) {
+		t.Fatalf("Expected Terminal 7 to not be found, got %v", err)
+	}
+
+	err = typed.Append(ctx, &Terminal{WhosOnFirstId: 1000000004, Name: "Terminal 7"})
+
+	if err != nil {
+		t.Fatalf("Failed to append terminal, %v", err)
+	}
+
+	rsp, err = typed.Find(ctx, "Terminal 7")
+
+	if err != nil || len(rsp) != 1 || rsp[0].WhosOnFirstId != 1000000004 {
+		t.Fatalf("Failed to find appended terminal, %v", err)
+	}
+
+	_, err = architecture.NewLookup(ctx, "terminals://reader")
+
+	if err == nil {
+		t.Fatalf("Expected reader URI without a reader to fail")
+	}
+}
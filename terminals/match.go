@@ -0,0 +1,191 @@
+package terminals
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+)
+
+// The terminal matched on its `sfomuseum:terminal_id` property.
+const MATCH_TERMINAL_ID string = "sfomuseum:terminal_id"
+
+// The terminal matched on its `wof:name` property.
+const MATCH_NAME string = "wof:name"
+
+// The terminal matched on its `wof:id` property.
+const MATCH_ID string = "wof:id"
+
+// The terminal matched on one of its preferred names.
+const MATCH_PREFERRED string = "preferred"
+
+// The terminal matched on one of its variant names.
+const MATCH_VARIANT string = "variant"
+
+// type TerminalMatch is a struct describing a `Terminal` record and the name or code by which it was matched.
+type TerminalMatch struct {
+	// The terminal that was matched.
+	Terminal *Terminal `json:"terminal"`
+	// The name or code that was matched.
+	Name string `json:"name"`
+	// The kind of name or code that was matched. One of the MATCH_* constants.
+	Source string `json:"source"`
+	// The (RFC 5646) language of the matched name, if known.
+	Language string `json:"language,omitempty"`
+	// Boolean flag signaling that the match was made using normalized values.
+	Normalized bool `json:"normalized"`
+}
+
+// IsPreferred reports whether the match was made on a preferred name.
+func (m *TerminalMatch) IsPreferred() bool {
+	return m.Source == MATCH_PREFERRED
+}
+
+// IsVariant reports whether the match was made on a variant name.
+func (m *TerminalMatch) IsVariant() bool {
+	return m.Source == MATCH_VARIANT
+}
+
+// FindMatches returns the list of `TerminalMatch` records for 'code' reporting which name (or code) each terminal was matched by.
+func (l *TerminalsLookup) FindMatches(ctx context.Context, code string) ([]*TerminalMatch, error) {
+
+	terminals, err := l.Find(ctx, code)
+
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]*TerminalMatch, 0)
+
+	for _, t := range terminals {
+
+		m, ok := matchTerminal(t, code, false)
+
+		if !ok {
+			m, ok = matchTerminal(t, architecture.NormalizeCode(code), true)
+		}
+
+		if !ok {
+			return nil, fmt.Errorf("Failed to determine how terminal %d matches '%s'", t.WhosOnFirstId, code)
+		}
+
+		matches = append(matches, m)
+	}
+
+	return matches, nil
+}
+
+// FindTerminalMatches returns the list of `TerminalMatch` records for 'code'. See `TerminalsLookup.FindMatches` for details.
+func FindTerminalMatches(ctx context.Context, code string) ([]*TerminalMatch, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.FindMatches(ctx, code)
+}
+
+func matchTerminal(t *Terminal, code string, normalized bool) (*TerminalMatch, bool) {
+
+	eq := func(name string) bool {
+
+		if normalized {
+			return name != "" && architecture.NormalizeCode(name) == code
+		}
+
+		return name == code
+	}
+
+	newMatch := func(name string, source string, lang string) (*TerminalMatch, bool) {
+
+		m := &TerminalMatch{
+			Terminal:   t,
+			Name:       name,
+			Source:     source,
+			Language:   lang,
+			Normalized: normalized,
+		}
+
+		return m, true
+	}
+
+	if eq(t.SFOMuseumId) {
+		return newMatch(t.SFOMuseumId, MATCH_TERMINAL_ID, "")
+	}
+
+	if eq(t.Name) {
+		return newMatch(t.Name, MATCH_NAME, "")
+	}
+
+	str_id := strconv.FormatInt(t.WhosOnFirstId, 10)
+
+	if eq(str_id) {
+		return newMatch(str_id, MATCH_ID, "")
+	}
+
+	// Names keyed by language, sorted so that matches are stable and preferred names are checked before variant names
+
+	labels := make([]string, 0)
+
+	for k := range t.Names {
+		labels = append(labels, k)
+	}
+
+	sort.Slice(labels, func(i, j int) bool {
+
+		pref_i := strings.HasSuffix(labels[i], "_preferred")
+		pref_j := strings.HasSuffix(labels[j], "_preferred")
+
+		if pref_i != pref_j {
+			return pref_i
+		}
+
+		return labels[i] < labels[j]
+	})
+
+	for _, k := range labels {
+
+		lang, _, _ := strings.Cut(k, "_x_")
+
+		// Names are only compiled for preferred and variant labels but records may have been derived from other sources, so
+		// anything that is not a preferred name is reported as a variant
+
+		source := MATCH_VARIANT
+
+		if strings.HasSuffix(k, "_preferred") {
+			source = MATCH_PREFERRED
+		}
+
+		for _, n := range t.Names[k] {
+
+			if !eq(n) {
+				continue
+			}
+
+			return newMatch(n, source, lang)
+		}
+	}
+
+	// Names without language information
+
+	for _, n := range t.PreferredNames {
+
+		if eq(n) {
+			return newMatch(n, MATCH_PREFERRED, "")
+		}
+	}
+
+	for _, n := range t.VariantNames {
+
+		if eq(n) {
+			return newMatch(n, MATCH_VARIANT, "")
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,101 @@
+package terminals
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+)
+
+// FindTerminalForParentWithLookup returns the Terminal with Who's On First ID 'id' as it existed between 'inception' and 'cessation'
+// using 'lookup'. It is meant for resolving the parent terminal of an architectural element (a gate or a gallery) whose `wof:hierarchy`
+// may point to a terminal record for a different period than the element itself. If the terminal record for 'id' does not overlap
+// 'inception' and 'cessation' then the terminal with the same code that does is returned instead. Multiple matches throw an error.
+func FindTerminalForParentWithLookup(ctx context.Context, lookup architecture.TypedLookup[*Terminal], id int64, inception string, cessation string) (*Terminal, error) {
+
+	str_id := strconv.FormatInt(id, 10)
+
+	rsp, err := lookup.Find(ctx, str_id)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find terminal '%s', %w", str_id, err)
+	}
+
+	var parent *Terminal
+
+	for _, t := range rsp {
+
+		if t.WhosOnFirstId == id {
+			parent = t
+			break
+		}
+	}
+
+	if parent == nil {
+		return nil, NotFound{Code: str_id, Reason: architecture.REASON_UNKNOWN_CODE}
+	}
+
+	span, err := architecture.NewSpan(inception, cessation)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive span for %s - %s, %w", inception, cessation, err)
+	}
+
+	overlaps := func(t *Terminal) (bool, error) {
+
+		t_span, err := architecture.NewSpan(t.Inception, t.Cessation)
+
+		if err != nil {
+			return false, fmt.Errorf("Failed to derive span for terminal %d, %w", t.WhosOnFirstId, err)
+		}
+
+		return t_span.Overlaps(span), nil
+	}
+
+	ok, err := overlaps(parent)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		return parent, nil
+	}
+
+	code := parent.Code()
+
+	rsp, err = lookup.Find(ctx, code)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find terminal '%s', %w", code, err)
+	}
+
+	candidates := make([]*Terminal, 0)
+
+	for _, t := range rsp {
+
+		ok, err := overlaps(t)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			candidates = append(candidates, t)
+		}
+	}
+
+	// An EDTF interval for the period being queried
+
+	date := fmt.Sprintf("%s/%s", inception, cessation)
+
+	switch len(candidates) {
+	case 0:
+		return nil, NotFound{Code: code, Date: date, Reason: architecture.REASON_NO_DATE_MATCH}
+	case 1:
+		return candidates[0], nil
+	default:
+		return nil, MultipleCandidates{Code: code, Date: date, Reason: architecture.REASON_MULTIPLE_DATE_MATCH, Candidates: candidates}
+	}
+}
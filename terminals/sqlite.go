@@ -0,0 +1,72 @@
+package terminals
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sfomuseum/go-sfomuseum-architecture"
+)
+
+// The indices, on the `geojson` table, used by `SQLiteTerminalsLookup` in addition to the placetype index and those created by `campus.NewDatabaseWithIterator`.
+var sqlite_indices = map[string]string{
+	"geojson_by_sfomuseum_terminal_id": sqlite_terminal_id_expr,
+}
+
+// The queries used by `SQLiteTerminalsLookup` to find terminals by their Who's On First ID, name or SFO Museum terminal ID. Preferred and
+// variant names are not matched.
+var sqlite_queries = []*architecture.SQLiteCodeQuery{
+	architecture.SQLITE_QUERY_BY_ID,
+	architecture.SQLITE_QUERY_BY_NAME,
+	architecture.NewSQLiteCodeQuery("SFO Museum ID", sqlite_terminal_id_expr, false),
+}
+
+const sqlite_terminal_id_expr string = `JSON_EXTRACT(body, '$.properties."sfomuseum:terminal_id"')`
+
+// The SQL expression that derives the same value as `Terminal.Code`, the terminal's SFO Museum terminal ID or else its name.
+const sqlite_code_expr string = `COALESCE(NULLIF(CAST(JSON_EXTRACT(body, '$.properties."sfomuseum:terminal_id"') AS TEXT), ''), JSON_EXTRACT(body, '$.properties."wof:name"'))`
+
+// SQLiteTerminalsLookup implements the `architecture.TypedLookup[*Terminal]` interface for terminals stored in a Who's On First SQLite database,
+// as produced by `campus.NewDatabaseWithIterator`. Records are queried from the database, by their Who's On First ID, name or SFO Museum terminal ID, each time
+// `Find` is called rather than being loaded in to memory ahead of time. The current and date-based finders (for example `FindCurrentTerminalWithLookup`)
+// filter the records returned by `Find` for a code so they only ever evaluate the handful of records sharing that code. See `architecture.SQLiteLookup`
+// for details.
+type SQLiteTerminalsLookup struct {
+	*architecture.SQLiteLookup[*Terminal]
+}
+
+// NewSQLiteTerminalsLookup will return a `SQLiteTerminalsLookup` instance derived from 'uri' which is expected to take the form:
+//
+//	`terminals://sqlite?dsn={DSN}`
+//
+// Where `{DSN}` is the data source name of a Who's On First SQLite database. See `architecture.NewSQLiteDatabaseWithURL` for details
+// on the other parameters.
+func NewSQLiteTerminalsLookup(ctx context.Context, uri string) (*SQLiteTerminalsLookup, error) {
+
+	sqlite_lookup, err := architecture.NewSQLiteLookup(ctx, record_kind, uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := &SQLiteTerminalsLookup{
+		SQLiteLookup: sqlite_lookup,
+	}
+
+	return l, nil
+}
+
+// NewSQLiteTerminalsLookupWithDatabase will return a `SQLiteTerminalsLookup` instance for 'db'. No indices are created.
+func NewSQLiteTerminalsLookupWithDatabase(ctx context.Context, db *sql.DB) (*SQLiteTerminalsLookup, error) {
+
+	sqlite_lookup, err := architecture.NewSQLiteLookupWithDatabase(ctx, record_kind, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := &SQLiteTerminalsLookup{
+		SQLiteLookup: sqlite_lookup,
+	}
+
+	return l, nil
+}
@@ -4,21 +4,46 @@ package terminals
 import (
 	"context"
 	"fmt"
+	"iter"
 	"log/slog"
 
+	"github.com/paulmach/orb/geojson"
 	"github.com/sfomuseum/go-edtf/cmp"
 	"github.com/sfomuseum/go-sfomuseum-architecture"
 )
 
-// To do: Make these sortable by inception/cessation
+// The default `architecture.ResolutionPolicy` used by the date-based finders which is to return the first (earliest) terminal matching a date.
+var DEFAULT_RESOLUTION_POLICY architecture.ResolutionPolicy = architecture.RESOLVE_FIRST_MATCH
+
+// type Terminals is a list of `Terminal` records that can be sorted in chronological order. See `architecture.CompareDates` for details.
+// Records whose dates can not be distinguished are sorted by their Who's On First IDs.
 type Terminals []*Terminal
 
+func (c Terminals) Len() int {
+	return len(c)
+}
+
+func (c Terminals) Less(i, j int) bool {
+
+	cmp := architecture.CompareDates(c[i].Inception, c[i].Cessation, c[j].Inception, c[j].Cessation)
+
+	if cmp != 0 {
+		return cmp < 0
+	}
+
+	return c[i].WhosOnFirstId < c[j].WhosOnFirstId
+}
+
+func (c Terminals) Swap(i, j int) {
+	c[i], c[j] = c[j], c[i]
+}
+
 // type Terminal is a struct representing a passenger terminal at SFO.
 type Terminal struct {
 	// The Who's On First ID associated with this terminal.
 	WhosOnFirstId int64 `json:"wof:id"`
 	// The SFO Museum name/label for this terminal
-	SFOMuseumId string `json:"sfomuseum:terminal_id,omityempty"`
+	SFOMuseumId string `json:"sfomuseum:terminal_id,omitempty"`
 	// The name of this terminal.
 	Name string `json:"wof:name"`
 	// A Who's On First "existential" (`KnownUnknownFlag`) flag signaling the terminal's status
@@ -27,10 +52,23 @@ type Terminal struct {
 	PreferredNames []string `json:"name:preferred,omitempty"`
 	// The list of name:{LANG}_x_variant names for this terminal
 	VariantNames []string `json:"name:variant,omitempty"`
+	// The preferred and variant names for this terminal keyed by their "{LANG}_x_{PREFERRED|VARIANT}" labels
+	Names map[string][]string `json:"names,omitempty"`
 	// The (EDTF) inception date for the gallery
 	Inception string `json:"edtf:inception"`
 	// The (EDTF) cessation date for the gallery
 	Cessation string `json:"edtf:cessation"`
+	// The Who's On First ID of the terminal's parent (typically the SFO campus).
+	ParentId int64 `json:"wof:parent_id,omitempty"`
+	// The list of Who's On First IDs that this terminal supersedes.
+	Supersedes []int64 `json:"wof:supersedes,omitempty"`
+	// The list of Who's On First IDs that this terminal is superseded by.
+	SupersededBy []int64 `json:"wof:superseded_by,omitempty"`
+	// The (EDTF) deprecated date for the terminal, if it has been deprecated.
+	Deprecated string `json:"edtf:deprecated,omitempty"`
+	// The Who's On First GeoJSON Feature for the terminal, including its geometry and all of its properties. It is only populated by
+	// lookups that load records on demand, for example `ReaderTerminalsLookup`, and is never encoded.
+	Feature *geojson.Feature `json:"-"`
 }
 
 // String() will return the name of the terminal.
@@ -38,46 +76,153 @@ func (g *Terminal) String() string {
 	return fmt.Sprintf("%d %s %s-%s (%d)", g.WhosOnFirstId, g.Name, g.Inception, g.Cessation, g.IsCurrent)
 }
 
+// Code returns the primary code for the terminal which is its SFO Museum ID or, if empty, its name.
+func (t *Terminal) Code() string {
+
+	if t.SFOMuseumId != "" {
+		return t.SFOMuseumId
+	}
+
+	return t.Name
+}
+
+// Dates returns the (EDTF) inception and cessation dates for the terminal.
+func (t *Terminal) Dates() (string, string) {
+	return t.Inception, t.Cessation
+}
+
+// Id returns the Who's On First ID for the terminal.
+func (t *Terminal) Id() int64 {
+	return t.WhosOnFirstId
+}
+
+// Placetype returns the SFO Museum placetype for the terminal which is always `PLACETYPE`.
+func (t *Terminal) Placetype() string {
+	return PLACETYPE
+}
+
+// Status returns whether the terminal is current, superseded or deprecated.
+func (t *Terminal) Status() *architecture.Status {
+	return architecture.NewStatus(t.IsCurrent, t.SupersededBy, t.Deprecated)
+}
+
 // Return the Terminal matching 'code' that was active for 'date'. Multiple matches throw an error.
 func FindTerminalForDate(ctx context.Context, code string, date string) (*Terminal, error) {
 
-	lookup, err := NewLookup(ctx, "")
+	lookup, err := defaultLookup()
 
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
 	}
 
-	return FindTerminalForDateWithLookup(ctx, lookup, code, date)
+	return FindTerminalForDateWithTypedLookup(ctx, lookup, code, date)
 }
 
 // Return all the Terminals matching 'code' that were active for 'date'.
 func FindAllTerminalsForDate(ctx context.Context, code string, date string) ([]*Terminal, error) {
 
-	lookup, err := NewLookup(ctx, "")
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return FindAllTerminalsForDateWithTypedLookup(ctx, lookup, code, date)
+}
+
+// Return all the Terminals matching 'code' that existed at any point between 'start' and 'end'. See `TerminalsLookup.FindAllForRange` for details.
+func FindAllTerminalsForRange(ctx context.Context, code string, start string, end string) ([]*Terminal, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.FindAllForRange(ctx, code, start, end)
+}
+
+// SnapshotForDate returns a snapshot of all the Terminals that were active on 'date' keyed by their codes. See `TerminalsLookup.SnapshotForDate` for details.
+func SnapshotForDate(ctx context.Context, date string) (*architecture.Snapshot[*Terminal], error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.SnapshotForDate(ctx, date)
+}
+
+// Suggest returns a ranked list of terminal codes that are similar to 'code'. See `TerminalsLookup.Suggest` for details.
+func Suggest(ctx context.Context, code string) ([]*architecture.Suggestion, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Suggest(ctx, code)
+}
+
+// Codes returns the sorted list of (primary) codes for all the Terminals matching 'filter'. See `TerminalsLookup.Codes` for details.
+func Codes(ctx context.Context, filter *architecture.ListFilter) ([]string, error) {
+
+	lookup, err := defaultLookup()
 
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
 	}
 
-	return FindAllTerminalsForDateWithLookup(ctx, lookup, code, date)
+	return lookup.Codes(ctx, filter)
+}
+
+// All returns an iterator over all the Terminals matching 'filter' in chronological order. See `TerminalsLookup.All` for details.
+func All(ctx context.Context, filter *architecture.ListFilter) (iter.Seq[*Terminal], error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.All(ctx, filter)
+}
+
+// Len returns the total number of Terminals.
+func Len(ctx context.Context) (int, error) {
+
+	lookup, err := defaultLookup()
+
+	if err != nil {
+		return 0, fmt.Errorf("Failed to create new lookup, %w", err)
+	}
+
+	return lookup.Len(ctx)
 }
 
 // Return the current Terminal matching 'code'. Multiple matches throw an error.
 func FindCurrentTerminal(ctx context.Context, code string) (*Terminal, error) {
 
-	lookup, err := NewLookup(ctx, "")
+	lookup, err := defaultLookup()
 
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
 	}
 
-	return FindCurrentTerminalWithLookup(ctx, lookup, code)
+	return FindCurrentTerminalWithTypedLookup(ctx, lookup, code)
 }
 
 // Return the current Terminal matching 'code' with a custom architecture.Lookup instance. Multiple matches throw an error.
 func FindCurrentTerminalWithLookup(ctx context.Context, lookup architecture.Lookup, code string) (*Terminal, error) {
+	return FindCurrentTerminalWithTypedLookup(ctx, architecture.NewTypedLookup[*Terminal](lookup), code)
+}
 
-	current, err := FindTerminalsCurrentWithLookup(ctx, lookup, code)
+// FindCurrentTerminalWithTypedLookup is the same as `FindCurrentTerminalWithLookup` but with a custom architecture.TypedLookup[*Terminal] instance.
+func FindCurrentTerminalWithTypedLookup(ctx context.Context, lookup architecture.TypedLookup[*Terminal], code string) (*Terminal, error) {
+
+	current, err := FindTerminalsCurrentWithTypedLookup(ctx, lookup, code)
 
 	if err != nil {
 		return nil, err
@@ -85,11 +230,11 @@ func FindCurrentTerminalWithLookup(ctx context.Context, lookup architecture.Look
 
 	switch len(current) {
 	case 0:
-		return nil, NotFound{code}
+		return nil, NotFound{Code: code, Reason: architecture.REASON_NO_CURRENT}
 	case 1:
 		return current[0], nil
 	default:
-		return nil, MultipleCandidates{code}
+		return nil, MultipleCandidates{Code: code, Reason: architecture.REASON_MULTIPLE_CURRENT, Candidates: current}
 	}
 
 }
@@ -97,17 +242,22 @@ func FindCurrentTerminalWithLookup(ctx context.Context, lookup architecture.Look
 // Returns all Terminal instances matching 'code' that are marked as current.
 func FindTerminalsCurrent(ctx context.Context, code string) ([]*Terminal, error) {
 
-	lookup, err := NewLookup(ctx, "")
+	lookup, err := defaultLookup()
 
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create new lookup, %w", err)
 	}
 
-	return FindTerminalsCurrentWithLookup(ctx, lookup, code)
+	return FindTerminalsCurrentWithTypedLookup(ctx, lookup, code)
 }
 
 // Returns all Terminal instances matching 'code' that are marked as current with a custom architecture.Lookup instance.
 func FindTerminalsCurrentWithLookup(ctx context.Context, lookup architecture.Lookup, code string) ([]*Terminal, error) {
+	return FindTerminalsCurrentWithTypedLookup(ctx, architecture.NewTypedLookup[*Terminal](lookup), code)
+}
+
+// FindTerminalsCurrentWithTypedLookup is the same as `FindTerminalsCurrentWithLookup` but with a custom architecture.TypedLookup[*Terminal] instance.
+func FindTerminalsCurrentWithTypedLookup(ctx context.Context, lookup architecture.TypedLookup[*Terminal], code string) ([]*Terminal, error) {
 
 	rsp, err := lookup.Find(ctx, code)
 
@@ -117,9 +267,7 @@ func FindTerminalsCurrentWithLookup(ctx context.Context, lookup architecture.Loo
 
 	current := make([]*Terminal, 0)
 
-	for _, r := range rsp {
-
-		g := r.(*Terminal)
+	for _, g := range rsp {
 
 		// if g.IsCurrent == 0 {
 		if g.IsCurrent != 1 {
@@ -132,10 +280,20 @@ func FindTerminalsCurrentWithLookup(ctx context.Context, lookup architecture.Loo
 	return current, nil
 }
 
-// Return the Terminal matching 'code' that was active for 'date' using 'lookup'. Multiple matches throw an error.
+// Return the Terminal matching 'code' that was active for 'date' using the architecture.Lookup instance 'lookup' and `DEFAULT_RESOLUTION_POLICY`. Multiple matches throw an error.
 func FindTerminalForDateWithLookup(ctx context.Context, lookup architecture.Lookup, code string, date string) (*Terminal, error) {
+	return FindTerminalForDateWithTypedLookup(ctx, architecture.NewTypedLookup[*Terminal](lookup), code, date)
+}
+
+// FindTerminalForDateWithTypedLookup is the same as `FindTerminalForDateWithLookup` but with a custom architecture.TypedLookup[*Terminal] instance.
+func FindTerminalForDateWithTypedLookup(ctx context.Context, lookup architecture.TypedLookup[*Terminal], code string, date string) (*Terminal, error) {
+	return FindTerminalForDateWithPolicy(ctx, lookup, code, date, DEFAULT_RESOLUTION_POLICY)
+}
+
+// Return the Terminal matching 'code' that was active for 'date' using 'lookup' and 'policy'. Multiple matches throw an error.
+func FindTerminalForDateWithPolicy(ctx context.Context, lookup architecture.TypedLookup[*Terminal], code string, date string, policy architecture.ResolutionPolicy) (*Terminal, error) {
 
-	terminals, err := FindAllTerminalsForDateWithLookup(ctx, lookup, code, date)
+	terminals, err := FindAllTerminalsForDateWithPolicy(ctx, lookup, code, date, policy)
 
 	if err != nil {
 		return nil, err
@@ -143,17 +301,27 @@ func FindTerminalForDateWithLookup(ctx context.Context, lookup architecture.Look
 
 	switch len(terminals) {
 	case 0:
-		return nil, NotFound{code}
+		return nil, NotFound{Code: code, Date: date, Reason: architecture.REASON_NO_DATE_MATCH}
 	case 1:
 		return terminals[0], nil
 	default:
-		return nil, MultipleCandidates{code}
+		return nil, MultipleCandidates{Code: code, Date: date, Reason: architecture.REASON_MULTIPLE_DATE_MATCH, Candidates: terminals}
 	}
-
 }
 
-// Return all the Terminals matching 'code' that were active for 'date' using 'lookup'.
+// Return all the Terminals matching 'code' that were active for 'date' using the architecture.Lookup instance 'lookup' and `DEFAULT_RESOLUTION_POLICY`.
 func FindAllTerminalsForDateWithLookup(ctx context.Context, lookup architecture.Lookup, code string, date string) ([]*Terminal, error) {
+	return FindAllTerminalsForDateWithTypedLookup(ctx, architecture.NewTypedLookup[*Terminal](lookup), code, date)
+}
+
+// FindAllTerminalsForDateWithTypedLookup is the same as `FindAllTerminalsForDateWithLookup` but with a custom architecture.TypedLookup[*Terminal] instance.
+func FindAllTerminalsForDateWithTypedLookup(ctx context.Context, lookup architecture.TypedLookup[*Terminal], code string, date string) ([]*Terminal, error) {
+	return FindAllTerminalsForDateWithPolicy(ctx, lookup, code, date, DEFAULT_RESOLUTION_POLICY)
+}
+
+// Return all the Terminals matching 'code' that were active for 'date' using 'lookup', choosing between multiple matches using 'policy'.
+// See `architecture.ResolutionPolicy` for details.
+func FindAllTerminalsForDateWithPolicy(ctx context.Context, lookup architecture.TypedLookup[*Terminal], code string, date string, policy architecture.ResolutionPolicy) ([]*Terminal, error) {
 
 	rsp, err := lookup.Find(ctx, code)
 
@@ -163,9 +331,7 @@ func FindAllTerminalsForDateWithLookup(ctx context.Context, lookup architecture.
 
 	terminals := make([]*Terminal, 0)
 
-	for _, r := range rsp {
-
-		g := r.(*Terminal)
+	for _, g := range rsp {
 
 		inception := g.Inception
 		cessation := g.Cessation
@@ -178,15 +344,27 @@ func FindAllTerminalsForDateWithLookup(ctx context.Context, lookup architecture.
 		}
 
 		if !is_between {
-			slog.Debug("Terminal does not match date conditions", "code", code, "date", date, "terminal", g.Name, "inception", inception, "cessation", cessation)
+			slog.Debug("Terminal does not match date conditions", "id", g.WhosOnFirstId, "code", code, "date", date, "terminal", g.Name, "inception", inception, "cessation", cessation)
 			continue
 		}
 
-		slog.Debug("Terminal DOES match date conditions", "code", code, "date", date, "terminal", g.Name, "inception", inception, "cessation", cessation)
+		slog.Debug("Terminal DOES match date conditions", "id", g.WhosOnFirstId, "code", code, "date", date, "terminal", g.Name, "inception", inception, "cessation", cessation)
 		terminals = append(terminals, g)
-		break
 	}
 
-	slog.Debug("Return terminals", "code", code, "date", date, "count", len(terminals))
+	terminals = architecture.ApplyResolutionPolicy(policy, date, terminals, resolutionCandidate)
+
+	slog.Debug("Return terminals", "code", code, "date", date, "policy", policy, "count", len(terminals))
 	return terminals, nil
 }
+
+func resolutionCandidate(g *Terminal) *architecture.ResolutionCandidate {
+
+	c := &architecture.ResolutionCandidate{
+		IsCurrent: g.IsCurrent,
+		Inception: g.Inception,
+		Cessation: g.Cessation,
+	}
+
+	return c
+}
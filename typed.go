@@ -0,0 +1,290 @@
+package architecture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// TypedLookup is a type-safe variant of the `Lookup` interface where results are returned, and data appended, as instances of 'T'
+// rather than `interface{}`.
+type TypedLookup[T any] interface {
+	Find(context.Context, string) ([]T, error)
+	Append(context.Context, T) error
+}
+
+// NewTypedLookup returns a `TypedLookup` instance for 'l'. If 'l' was created by `NewUntypedLookup` then the original `TypedLookup`
+// instance is returned. Otherwise results returned by 'l' are type-checked and an error (rather than a panic) is returned for any
+// result that is not an instance of 'T'.
+func NewTypedLookup[T any](l Lookup) TypedLookup[T] {
+
+	u, ok := l.(*UntypedLookup[T])
+
+	if ok {
+		return u.Typed()
+	}
+
+	t := &typedLookup[T]{
+		lookup: l,
+	}
+
+	return t
+}
+
+type typedLookup[T any] struct {
+	lookup Lookup
+}
+
+func (l *typedLookup[T]) Find(ctx context.Context, code string) ([]T, error) {
+
+	rsp, err := l.lookup.Find(ctx, code)
+
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]T, len(rsp))
+
+	for idx, r := range rsp {
+
+		v, ok := r.(T)
+
+		if !ok {
+			return nil, fmt.Errorf("Invalid result type (%T) for code '%s'", r, code)
+		}
+
+		results[idx] = v
+	}
+
+	return results, nil
+}
+
+func (l *typedLookup[T]) Append(ctx context.Context, data T) error {
+	return l.lookup.Append(ctx, data)
+}
+
+// UntypedLookup wraps a `TypedLookup` instance so that it can be used anywhere an `architecture.Lookup` instance is expected.
+type UntypedLookup[T any] struct {
+	typed TypedLookup[T]
+}
+
+// NewUntypedLookup returns an `architecture.Lookup` instance wrapping 'l'.
+func NewUntypedLookup[T any](l TypedLookup[T]) Lookup {
+
+	u := &UntypedLookup[T]{
+		typed: l,
+	}
+
+	return u
+}
+
+// Typed returns the underlying `TypedLookup` instance.
+func (l *UntypedLookup[T]) Typed() TypedLookup[T] {
+	return l.typed
+}
+
+func (l *UntypedLookup[T]) Find(ctx context.Context, code string) ([]interface{}, error) {
+
+	rsp, err := l.typed.Find(ctx, code)
+
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]interface{}, len(rsp))
+
+	for idx, r := range rsp {
+		results[idx] = r
+	}
+
+	return results, nil
+}
+
+func (l *UntypedLookup[T]) Append(ctx context.Context, data interface{}) error {
+
+	v, ok := data.(T)
+
+	if !ok {
+		return fmt.Errorf("Invalid data type (%T)", data)
+	}
+
+	return l.typed.Append(ctx, v)
+}
+
+// Reload will reload the underlying `TypedLookup` instance using 'uri' if it implements the `ReloadableLookup` interface.
+func (l *UntypedLookup[T]) Reload(ctx context.Context, uri string) error {
+
+	r, ok := l.typed.(ReloadableLookup)
+
+	if !ok {
+		return fmt.Errorf("Lookup does not support reloading, %w", errors.ErrUnsupported)
+	}
+
+	return r.Reload(ctx, uri)
+}
+
+// SnapshotForDate will return a `Snapshot` for 'date' derived from the underlying `TypedLookup` instance if it implements a
+// `SnapshotForDate` method returning a `*Snapshot[T]`.
+func (l *UntypedLookup[T]) SnapshotForDate(ctx context.Context, date string) (*Snapshot[interface{}], error) {
+
+	sl, ok := l.typed.(interface {
+		SnapshotForDate(context.Context, string) (*Snapshot[T], error)
+	})
+
+	if !ok {
+		return nil, fmt.Errorf("Lookup does not support snapshots, %w", errors.ErrUnsupported)
+	}
+
+	typed_s, err := sl.SnapshotForDate(ctx, date)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewSnapshot[interface{}](typed_s.Date)
+
+	for code, records := range typed_s.Records {
+
+		for _, r := range records {
+			s.Add(code, r)
+		}
+	}
+
+	return s, nil
+}
+
+// Codes will return the codes for the records matching 'filter' derived from the underlying `TypedLookup` instance if it implements
+// a `Codes` method.
+func (l *UntypedLookup[T]) Codes(ctx context.Context, filter *ListFilter) ([]string, error) {
+
+	cl, ok := l.typed.(interface {
+		Codes(context.Context, *ListFilter) ([]string, error)
+	})
+
+	if !ok {
+		return nil, fmt.Errorf("Lookup does not support listing codes, %w", errors.ErrUnsupported)
+	}
+
+	return cl.Codes(ctx, filter)
+}
+
+// All will return an iterator over the records matching 'filter' derived from the underlying `TypedLookup` instance if it implements
+// an `All` method returning an `iter.Seq[T]`.
+func (l *UntypedLookup[T]) All(ctx context.Context, filter *ListFilter) (iter.Seq[interface{}], error) {
+
+	al, ok := l.typed.(interface {
+		All(context.Context, *ListFilter) (iter.Seq[T], error)
+	})
+
+	if !ok {
+		return nil, fmt.Errorf("Lookup does not support listing records, %w", errors.ErrUnsupported)
+	}
+
+	typed_seq, err := al.All(ctx, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	seq := func(yield func(interface{}) bool) {
+
+		for r := range typed_seq {
+
+			if !yield(r) {
+				return
+			}
+		}
+	}
+
+	return seq, nil
+}
+
+// Len will return the number of records in the underlying `TypedLookup` instance if it implements a `Len` method.
+func (l *UntypedLookup[T]) Len(ctx context.Context) (int, error) {
+
+	ll, ok := l.typed.(interface {
+		Len(context.Context) (int, error)
+	})
+
+	if !ok {
+		return 0, fmt.Errorf("Lookup does not support counting records, %w", errors.ErrUnsupported)
+	}
+
+	return ll.Len(ctx)
+}
+
+// Update will replace the record with the same Who's On First ID as 'data' in the underlying `TypedLookup` instance if it implements
+// an `Update` method.
+func (l *UntypedLookup[T]) Update(ctx context.Context, data interface{}) error {
+
+	ml, ok := l.typed.(interface {
+		Update(context.Context, T) error
+	})
+
+	if !ok {
+		return fmt.Errorf("Lookup does not support updating records, %w", errors.ErrUnsupported)
+	}
+
+	v, ok := data.(T)
+
+	if !ok {
+		return fmt.Errorf("Invalid data type (%T)", data)
+	}
+
+	return ml.Update(ctx, v)
+}
+
+// Remove will remove the record with Who's On First ID 'id' from the underlying `TypedLookup` instance if it implements a `Remove` method.
+func (l *UntypedLookup[T]) Remove(ctx context.Context, id int64) error {
+
+	ml, ok := l.typed.(interface {
+		Remove(context.Context, int64) error
+	})
+
+	if !ok {
+		return fmt.Errorf("Lookup does not support removing records, %w", errors.ErrUnsupported)
+	}
+
+	return ml.Remove(ctx, id)
+}
+
+// AppendBatch will add 'data' to the underlying `TypedLookup` instance, together, if it implements an `AppendBatch` method.
+func (l *UntypedLookup[T]) AppendBatch(ctx context.Context, data []interface{}) error {
+
+	ml, ok := l.typed.(interface {
+		AppendBatch(context.Context, []T) error
+	})
+
+	if !ok {
+		return fmt.Errorf("Lookup does not support appending batches of records, %w", errors.ErrUnsupported)
+	}
+
+	typed_data := make([]T, len(data))
+
+	for idx, d := range data {
+
+		v, ok := d.(T)
+
+		if !ok {
+			return fmt.Errorf("Invalid data type (%T) at offset %d", d, idx)
+		}
+
+		typed_data[idx] = v
+	}
+
+	return ml.AppendBatch(ctx, typed_data)
+}
+
+// WriteTo will write all the records in the underlying `TypedLookup` instance to 'wr' if it implements a `WriteTo` method.
+func (l *UntypedLookup[T]) WriteTo(ctx context.Context, wr io.Writer) error {
+
+	el, ok := l.typed.(ExportableLookup)
+
+	if !ok {
+		return fmt.Errorf("Lookup does not support exporting records, %w", errors.ErrUnsupported)
+	}
+
+	return el.WriteTo(ctx, wr)
+}
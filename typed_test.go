@@ -0,0 +1,122 @@
+package architecture
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+type testRecord struct {
+	Code string
+}
+
+type testLookup struct {
+	records []interface{}
+}
+
+func (l *testLookup) Find(ctx context.Context, code string) ([]interface{}, error) {
+
+	results := make([]interface{}, 0)
+
+	for _, r := range l.records {
+
+		switch r.(type) {
+		case *testRecord:
+			if r.(*testRecord).Code != code {
+				continue
+			}
+		}
+
+		results = append(results, r)
+	}
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("Code '%s' not found", code)
+	}
+
+	return results, nil
+}
+
+func (l *testLookup) Append(ctx context.Context, data interface{}) error {
+	l.records = append(l.records, data)
+	return nil
+}
+
+func TestTypedLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	lu := &testLookup{
+		records: []interface{}{
+			&testRecord{Code: "A1"},
+		},
+	}
+
+	typed_lu := NewTypedLookup[*testRecord](lu)
+
+	err := typed_lu.Append(ctx, &testRecord{Code: "A2"})
+
+	if err != nil {
+		t.Fatalf("Failed to append record, %v", err)
+	}
+
+	results, err := typed_lu.Find(ctx, "A2")
+
+	if err != nil {
+		t.Fatalf("Failed to find A2, %v", err)
+	}
+
+	if len(results) != 1 || results[0].Code != "A2" {
+		t.Fatalf("Unexpected results for A2")
+	}
+
+	err = lu.Append(ctx, "not a record")
+
+	if err != nil {
+		t.Fatalf("Failed to append string, %v", err)
+	}
+
+	_, err = typed_lu.Find(ctx, "A1")
+
+	if err == nil {
+		t.Fatalf("Expected invalid result type error")
+	}
+}
+
+func TestUntypedLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	lu := &testLookup{
+		records: make([]interface{}, 0),
+	}
+
+	typed_lu := NewTypedLookup[*testRecord](lu)
+	untyped_lu := NewUntypedLookup[*testRecord](typed_lu)
+
+	err := untyped_lu.Append(ctx, &testRecord{Code: "B1"})
+
+	if err != nil {
+		t.Fatalf("Failed to append record, %v", err)
+	}
+
+	err = untyped_lu.Append(ctx, "B2")
+
+	if err == nil {
+		t.Fatalf("Expected invalid data type error")
+	}
+
+	results, err := untyped_lu.Find(ctx, "B1")
+
+	if err != nil {
+		t.Fatalf("Failed to find B1, %v", err)
+	}
+
+	if len(results) != 1 {
+		t.Fatalf("Unexpected results for B1")
+	}
+
+	if NewTypedLookup[*testRecord](untyped_lu) != typed_lu {
+		t.Fatalf("Expected original typed lookup")
+	}
+}